@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type SlackBlock struct {
+	Type     string       `json:"type"`
+	Text     *SlackText   `json:"text,omitempty"`
+	Elements []*SlackText `json:"elements,omitempty"`
+}
+
+type SlackMessage struct {
+	Channel  string        `json:"channel,omitempty"`
+	Username string        `json:"username,omitempty"`
+	Text     string        `json:"text"`
+	Blocks   []*SlackBlock `json:"blocks"`
+}
+
+type handler struct{}
+
+func (h *handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	var (
+		err  error
+		data []byte
+	)
+
+	data, err = io.ReadAll(req.Body)
+	if err != nil {
+		_, _ = fmt.Fprintf(res, "error: %v", err)
+
+		return
+	}
+
+	msg := &SlackMessage{}
+
+	err = json.Unmarshal(data, msg)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "json unmarshal error: %v, data: %s\n", err, data)
+
+		res.WriteHeader(http.StatusBadRequest)
+		_, _ = res.Write([]byte("invalid_payload"))
+
+		return
+	}
+
+	if len(msg.Blocks) == 0 && msg.Text == "" {
+		res.WriteHeader(http.StatusBadRequest)
+		_, _ = res.Write([]byte("no_text"))
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "[channel: %s, username: %s] %s\n", msg.Channel, msg.Username, msg.Text)
+
+	for _, block := range msg.Blocks {
+		if block.Text != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "%s\n", block.Text.Text)
+		}
+
+		for _, element := range block.Elements {
+			_, _ = fmt.Fprintf(os.Stdout, "%s\n", element.Text)
+		}
+	}
+
+	_, _ = res.Write([]byte("ok"))
+}
+
+func main() {
+	if err := http.ListenAndServe(":55322", &handler{}); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/vogo/logtail/internal/smtpmock"
+)
+
+func main() {
+	server, err := smtpmock.Listen(":55325", smtpmock.Config{}, func(msg *smtpmock.Message) {
+		_, _ = fmt.Fprintf(os.Stdout, "[from: %s, to: %s]\n%s\n", msg.From, strings.Join(msg.To, ", "), msg.Data)
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v", err)
+
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	<-signals
+
+	_ = server.Close()
+}
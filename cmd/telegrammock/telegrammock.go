@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type TelegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+type handler struct{}
+
+func (h *handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+
+	if !strings.HasSuffix(req.URL.Path, "/sendMessage") {
+		res.WriteHeader(http.StatusNotFound)
+		_, _ = res.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+
+		return
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(res, `{"ok":false,"error_code":400,"description":%q}`, err.Error())
+
+		return
+	}
+
+	msg := &TelegramMessage{}
+
+	if err = json.Unmarshal(data, msg); err != nil || msg.ChatID == "" || msg.Text == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "invalid message: %s\n", data)
+
+		res.WriteHeader(http.StatusBadRequest)
+		_, _ = res.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: message text is empty"}`))
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "[chat: %s, parse_mode: %s, length: %d]\n%s\n",
+		msg.ChatID, msg.ParseMode, len([]rune(msg.Text)), msg.Text)
+
+	_, _ = res.Write([]byte(`{"ok":true,"result":{}}`))
+}
+
+func main() {
+	if err := http.ListenAndServe(":55323", &handler{}); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v", err)
+	}
+}
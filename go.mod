@@ -4,10 +4,14 @@ go 1.25.0
 
 require (
 	github.com/gorilla/websocket v1.5.3
+	github.com/klauspost/compress v1.19.2
 	github.com/stretchr/testify v1.7.1
+	github.com/twmb/franz-go v1.21.7
+	github.com/twmb/franz-go/pkg/kfake v0.0.0-20251021233722-4ca18825d8c0
 	github.com/vogo/fwatch v1.6.1
 	github.com/vogo/vogo v0.0.0-20260219094625-1e3cccd1958b
 	golang.org/x/sys v0.42.0
+	google.golang.org/protobuf v1.36.12
 )
 
 require gopkg.in/yaml.v3 v3.0.1
@@ -15,5 +19,8 @@ require gopkg.in/yaml.v3 v3.0.1
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/fsnotify/fsnotify v1.9.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.26 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/twmb/franz-go/pkg/kmsg v1.13.1 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
 )
@@ -20,8 +20,10 @@ package conf
 import (
 	"errors"
 	"os"
+	"time"
 
 	"github.com/vogo/fwatch"
+	"github.com/vogo/logtail/internal/mask"
 	"github.com/vogo/logtail/internal/match"
 	"github.com/vogo/vogo/vlog"
 	"gopkg.in/yaml.v3"
@@ -42,6 +44,37 @@ var (
 	ErrTransTypeNil     = errors.New("transfer type is nil")
 	ErrTransTypeInvalid = errors.New("invalid transfer type")
 	ErrTransDirNil      = errors.New("transfer dir is nil")
+	ErrMatcherNil       = errors.New("matcher is nil")
+	ErrMatcherEmpty     = errors.New("nested matcher is empty")
+	ErrMatchPatternNil  = errors.New("match pattern is nil")
+	ErrRegexInvalid     = errors.New("invalid regex")
+	ErrFieldPathNil     = errors.New("field path is nil")
+	ErrFieldCondNil     = errors.New("field condition is nil")
+	ErrThresholdInvalid = errors.New("invalid threshold")
+	ErrSilenceInvalid   = errors.New("invalid silence duration")
+	ErrDedupInvalid     = errors.New("invalid dedup window")
+	ErrMaskInvalid      = errors.New("invalid mask config")
+	ErrRecordInvalid    = errors.New("invalid record flush timeout or size")
+	ErrLevelInvalid     = errors.New("invalid level")
+	ErrExtractInvalid   = errors.New("invalid extract pattern")
+	ErrTemplateInvalid  = errors.New("invalid message template")
+	ErrMsgTypeInvalid   = errors.New("invalid message type")
+	ErrBotTokenNil      = errors.New("transfer bot token is nil")
+	ErrChatIDNil        = errors.New("transfer chat id is nil")
+	ErrParseModeInvalid = errors.New("invalid parse mode")
+	ErrSMTPHostNil      = errors.New("transfer smtp host is nil")
+	ErrMailFromNil      = errors.New("transfer mail from is nil")
+	ErrMailToNil        = errors.New("transfer mail to is nil")
+	ErrMailInvalid      = errors.New("invalid mail address")
+	ErrTLSModeInvalid   = errors.New("invalid tls mode")
+	ErrDigestInvalid    = errors.New("invalid digest interval")
+	ErrBrokersNil       = errors.New("transfer brokers is nil")
+	ErrTopicNil         = errors.New("transfer topic is nil")
+	ErrKafkaInvalid     = errors.New("invalid kafka config")
+	ErrEncodingInvalid  = errors.New("invalid encoding")
+	ErrLabelInvalid     = errors.New("invalid label name")
+
+	ErrFormatPresetNotExist = errors.New("format preset not exists")
 )
 
 type Config struct {
@@ -53,6 +86,22 @@ type Config struct {
 	Transfers              map[string]*TransferConfig `json:"transfers"`
 	Routers                map[string]*RouterConfig   `json:"routers"`
 	Servers                map[string]*ServerConfig   `json:"servers"`
+
+	// CheckpointFile the file to save read positions of following files,
+	// default is the config file path with the suffix `.checkpoint`.
+	CheckpointFile string `json:"checkpoint_file,omitempty"`
+
+	// CheckpointIntervalSeconds the interval to flush the checkpoint file, default 5 seconds.
+	CheckpointIntervalSeconds int `json:"checkpoint_interval_seconds,omitempty"`
+}
+
+// GetCheckpointFile returns the checkpoint file, empty if no checkpoint file and no config file.
+func (c *Config) GetCheckpointFile() string {
+	if c.CheckpointFile != "" || c.file == "" {
+		return c.CheckpointFile
+	}
+
+	return c.file + ".checkpoint"
 }
 
 func (c *Config) GetRouters(routers []string) []*RouterConfig {
@@ -102,6 +151,15 @@ type ServerConfig struct {
 
 	// command to generate multiple commands split by new line.
 	File *FileConfig `json:"file,omitempty"`
+
+	// FlushTimeoutMs flushes the incomplete record after the milliseconds without new data, 0 means never.
+	FlushTimeoutMs int `json:"flush_timeout_ms,omitempty"`
+
+	// MaxRecordSize the max bytes of a record, the exceeded record is truncated with a marker, 0 means unlimited.
+	MaxRecordSize int `json:"max_record_size,omitempty"`
+
+	// SplitLargeRecord splits the record exceeding the max size into multiple records instead of truncating it.
+	SplitLargeRecord bool `json:"split_large_record,omitempty"`
 }
 
 // ServerTypes server types.
@@ -140,11 +198,108 @@ type RouterConfig struct {
 	Transfers    []string         `json:"transfers"`
 	BufferSize   int              `json:"buffer_size,omitempty"`
 	BlockingMode bool             `json:"blocking_mode,omitempty"`
+
+	// matcher groups, which must be satisfied together with the matchers.
+	AnyOf  []*MatcherConfig `json:"any_of,omitempty"`
+	AllOf  []*MatcherConfig `json:"all_of,omitempty"`
+	NoneOf []*MatcherConfig `json:"none_of,omitempty"`
+
+	// MinLevel skips the records below the level, e.g. `warn`, including the ones of unknown level.
+	MinLevel string `json:"min_level,omitempty"`
+
+	// Threshold transfers an alert only if the matched records reach the threshold.
+	Threshold *ThresholdConfig `json:"threshold,omitempty"`
+
+	// Silence transfers an alert if no record arrives in the duration, and a recovery when records resume.
+	Silence *SilenceConfig `json:"silence,omitempty"`
+
+	// Dedup transfers only the first record of the same fingerprint in a window, and a summary of the repeats.
+	Dedup *DedupConfig `json:"dedup,omitempty"`
+
+	// Mask masks sensitive data of the records before transferring.
+	Mask *MaskConfig `json:"mask,omitempty"`
 }
 
+// MaskConfig the rules to mask sensitive data, the user rules are applied before the built-in ones.
+type MaskConfig struct {
+	// Builtin the built-in rules: email, phone, id_card, credit_card, jwt, bearer, password, or all.
+	Builtin []string `json:"builtin,omitempty"`
+
+	// Rules the user rules.
+	Rules []*mask.Rule `json:"rules,omitempty"`
+}
+
+// DedupConfig deduplicates records by fingerprints in the window,
+// timestamps, numbers, hex ids and uuids are ignored when computing the fingerprint.
+type DedupConfig struct {
+	WindowSeconds int `json:"window_seconds"`
+}
+
+func (c *DedupConfig) GetWindow() time.Duration {
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// SilenceConfig alerts if no matched record arrives within the duration.
+type SilenceConfig struct {
+	DurationSeconds int `json:"duration_seconds"`
+
+	// AnyRecord checks any record received by the router instead of the matched ones,
+	// i.e. alerts when the source stops producing logs.
+	AnyRecord bool `json:"any_record,omitempty"`
+}
+
+func (c *SilenceConfig) GetDuration() time.Duration {
+	return time.Duration(c.DurationSeconds) * time.Second
+}
+
+// ThresholdConfig alerts if at least Count records matched within the window.
+type ThresholdConfig struct {
+	Count         int `json:"count"`
+	WindowSeconds int `json:"window_seconds"`
+}
+
+func (c *ThresholdConfig) GetWindow() time.Duration {
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// MatcherConfigs returns the matchers of the router, including the matcher groups.
+func (c *RouterConfig) MatcherConfigs() []*MatcherConfig {
+	if len(c.AnyOf) == 0 && len(c.AllOf) == 0 && len(c.NoneOf) == 0 {
+		return c.Matchers
+	}
+
+	configs := make([]*MatcherConfig, 0, len(c.Matchers)+1)
+	configs = append(configs, c.Matchers...)
+
+	return append(configs, &MatcherConfig{
+		AnyOf:  c.AnyOf,
+		AllOf:  c.AllOf,
+		NoneOf: c.NoneOf,
+	})
+}
+
+// MatcherConfig all conditions of the matcher must be satisfied.
 type MatcherConfig struct {
 	Contains    []string `json:"contains,omitempty"`
 	NotContains []string `json:"not_contains,omitempty"`
+	Regex       []string `json:"regex,omitempty"`
+	NotRegex    []string `json:"not_regex,omitempty"`
+
+	// Fields conditions on the extracted fields or the fields of JSON records, all must be satisfied.
+	Fields []*match.FieldCondition `json:"fields,omitempty"`
+
+	// IgnoreCase ignores the case of the contains, not_contains, regex and not_regex patterns,
+	// and the equals, in and regex conditions of the fields, by the unicode simple case folding.
+	IgnoreCase bool `json:"ignore_case,omitempty"`
+
+	// AnyOf at least one of the nested matchers must be satisfied.
+	AnyOf []*MatcherConfig `json:"any_of,omitempty"`
+
+	// AllOf all of the nested matchers must be satisfied.
+	AllOf []*MatcherConfig `json:"all_of,omitempty"`
+
+	// NoneOf none of the nested matchers can be satisfied.
+	NoneOf []*MatcherConfig `json:"none_of,omitempty"`
 }
 
 type TransferConfig struct {
@@ -159,4 +314,98 @@ type TransferConfig struct {
 	RateBurst       int     `json:"rate_burst,omitempty"`
 	BatchSize       int     `json:"batch_size,omitempty"`
 	BatchTimeout    string  `json:"batch_timeout,omitempty"`
+
+	// DisableDropInterval transfers every message for ding and lark,
+	// instead of dropping messages in 5 seconds after one, mostly used with the router dedup stage.
+	DisableDropInterval bool `json:"disable_drop_interval,omitempty"`
+
+	// Envelope posts the records in a JSON envelope with their metadata and extracted fields for webhook.
+	Envelope bool `json:"envelope,omitempty"`
+
+	// Template the Go text/template of the message for ding, lark, webhook, slack, wecom, telegram and email,
+	// e.g. `{{.Source}}: {{.Text}}`, see trans.MessageData for the fields.
+	Template string `json:"template,omitempty"`
+
+	// Channel and Username override the defaults of the slack incoming webhook.
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+
+	// MsgType the message type of wecom, `text` (default) or `markdown`.
+	MsgType string `json:"msg_type,omitempty"`
+
+	// MentionedList the user ids mentioned by wecom messages, `@all` for everyone in text messages.
+	MentionedList []string `json:"mentioned_list,omitempty"`
+
+	// MentionedMobileList the mobiles of the users mentioned by wecom text messages.
+	MentionedMobileList []string `json:"mentioned_mobile_list,omitempty"`
+
+	// BotToken and ChatID the token of the telegram bot and the id of the chat to send messages to,
+	// the url of telegram is the base url of the Bot API, defaults to https://api.telegram.org.
+	BotToken string `json:"bot_token,omitempty"`
+	ChatID   string `json:"chat_id,omitempty"`
+
+	// ParseMode the parse mode of telegram messages, `MarkdownV2` or `HTML`, plain text if empty.
+	ParseMode string `json:"parse_mode,omitempty"`
+
+	// Host and Port the SMTP server of email, the port defaults to 587 for starttls, 465 for tls and 25 for none.
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+
+	// TLSMode the tls mode of email, `starttls`, `tls` (implicit TLS) or `none`,
+	// defaults to tls for the port 465, otherwise starttls.
+	TLSMode string `json:"tls_mode,omitempty"`
+
+	// TLSSkipVerify skips verifying the certificate of the SMTP server, e.g. self-signed ones.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// AuthUsername and AuthPassword authenticate to the SMTP server by PLAIN if the username is not empty,
+	// and to elasticsearch and loki by the basic authentication.
+	AuthUsername string `json:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
+
+	// From and To the sender and recipients of email, e.g. `Logtail <logtail@example.com>`.
+	From string   `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+
+	// Subject the Go text/template of the email subject, see trans.MessageData for the fields.
+	Subject string `json:"subject,omitempty"`
+
+	// DigestInterval collects the records for the interval and sends them in one email, e.g. `1h`,
+	// the digest is sent in advance when the records reach the batch size, which defaults to 1000.
+	DigestInterval string `json:"digest_interval,omitempty"`
+
+	// Brokers the seed brokers of kafka, e.g. `127.0.0.1:9092`.
+	Brokers []string `json:"brokers,omitempty"`
+
+	// Topic the topic of kafka, or a Go text/template of the topic of each record, e.g. `logs-{{.Source}}`.
+	Topic string `json:"topic,omitempty"`
+
+	// Key the key of kafka records, `source`, `hostname` or `field:<name>` of an extracted field, no key if empty.
+	Key string `json:"key,omitempty"`
+
+	// Acks the acks of kafka, `all` (default), `leader` or `none`.
+	Acks string `json:"acks,omitempty"`
+
+	// Compression the compression of kafka, `snappy` (default), `none`, `gzip`, `lz4` or `zstd`.
+	Compression string `json:"compression,omitempty"`
+
+	// MaxBufferedRecords the max records of kafka waiting for delivery, the exceeded are dropped, default 10000.
+	MaxBufferedRecords int `json:"max_buffered_records,omitempty"`
+
+	// Index the Go text/template of the elasticsearch index of each record,
+	// defaults to `logtail-{{.Timestamp.UTC.Format "2006.01.02"}}`.
+	Index string `json:"index,omitempty"`
+
+	// APIKey the api key of elasticsearch, used instead of the basic authentication of auth_username.
+	APIKey string `json:"api_key,omitempty"`
+
+	// Labels the static labels of loki streams, besides the labels server, router and host of the records.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Encoding the encoding of loki push requests, `protobuf` (default, snappy compressed) or `json`,
+	// and of otlp export requests, `protobuf` (default) or `json`.
+	Encoding string `json:"encoding,omitempty"`
+
+	// TenantID the tenant of multi-tenant loki, sent in the header X-Scope-OrgID.
+	TenantID string `json:"tenant_id,omitempty"`
 }
@@ -19,7 +19,12 @@ package conf
 
 import (
 	"fmt"
+	"net/mail"
+	"regexp"
+	"time"
 
+	"github.com/vogo/logtail/internal/mask"
+	"github.com/vogo/logtail/internal/match"
 	"github.com/vogo/logtail/internal/trans"
 	"github.com/vogo/logtail/internal/util"
 	"github.com/vogo/vogo/vlog"
@@ -50,6 +55,10 @@ func InitialCheckConfig(config *Config) error {
 		return routerErr
 	}
 
+	if err := checkFormat(config.DefaultFormat); err != nil {
+		return err
+	}
+
 	for _, server := range config.Servers {
 		if serverErr := CheckServerConfig(config, server); serverErr != nil {
 			return serverErr
@@ -68,9 +77,51 @@ func CheckServerConfig(config *Config, server *ServerConfig) error {
 		vlog.Warnf("%v for server %s", ErrNoTailingConfig, server.Name)
 	}
 
+	if err := checkFormat(server.Format); err != nil {
+		return err
+	}
+
+	if server.FlushTimeoutMs < 0 || server.MaxRecordSize < 0 {
+		return fmt.Errorf("%w: server %s", ErrRecordInvalid, server.Name)
+	}
+
 	return checkRouterRef(config, server.Routers)
 }
 
+func checkFormat(format *match.Format) error {
+	if format == nil {
+		return nil
+	}
+
+	if format.Preset != "" && !match.IsFormatPreset(format.Preset) {
+		return fmt.Errorf("%w: %s", ErrFormatPresetNotExist, format.Preset)
+	}
+
+	for _, pattern := range []string{format.PrefixRegex, format.ContinuationRegex, format.TimeRegex, format.LevelRegex} {
+		if pattern == "" {
+			continue
+		}
+
+		if err := checkRegex(pattern); err != nil {
+			return err
+		}
+	}
+
+	for level := range format.LevelTokens {
+		if err := checkLevel(level); err != nil {
+			return err
+		}
+	}
+
+	for _, extract := range format.Extract {
+		if _, err := match.CompileExtractPattern(extract); err != nil {
+			return fmt.Errorf("%w: %v", ErrExtractInvalid, err)
+		}
+	}
+
+	return nil
+}
+
 func checkRouterConfigs(config *Config, routers map[string]*RouterConfig) error {
 	for _, router := range routers {
 		if err := CheckRouterConfig(config, router); err != nil {
@@ -86,13 +137,48 @@ func CheckRouterConfig(config *Config, router *RouterConfig) error {
 		return ErrRouterIDNil
 	}
 
-	if err := CheckMatchers(router.Matchers); err != nil {
+	if err := CheckMatchers(router.MatcherConfigs()); err != nil {
+		return err
+	}
+
+	if err := checkRouterStages(router); err != nil {
 		return err
 	}
 
 	return checkTransferRef(config, router.Transfers)
 }
 
+func checkRouterStages(router *RouterConfig) error {
+	if router.Threshold != nil && (router.Threshold.Count <= 0 || router.Threshold.WindowSeconds <= 0) {
+		return fmt.Errorf("%w: count %d, window %d seconds",
+			ErrThresholdInvalid, router.Threshold.Count, router.Threshold.WindowSeconds)
+	}
+
+	if router.Silence != nil && router.Silence.DurationSeconds <= 0 {
+		return fmt.Errorf("%w: %d seconds", ErrSilenceInvalid, router.Silence.DurationSeconds)
+	}
+
+	if router.Dedup != nil && router.Dedup.WindowSeconds <= 0 {
+		return fmt.Errorf("%w: %d seconds", ErrDedupInvalid, router.Dedup.WindowSeconds)
+	}
+
+	if router.Mask != nil {
+		if _, err := mask.NewMasker(router.Mask.Builtin, router.Mask.Rules); err != nil {
+			return fmt.Errorf("%w: %v", ErrMaskInvalid, err)
+		}
+	}
+
+	return checkLevel(router.MinLevel)
+}
+
+func checkLevel(level string) error {
+	if level != "" && match.ParseLevel(level) == match.LevelUnknown {
+		return fmt.Errorf("%w: %s", ErrLevelInvalid, level)
+	}
+
+	return nil
+}
+
 func checkRouterRef(config *Config, routers []string) error {
 	for _, r := range routers {
 		if _, ok := config.Routers[r]; !ok {
@@ -137,10 +223,50 @@ func checkTransferConfig(_ *Config, transferConfig *TransferConfig) error {
 	}
 
 	switch transferConfig.Type {
-	case trans.TypeWebhook, trans.TypeDing, trans.TypeLark:
+	case trans.TypeWebhook, trans.TypeDing, trans.TypeLark, trans.TypeSlack:
+		if transferConfig.URL == "" {
+			return ErrTransURLNil
+		}
+	case trans.TypeElasticsearch:
+		if transferConfig.URL == "" {
+			return ErrTransURLNil
+		}
+
+		if _, err := trans.ParseTemplate(transferConfig.Index); err != nil {
+			return fmt.Errorf("%w: %v", ErrTemplateInvalid, err)
+		}
+	case trans.TypeLoki:
+		if err := checkLokiConfig(transferConfig); err != nil {
+			return err
+		}
+	case trans.TypeOTLP:
+		switch transferConfig.Encoding {
+		case "", trans.OTLPEncodingProtobuf, trans.OTLPEncodingJSON:
+		default:
+			return fmt.Errorf("%w: %s", ErrEncodingInvalid, transferConfig.Encoding)
+		}
+	case trans.TypeWeCom:
 		if transferConfig.URL == "" {
 			return ErrTransURLNil
 		}
+
+		switch transferConfig.MsgType {
+		case "", trans.WeComMsgTypeText, trans.WeComMsgTypeMarkdown:
+		default:
+			return fmt.Errorf("%w: %s", ErrMsgTypeInvalid, transferConfig.MsgType)
+		}
+	case trans.TypeTelegram:
+		if err := checkTelegramConfig(transferConfig); err != nil {
+			return err
+		}
+	case trans.TypeEmail:
+		if err := checkEmailConfig(transferConfig); err != nil {
+			return err
+		}
+	case trans.TypeKafka:
+		if err := checkKafkaConfig(transferConfig); err != nil {
+			return err
+		}
 	case trans.TypeFile:
 		if transferConfig.Dir == "" {
 			return ErrTransDirNil
@@ -151,13 +277,201 @@ func checkTransferConfig(_ *Config, transferConfig *TransferConfig) error {
 		return fmt.Errorf("%w: %s", ErrTransTypeInvalid, transferConfig.Type)
 	}
 
+	if transferConfig.Template != "" {
+		if _, err := trans.ParseTemplate(transferConfig.Template); err != nil {
+			return fmt.Errorf("%w: %v", ErrTemplateInvalid, err)
+		}
+	}
+
+	return nil
+}
+
+func checkTelegramConfig(transferConfig *TransferConfig) error {
+	if transferConfig.BotToken == "" {
+		return ErrBotTokenNil
+	}
+
+	if transferConfig.ChatID == "" {
+		return ErrChatIDNil
+	}
+
+	switch transferConfig.ParseMode {
+	case "", trans.TelegramParseModeMarkdownV2, trans.TelegramParseModeHTML:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrParseModeInvalid, transferConfig.ParseMode)
+	}
+}
+
+func checkEmailConfig(transferConfig *TransferConfig) error {
+	if transferConfig.Host == "" {
+		return ErrSMTPHostNil
+	}
+
+	if transferConfig.From == "" {
+		return ErrMailFromNil
+	}
+
+	if len(transferConfig.To) == 0 {
+		return ErrMailToNil
+	}
+
+	for _, address := range append([]string{transferConfig.From}, transferConfig.To...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("%w: %s", ErrMailInvalid, address)
+		}
+	}
+
+	switch transferConfig.TLSMode {
+	case "", trans.EmailTLSModeStartTLS, trans.EmailTLSModeImplicit, trans.EmailTLSModeNone:
+	default:
+		return fmt.Errorf("%w: %s", ErrTLSModeInvalid, transferConfig.TLSMode)
+	}
+
+	if transferConfig.Subject != "" {
+		if _, err := trans.ParseTemplate(transferConfig.Subject); err != nil {
+			return fmt.Errorf("%w: %v", ErrTemplateInvalid, err)
+		}
+	}
+
+	if transferConfig.DigestInterval != "" {
+		if d, err := time.ParseDuration(transferConfig.DigestInterval); err != nil || d <= 0 {
+			return fmt.Errorf("%w: %s", ErrDigestInvalid, transferConfig.DigestInterval)
+		}
+	}
+
+	return nil
+}
+
+func checkKafkaConfig(transferConfig *TransferConfig) error {
+	if len(transferConfig.Brokers) == 0 {
+		return ErrBrokersNil
+	}
+
+	if transferConfig.Topic == "" {
+		return ErrTopicNil
+	}
+
+	if _, err := trans.ParseTemplate(transferConfig.Topic); err != nil {
+		return fmt.Errorf("%w: %v", ErrTemplateInvalid, err)
+	}
+
+	err := trans.CheckKafkaOptions(trans.KafkaTransferOptions{
+		Brokers:     transferConfig.Brokers,
+		Key:         transferConfig.Key,
+		Acks:        transferConfig.Acks,
+		Compression: transferConfig.Compression,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKafkaInvalid, err)
+	}
+
 	return nil
 }
 
 func checkMatchConfig(config *MatcherConfig) error {
-	if len(config.Contains) == 0 && len(config.NotContains) == 0 {
+	if config == nil {
+		return ErrMatcherNil
+	}
+
+	if matcherConfigEmpty(config) {
 		vlog.Debugf("match contains is nil")
 	}
 
+	for _, patterns := range [][]string{config.Contains, config.NotContains} {
+		for _, pattern := range patterns {
+			if pattern == "" {
+				return ErrMatchPatternNil
+			}
+		}
+	}
+
+	for _, patterns := range [][]string{config.Regex, config.NotRegex} {
+		for _, pattern := range patterns {
+			if err := checkRegex(pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, field := range config.Fields {
+		if err := checkFieldCondition(field); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range [][]*MatcherConfig{config.AnyOf, config.AllOf, config.NoneOf} {
+		if err := checkGroupMatchers(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkGroupMatchers checks the nested matchers of a group, which must have conditions,
+// as an empty nested matcher matches all lines, e.g. `any_of: [{}]` matches all.
+func checkGroupMatchers(matchers []*MatcherConfig) error {
+	for _, config := range matchers {
+		if config != nil && matcherConfigEmpty(config) {
+			return ErrMatcherEmpty
+		}
+	}
+
+	return CheckMatchers(matchers)
+}
+
+// matcherConfigEmpty whether the matcher config has no condition.
+func matcherConfigEmpty(config *MatcherConfig) bool {
+	return len(config.Contains) == 0 && len(config.NotContains) == 0 &&
+		len(config.Regex) == 0 && len(config.NotRegex) == 0 && len(config.Fields) == 0 &&
+		len(config.AnyOf) == 0 && len(config.AllOf) == 0 && len(config.NoneOf) == 0
+}
+
+func checkFieldCondition(field *match.FieldCondition) error {
+	if field == nil || !field.HasCondition() {
+		return ErrFieldCondNil
+	}
+
+	if field.Path == "" {
+		return ErrFieldPathNil
+	}
+
+	if field.Regex != "" {
+		return checkRegex(field.Regex)
+	}
+
+	return nil
+}
+
+func checkRegex(pattern string) error {
+	if pattern == "" {
+		return ErrMatchPatternNil
+	}
+
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("%w: %s, %v", ErrRegexInvalid, pattern, err)
+	}
+
+	return nil
+}
+
+func checkLokiConfig(transferConfig *TransferConfig) error {
+	if transferConfig.URL == "" {
+		return ErrTransURLNil
+	}
+
+	switch transferConfig.Encoding {
+	case "", trans.LokiEncodingProtobuf, trans.LokiEncodingJSON:
+	default:
+		return fmt.Errorf("%w: %s", ErrEncodingInvalid, transferConfig.Encoding)
+	}
+
+	for name := range transferConfig.Labels {
+		if !trans.IsLokiLabelName(name) {
+			return fmt.Errorf("%w: %s", ErrLabelInvalid, name)
+		}
+	}
+
 	return nil
 }
@@ -20,9 +20,12 @@ package conf_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/mask"
+	"github.com/vogo/logtail/internal/match"
 )
 
 func TestInitialCheckConfig_Valid(t *testing.T) {
@@ -129,6 +132,84 @@ func TestCheckServerConfig(t *testing.T) {
 		err := conf.CheckServerConfig(config, &conf.ServerConfig{Name: "s1", Command: "echo", Routers: []string{"missing"}})
 		assert.ErrorIs(t, err, conf.ErrRouterNotExist)
 	})
+
+	t.Run("FormatRegex", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo",
+			Format: &match.Format{PrefixRegex: `\[\d{4}-`, ContinuationRegex: `\s+at `},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo",
+			Format: &match.Format{PrefixRegex: "[a-"},
+		})
+		assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+
+		err = conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo",
+			Format: &match.Format{TimeLayout: time.RFC3339, TimeRegex: "ts=(\\S+"},
+		})
+		assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+
+		err = conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo",
+			Format: &match.Format{LevelTokens: map[string][]string{"err": {"E"}, "severe": {"S"}}},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo",
+			Format: &match.Format{LevelTokens: map[string][]string{"high": {"H"}}},
+		})
+		assert.ErrorIs(t, err, conf.ErrLevelInvalid)
+	})
+
+	t.Run("FormatPreset", func(t *testing.T) {
+		t.Parallel()
+
+		for _, preset := range []string{match.PresetAuto, match.PresetGlog} {
+			err := conf.CheckServerConfig(config, &conf.ServerConfig{
+				Name: "s1", Command: "echo", Format: &match.Format{Preset: preset},
+			})
+			assert.NoError(t, err)
+		}
+
+		err := conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo", Format: &match.Format{Preset: "nginx-error"},
+		})
+		assert.ErrorIs(t, err, conf.ErrFormatPresetNotExist)
+	})
+
+	t.Run("RecordLimit", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo", FlushTimeoutMs: 2000, MaxRecordSize: 1024, SplitLargeRecord: true,
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckServerConfig(config, &conf.ServerConfig{Name: "s1", Command: "echo", MaxRecordSize: -1})
+		assert.ErrorIs(t, err, conf.ErrRecordInvalid)
+	})
+
+	t.Run("Extract", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckServerConfig(config, &conf.ServerConfig{
+			Name: "s1", Command: "echo", Format: &match.Format{Extract: []string{`%{IP:client} (?P<cost>\d+)ms`}},
+		})
+		assert.NoError(t, err)
+
+		for _, extract := range []string{`%{UNKNOWN:x}`, `%{INT} ms`, `(?P<x>\d+`} {
+			err = conf.CheckServerConfig(config, &conf.ServerConfig{
+				Name: "s1", Command: "echo", Format: &match.Format{Extract: []string{extract}},
+			})
+			assert.ErrorIs(t, err, conf.ErrExtractInvalid, extract)
+		}
+	})
 }
 
 func TestCheckRouterConfig(t *testing.T) {
@@ -160,6 +241,86 @@ func TestCheckRouterConfig(t *testing.T) {
 		err := conf.CheckRouterConfig(config, &conf.RouterConfig{Name: "r1", Transfers: []string{"missing"}})
 		assert.ErrorIs(t, err, conf.ErrTransferNotExist)
 	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Threshold: &conf.ThresholdConfig{Count: 20, WindowSeconds: 60},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Threshold: &conf.ThresholdConfig{Count: 20},
+		})
+		assert.ErrorIs(t, err, conf.ErrThresholdInvalid)
+	})
+
+	t.Run("Silence", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Silence: &conf.SilenceConfig{DurationSeconds: 300, AnyRecord: true},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Silence: &conf.SilenceConfig{},
+		})
+		assert.ErrorIs(t, err, conf.ErrSilenceInvalid)
+	})
+
+	t.Run("Dedup", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Dedup: &conf.DedupConfig{WindowSeconds: 300},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Dedup: &conf.DedupConfig{WindowSeconds: -1},
+		})
+		assert.ErrorIs(t, err, conf.ErrDedupInvalid)
+	})
+
+	t.Run("Mask", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Mask: &conf.MaskConfig{Builtin: []string{"all"}, Rules: []*mask.Rule{{Regex: `card=\d+`}}},
+		})
+		assert.NoError(t, err)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Mask: &conf.MaskConfig{Builtin: []string{"address"}},
+		})
+		assert.ErrorIs(t, err, conf.ErrMaskInvalid)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{
+			Name: "r1", Transfers: []string{"t1"},
+			Mask: &conf.MaskConfig{Rules: []*mask.Rule{{Regex: "card=("}}},
+		})
+		assert.ErrorIs(t, err, conf.ErrMaskInvalid)
+	})
+
+	t.Run("MinLevel", func(t *testing.T) {
+		t.Parallel()
+
+		err := conf.CheckRouterConfig(config, &conf.RouterConfig{Name: "r1", Transfers: []string{"t1"}, MinLevel: "WARNING"})
+		assert.NoError(t, err)
+
+		err = conf.CheckRouterConfig(config, &conf.RouterConfig{Name: "r1", Transfers: []string{"t1"}, MinLevel: "high"})
+		assert.ErrorIs(t, err, conf.ErrLevelInvalid)
+	})
 }
 
 func TestCheckMatchers(t *testing.T) {
@@ -173,6 +334,68 @@ func TestCheckMatchers(t *testing.T) {
 
 	err = conf.CheckMatchers([]*conf.MatcherConfig{{}})
 	assert.NoError(t, err) // empty matchers only log debug
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Contains: []string{""}}})
+	assert.ErrorIs(t, err, conf.ErrMatchPatternNil)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Regex: []string{`status=5\d\d`}, NotRegex: []string{`took \d+ms`}}})
+	assert.NoError(t, err)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Regex: []string{"status=("}}})
+	assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{NotRegex: []string{"[a-"}}})
+	assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{AnyOf: []*conf.MatcherConfig{{Regex: []string{"[a-"}}}}})
+	assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{NoneOf: []*conf.MatcherConfig{nil}}})
+	assert.ErrorIs(t, err, conf.ErrMatcherNil)
+
+	// an empty nested matcher would match all lines.
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{AnyOf: []*conf.MatcherConfig{{}}}})
+	assert.ErrorIs(t, err, conf.ErrMatcherEmpty)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{
+		{AllOf: []*conf.MatcherConfig{{Contains: []string{"ERROR"}}, {NoneOf: []*conf.MatcherConfig{{}}}}},
+	})
+	assert.ErrorIs(t, err, conf.ErrMatcherEmpty)
+}
+
+func TestCheckMatchers_Fields(t *testing.T) {
+	t.Parallel()
+
+	status := 499.0
+
+	err := conf.CheckMatchers([]*conf.MatcherConfig{{Fields: []*match.FieldCondition{{Path: "http.status", Gt: &status}}}})
+	assert.NoError(t, err)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Fields: []*match.FieldCondition{{Path: "level"}}}})
+	assert.ErrorIs(t, err, conf.ErrFieldCondNil)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Fields: []*match.FieldCondition{nil}}})
+	assert.ErrorIs(t, err, conf.ErrFieldCondNil)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Fields: []*match.FieldCondition{{In: []string{"error"}}}}})
+	assert.ErrorIs(t, err, conf.ErrFieldPathNil)
+
+	err = conf.CheckMatchers([]*conf.MatcherConfig{{Fields: []*match.FieldCondition{{Path: "msg", Regex: "[a-"}}}})
+	assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+}
+
+func TestRouterConfigMatcherConfigs(t *testing.T) {
+	t.Parallel()
+
+	matchers := []*conf.MatcherConfig{{Contains: []string{"ERROR"}}}
+
+	router := &conf.RouterConfig{Name: "r1", Matchers: matchers}
+	assert.Equal(t, matchers, router.MatcherConfigs())
+
+	router.AnyOf = []*conf.MatcherConfig{{Contains: []string{"FATAL"}}}
+	configs := router.MatcherConfigs()
+	assert.Len(t, configs, 2)
+	assert.Equal(t, router.AnyOf, configs[1].AnyOf)
 }
 
 func TestCheckTransferConfig(t *testing.T) {
@@ -198,6 +421,115 @@ func TestCheckTransferConfig(t *testing.T) {
 		{"DingValid", &conf.TransferConfig{Name: "t", Type: "ding", URL: "http://x"}, nil},
 		{"LarkNoURL", &conf.TransferConfig{Name: "t", Type: "lark"}, conf.ErrTransURLNil},
 		{"LarkValid", &conf.TransferConfig{Name: "t", Type: "lark", URL: "http://x"}, nil},
+		{"SlackNoURL", &conf.TransferConfig{Name: "t", Type: "slack"}, conf.ErrTransURLNil},
+		{"SlackValid", &conf.TransferConfig{Name: "t", Type: "slack", URL: "http://x", Channel: "#alerts"}, nil},
+		{"WeComNoURL", &conf.TransferConfig{Name: "t", Type: "wecom"}, conf.ErrTransURLNil},
+		{"WeComValid", &conf.TransferConfig{Name: "t", Type: "wecom", URL: "http://x", MsgType: "markdown"}, nil},
+		{
+			"WeComMsgTypeInvalid",
+			&conf.TransferConfig{Name: "t", Type: "wecom", URL: "http://x", MsgType: "news"},
+			conf.ErrMsgTypeInvalid,
+		},
+		{"TelegramNoBotToken", &conf.TransferConfig{Name: "t", Type: "telegram", ChatID: "1"}, conf.ErrBotTokenNil},
+		{"TelegramNoChatID", &conf.TransferConfig{Name: "t", Type: "telegram", BotToken: "x"}, conf.ErrChatIDNil},
+		{"TelegramValid", &conf.TransferConfig{Name: "t", Type: "telegram", BotToken: "x", ChatID: "1"}, nil},
+		{
+			"TelegramParseModeInvalid",
+			&conf.TransferConfig{Name: "t", Type: "telegram", BotToken: "x", ChatID: "1", ParseMode: "Markdown"},
+			conf.ErrParseModeInvalid,
+		},
+		{"EmailNoHost", &conf.TransferConfig{Name: "t", Type: "email"}, conf.ErrSMTPHostNil},
+		{"EmailNoFrom", &conf.TransferConfig{Name: "t", Type: "email", Host: "smtp"}, conf.ErrMailFromNil},
+		{"EmailNoTo", &conf.TransferConfig{Name: "t", Type: "email", Host: "smtp", From: "a@x.com"}, conf.ErrMailToNil},
+		{
+			"EmailValid",
+			&conf.TransferConfig{
+				Name: "t", Type: "email", Host: "smtp", From: "Logtail <a@x.com>", To: []string{"b@x.com"},
+				TLSMode: "tls", Subject: "{{.Source}}", DigestInterval: "30m",
+			},
+			nil,
+		},
+		{
+			"EmailAddressInvalid",
+			&conf.TransferConfig{Name: "t", Type: "email", Host: "smtp", From: "a@x.com", To: []string{"b"}},
+			conf.ErrMailInvalid,
+		},
+		{
+			"EmailTLSModeInvalid",
+			&conf.TransferConfig{
+				Name: "t", Type: "email", Host: "smtp", From: "a@x.com", To: []string{"b@x.com"}, TLSMode: "ssl",
+			},
+			conf.ErrTLSModeInvalid,
+		},
+		{
+			"EmailSubjectInvalid",
+			&conf.TransferConfig{
+				Name: "t", Type: "email", Host: "smtp", From: "a@x.com", To: []string{"b@x.com"}, Subject: "{{",
+			},
+			conf.ErrTemplateInvalid,
+		},
+		{
+			"EmailDigestInvalid",
+			&conf.TransferConfig{
+				Name: "t", Type: "email", Host: "smtp", From: "a@x.com", To: []string{"b@x.com"}, DigestInterval: "1",
+			},
+			conf.ErrDigestInvalid,
+		},
+		{"KafkaNoBrokers", &conf.TransferConfig{Name: "t", Type: "kafka"}, conf.ErrBrokersNil},
+		{"KafkaNoTopic", &conf.TransferConfig{Name: "t", Type: "kafka", Brokers: []string{"k:9092"}}, conf.ErrTopicNil},
+		{
+			"KafkaValid",
+			&conf.TransferConfig{
+				Name: "t", Type: "kafka", Brokers: []string{"k:9092"}, Topic: "logs-{{.Source}}",
+				Key: "field:trace_id", Acks: "none", Compression: "gzip",
+			},
+			nil,
+		},
+		{
+			"KafkaTopicInvalid",
+			&conf.TransferConfig{Name: "t", Type: "kafka", Brokers: []string{"k:9092"}, Topic: "logs-{{.Source"},
+			conf.ErrTemplateInvalid,
+		},
+		{
+			"KafkaAcksInvalid",
+			&conf.TransferConfig{Name: "t", Type: "kafka", Brokers: []string{"k:9092"}, Topic: "logs", Acks: "2"},
+			conf.ErrKafkaInvalid,
+		},
+		{"ElasticsearchNoURL", &conf.TransferConfig{Name: "t", Type: "elasticsearch"}, conf.ErrTransURLNil},
+		{"ElasticsearchValid", &conf.TransferConfig{Name: "t", Type: "elasticsearch", URL: "http://x"}, nil},
+		{
+			"ElasticsearchIndexInvalid",
+			&conf.TransferConfig{Name: "t", Type: "elasticsearch", URL: "http://x", Index: "logs-{{.Source"},
+			conf.ErrTemplateInvalid,
+		},
+		{"LokiNoURL", &conf.TransferConfig{Name: "t", Type: "loki"}, conf.ErrTransURLNil},
+		{
+			"LokiValid",
+			&conf.TransferConfig{Name: "t", Type: "loki", URL: "http://x", Labels: map[string]string{"env": "prod"}},
+			nil,
+		},
+		{
+			"LokiEncodingInvalid",
+			&conf.TransferConfig{Name: "t", Type: "loki", URL: "http://x", Encoding: "xml"},
+			conf.ErrEncodingInvalid,
+		},
+		{
+			"LokiLabelInvalid",
+			&conf.TransferConfig{Name: "t", Type: "loki", URL: "http://x", Labels: map[string]string{"app-env": "prod"}},
+			conf.ErrLabelInvalid,
+		},
+		{"OTLPValid", &conf.TransferConfig{Name: "t", Type: "otlp"}, nil},
+		{
+			"OTLPEncodingInvalid",
+			&conf.TransferConfig{Name: "t", Type: "otlp", URL: "http://x:4318", Encoding: "grpc"},
+			conf.ErrEncodingInvalid,
+		},
+		{"TemplateValid", &conf.TransferConfig{Name: "t", Type: "ding", URL: "http://x", Template: "{{.Text}}"}, nil},
+		{
+			"TemplateInvalid",
+			&conf.TransferConfig{Name: "t", Type: "lark", URL: "http://x", Template: "{{.Text"},
+			conf.ErrTemplateInvalid,
+		},
 	}
 
 	for _, tt := range tests {
@@ -221,3 +221,14 @@ func TestConfigSaveToFile_EmptyPath(t *testing.T) {
 	config := &Config{}
 	config.SaveToFile() // should not panic
 }
+
+func TestConfigGetCheckpointFile(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, (&Config{}).GetCheckpointFile())
+	assert.Equal(t, "/etc/logtail.json.checkpoint", (&Config{file: "/etc/logtail.json"}).GetCheckpointFile())
+	assert.Equal(t, "/var/lib/logtail.checkpoint", (&Config{
+		file:           "/etc/logtail.json",
+		CheckpointFile: "/var/lib/logtail.checkpoint",
+	}).GetCheckpointFile())
+}
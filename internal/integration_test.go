@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/route"
 	"github.com/vogo/logtail/internal/starter"
 	"github.com/vogo/logtail/internal/tail"
@@ -179,14 +180,14 @@ func TestIntegrationDropCounterUnderLoad(t *testing.T) {
 		ID:           "drop-load-test",
 		Name:         "drop-load-test",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: false,
 	}
 
 	// Send 100 messages rapidly without consuming -- most will be dropped.
 	for i := range totalMessages {
-		router.Receive(fmt.Appendf(nil, "msg-%d", i))
+		router.Receive(record.New("", "", fmt.Appendf(nil, "msg-%d", i)))
 	}
 
 	// Drain the channel to count delivered messages.
@@ -225,19 +226,19 @@ func TestIntegrationBlockingMode(t *testing.T) {
 		ID:           "blocking-integ",
 		Name:         "blocking-integ",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: true,
 	}
 
 	// Fill the channel to capacity.
-	router.Channel <- []byte("fill")
+	router.Channel <- record.New("", "", []byte("fill"))
 
 	// Receive in a goroutine -- should block because channel is full.
 	done := make(chan struct{})
 
 	go func() {
-		router.Receive([]byte("blocked-msg"))
+		router.Receive(record.New("", "", []byte("blocked-msg")))
 		close(done)
 	}()
 
@@ -282,19 +283,19 @@ func TestIntegrationBlockingModeRespectsStop(t *testing.T) {
 		ID:           "stop-integ",
 		Name:         "stop-integ",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: true,
 	}
 
 	// Fill the channel.
-	router.Channel <- []byte("fill")
+	router.Channel <- record.New("", "", []byte("fill"))
 
 	// Receive in a goroutine -- should block.
 	done := make(chan struct{})
 
 	go func() {
-		router.Receive([]byte("blocked-msg"))
+		router.Receive(record.New("", "", []byte("blocked-msg")))
 		close(done)
 	}()
 
@@ -364,7 +365,7 @@ func TestIntegrationStatsAPI(t *testing.T) {
 		for _, router := range worker.Routers {
 			// Fill the channel and then send extra messages to trigger drops.
 			for range 20 {
-				router.Receive([]byte("load-msg"))
+				router.Receive(record.New("", "", []byte("load-msg")))
 			}
 		}
 	}
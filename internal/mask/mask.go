@@ -0,0 +1,209 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mask masks sensitive data in records before they are transferred.
+package mask
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrRuleNotExist = errors.New("mask rule not exists")
+
+// DefaultReplacement the default replacement of the masked data.
+const DefaultReplacement = "******"
+
+// names of the built-in rules.
+const (
+	RuleAll        = "all"
+	RuleEmail      = "email"
+	RulePhone      = "phone"
+	RuleIDCard     = "id_card"
+	RuleCreditCard = "credit_card"
+	RuleJWT        = "jwt"
+	RuleBearer     = "bearer"
+	RulePassword   = "password"
+)
+
+// Rule masks the data matching the regular expression with the replacement,
+// which may refer to the groups of the expression, e.g. `${1}******`.
+type Rule struct {
+	Regex       string `json:"regex"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type rule struct {
+	regexp      *regexp.Regexp
+	replacement []byte
+
+	// validate whether the matched data should be masked, nil means always.
+	validate func([]byte) bool
+}
+
+// builtinRules the built-in rules in the order to apply.
+//
+//nolint:gochecknoglobals // ignore this
+var builtinRules = []struct {
+	name string
+	rule *rule
+}{
+	{RuleJWT, &rule{regexp: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)}},
+	{RuleBearer, &rule{
+		regexp:      regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]+=*`),
+		replacement: []byte("${1}" + DefaultReplacement),
+	}},
+	{RulePassword, &rule{
+		regexp:      regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret)("?\s*[=:]\s*"?)[^\s"&,;]+`),
+		replacement: []byte("${1}${2}" + DefaultReplacement),
+	}},
+	{RuleEmail, &rule{regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)}},
+	{RuleIDCard, &rule{regexp: regexp.MustCompile(`\b\d{17}[\dXx]\b`)}},
+	{RuleCreditCard, &rule{
+		regexp:   regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		validate: luhn,
+	}},
+	{RulePhone, &rule{regexp: regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?\b1[3-9]\d{9}\b`)}},
+}
+
+// IsBuiltinRule whether the name is a built-in rule or `all`.
+func IsBuiltinRule(name string) bool {
+	if name == RuleAll {
+		return true
+	}
+
+	for _, builtin := range builtinRules {
+		if builtin.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Masker masks sensitive data by the rules.
+type Masker struct {
+	rules []*rule
+}
+
+// NewMasker new masker of the built-in rules and the user rules, the user rules are applied first.
+// It returns error if a built-in rule not exists or a regular expression is invalid.
+func NewMasker(builtin []string, rules []*Rule) (*Masker, error) {
+	masker := &Masker{}
+
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask regex %s: %w", r.Regex, err)
+		}
+
+		replacement := r.Replacement
+		if replacement == "" {
+			replacement = DefaultReplacement
+		}
+
+		masker.rules = append(masker.rules, &rule{regexp: re, replacement: []byte(replacement)})
+	}
+
+	for _, name := range builtin {
+		if !IsBuiltinRule(name) {
+			return nil, fmt.Errorf("%w: %s", ErrRuleNotExist, name)
+		}
+	}
+
+	for _, b := range builtinRules {
+		if slices.Contains(builtin, RuleAll) || slices.Contains(builtin, b.name) {
+			masker.rules = append(masker.rules, b.rule)
+		}
+	}
+
+	return masker, nil
+}
+
+// Mask returns the data with the sensitive data masked, the data is not changed.
+func (m *Masker) Mask(data []byte) []byte {
+	for _, r := range m.rules {
+		data = r.mask(data)
+	}
+
+	return data
+}
+
+// MaskFields returns the fields with the sensitive values masked, the fields are not changed.
+// A field is masked in the form `name=value`, so that the rules on the names also apply, e.g. the password rule.
+func (m *Masker) MaskFields(fields map[string]string) map[string]string {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	masked := make(map[string]string, len(fields))
+
+	for name, value := range fields {
+		prefix := name + "="
+
+		// the name is masked with the value by a rule if the prefix is absent, then take all as the value.
+		masked[name], _ = strings.CutPrefix(string(m.Mask([]byte(prefix+value))), prefix)
+	}
+
+	return masked
+}
+
+func (r *rule) mask(data []byte) []byte {
+	replacement := r.replacement
+	if replacement == nil {
+		replacement = []byte(DefaultReplacement)
+	}
+
+	if r.validate == nil {
+		return r.regexp.ReplaceAll(data, replacement)
+	}
+
+	return r.regexp.ReplaceAllFunc(data, func(matched []byte) []byte {
+		if !r.validate(matched) {
+			return matched
+		}
+
+		return r.regexp.Expand(nil, replacement, matched, r.regexp.FindSubmatchIndex(matched))
+	})
+}
+
+// luhn checks the digits in the data by the luhn algorithm.
+func luhn(data []byte) bool {
+	sum, double := 0, false
+
+	for i := len(data) - 1; i >= 0; i-- {
+		if data[i] < '0' || data[i] > '9' {
+			continue
+		}
+
+		digit := int(data[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mask_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/mask"
+)
+
+func TestMasker_Builtin(t *testing.T) {
+	t.Parallel()
+
+	masker, err := mask.NewMasker([]string{mask.RuleAll}, nil)
+	require.NoError(t, err)
+
+	for data, expected := range map[string]string{
+		"user alice@example.com login":                     "user ****** login",
+		"手机13812345678登录失败, call +86 13912345678":          "手机******登录失败, call ******",
+		"id card 11010519491231002X verify failed":         "id card ****** verify failed",
+		"pay by 4111 1111 1111 1111 failed":                "pay by ****** failed",
+		"order 1234567890123456 not luhn":                  "order 1234567890123456 not luhn",
+		"Authorization: Bearer abc.DEF-123_x= end":         "Authorization: Bearer ****** end",
+		"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig-1": "token ******",
+		"login password=s3cret&user=bob":                   "login password=******&user=bob",
+		`{"user":"bob","Password": "s3cret","age":3}`:      `{"user":"bob","Password": "******","age":3}`,
+		"2024-01-02 10:11:12.345 ERROR order 1001 timeout": "2024-01-02 10:11:12.345 ERROR order 1001 timeout",
+	} {
+		assert.Equal(t, expected, string(masker.Mask([]byte(data))), data)
+	}
+}
+
+func TestMasker_MaskFields(t *testing.T) {
+	t.Parallel()
+
+	masker, err := mask.NewMasker([]string{mask.RulePassword, mask.RulePhone}, []*mask.Rule{{Regex: `user=\w+`}})
+	require.NoError(t, err)
+
+	fields := map[string]string{"password": "s3cret", "mobile": "13812345678", "user": "bob", "order": "1001"}
+
+	assert.Equal(t, map[string]string{"password": "******", "mobile": "******", "user": "******", "order": "1001"},
+		masker.MaskFields(fields))
+	assert.Equal(t, "s3cret", fields["password"])
+	assert.Nil(t, masker.MaskFields(nil))
+}
+
+func TestMasker_Rules(t *testing.T) {
+	t.Parallel()
+
+	masker, err := mask.NewMasker([]string{mask.RuleEmail}, []*mask.Rule{
+		{Regex: `(card_no=)\d+`, Replacement: "${1}<card>"},
+		{Regex: `api_key=\w+`},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "card_no=<card> ****** ****** phone 13812345678",
+		string(masker.Mask([]byte("card_no=622202 api_key=abc123 bob@example.com phone 13812345678"))))
+
+	_, err = mask.NewMasker([]string{"address"}, nil)
+	assert.ErrorIs(t, err, mask.ErrRuleNotExist)
+
+	_, err = mask.NewMasker(nil, []*mask.Rule{{Regex: "card=("}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrGrokPatternNotExist = errors.New("grok pattern not exists")
+	ErrNoNamedGroup        = errors.New("no named group")
+)
+
+// grokPatterns the named patterns referenced by `%{NAME}` or `%{NAME:field}` in extract patterns.
+//
+//nolint:gochecknoglobals // ignore this
+var grokPatterns = map[string]string{
+	"WORD":              `\w+`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"BASE16NUM":         `(?:0[xX])?[0-9A-Fa-f]+`,
+	"UUID":              `[0-9A-Fa-f]{8}-(?:[0-9A-Fa-f]{4}-){3}[0-9A-Fa-f]{12}`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[0-9A-Fa-f]*:[0-9A-Fa-f:.]+`,
+	"IP":                `(?:(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f]*:[0-9A-Fa-f:.]+)`,
+	"HOSTNAME":          `[0-9A-Za-z][0-9A-Za-z.-]*`,
+	"URIPATH":           `/[^\s?#]*`,
+	"URIPATHPARAM":      `/[^\s#]*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"QS":                `"(?:[^"\\]|\\.)*"`,
+	"DURATION":          `[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:ns|us|µs|ms|s|m|h)`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|panic)`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+	"HTTPDATE":          `\d{2}/[A-Za-z]{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+}
+
+//nolint:gochecknoglobals // ignore this
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::(\w+))?\}`)
+
+// GrokPatterns returns the names of the grok patterns.
+func GrokPatterns() []string {
+	names := make([]string, 0, len(grokPatterns))
+
+	for name := range grokPatterns {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// ExpandGrok expands the grok references in the pattern to regular expressions,
+// `%{NAME:field}` to a group named by the field, and `%{NAME}` to a non-capturing group.
+func ExpandGrok(pattern string) (string, error) {
+	var (
+		builder strings.Builder
+		last    int
+	)
+
+	for _, loc := range grokReference.FindAllStringSubmatchIndex(pattern, -1) {
+		name := pattern[loc[2]:loc[3]]
+
+		expr, ok := grokPatterns[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrGrokPatternNotExist, name)
+		}
+
+		builder.WriteString(pattern[last:loc[0]])
+
+		if loc[4] >= 0 {
+			builder.WriteString("(?P<" + pattern[loc[4]:loc[5]] + ">" + expr + ")")
+		} else {
+			builder.WriteString("(?:" + expr + ")")
+		}
+
+		last = loc[1]
+	}
+
+	builder.WriteString(pattern[last:])
+
+	return builder.String(), nil
+}
+
+// CompileExtractPattern compiles the extract pattern, a regular expression with named groups,
+// in which grok references are expanded.
+func CompileExtractPattern(pattern string) (*regexp.Regexp, error) {
+	expr, err := ExpandGrok(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range compiled.SubexpNames() {
+		if name != "" {
+			return compiled, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoNamedGroup, pattern)
+}
+
+// ExtractFields extracts the fields of the record by the extract patterns of the format,
+// the fields of all the matched patterns are merged, and the first extracted value of a name wins.
+// It returns nil if no field extracted.
+func (f *Format) ExtractFields(data []byte) map[string]string {
+	f.compile()
+
+	var fields map[string]string
+
+	for _, extractRegexp := range f.extractRegexps {
+		match := extractRegexp.FindSubmatch(data)
+		if match == nil {
+			continue
+		}
+
+		for i, name := range extractRegexp.SubexpNames() {
+			if name == "" || match[i] == nil {
+				continue
+			}
+
+			if fields == nil {
+				fields = make(map[string]string, len(match))
+			}
+
+			if _, exist := fields[name]; !exist {
+				fields[name] = string(match[i])
+			}
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/match"
+)
+
+func TestExpandGrok(t *testing.T) {
+	t.Parallel()
+
+	expr, err := match.ExpandGrok(`%{IPV4:client} took %{INT}ms`)
+	require.NoError(t, err)
+	assert.Equal(t, `(?P<client>(?:\d{1,3}\.){3}\d{1,3}) took (?:[+-]?\d+)ms`, expr)
+
+	_, err = match.ExpandGrok(`%{UNKNOWN:x}`)
+	require.ErrorIs(t, err, match.ErrGrokPatternNotExist)
+
+	assert.Contains(t, match.GrokPatterns(), "TIMESTAMP_ISO8601")
+}
+
+func TestCompileExtractPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := match.CompileExtractPattern(`user=(?P<user>\w+)`)
+	require.NoError(t, err)
+
+	_, err = match.CompileExtractPattern(`%{INT} ms`)
+	require.ErrorIs(t, err, match.ErrNoNamedGroup)
+
+	_, err = match.CompileExtractPattern(`(?P<user>\w+`)
+	require.Error(t, err)
+}
+
+func TestExtractFields(t *testing.T) {
+	t.Parallel()
+
+	format := &match.Format{Extract: []string{
+		`^%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} `,
+		`order=(?P<order>\d+)`,
+		`(?P<level>\w+) cost=%{DURATION:cost}`,
+	}}
+
+	fields := format.ExtractFields([]byte("2024-01-15 10:30:45 ERROR pay failed order=1024 cost=15ms\n\tat Pay.run"))
+	assert.Equal(t, map[string]string{
+		"time":  "2024-01-15 10:30:45",
+		"level": "ERROR",
+		"order": "1024",
+		"cost":  "15ms",
+	}, fields)
+
+	assert.Nil(t, format.ExtractFields([]byte("no fields")))
+	assert.Nil(t, (&match.Format{}).ExtractFields([]byte("2024-01-15 10:30:45 ERROR")))
+}
+
+func TestExtractFields_Preset(t *testing.T) {
+	t.Parallel()
+
+	format := &match.Format{Preset: match.PresetNginxAccess}
+	line := `192.168.1.10 - alice [15/Jan/2024:10:30:45 +0800] "POST /api/orders?id=1 HTTP/1.1" 502 157 "-" "curl/8.0"`
+
+	assert.Equal(t, map[string]string{
+		"client": "192.168.1.10",
+		"user":   "alice",
+		"method": "POST",
+		"path":   "/api/orders?id=1",
+		"status": "502",
+		"bytes":  "157",
+	}, format.ExtractFields([]byte(line)))
+
+	// the extract patterns of the format override the preset ones.
+	format = &match.Format{Preset: match.PresetNginxAccess, Extract: []string{`" %{INT:code} `}}
+	assert.Equal(t, map[string]string{"code": "502"}, format.ExtractFields([]byte(line)))
+}
@@ -19,23 +19,252 @@ package match
 
 import (
 	"fmt"
+	"regexp"
+	"sync"
+	"time"
 
 	"github.com/vogo/logtail/internal/util"
 )
 
 // Format the log format.
+// A line starts a new record if it matches both the prefix and the prefix regex when they are set,
+// and doesn't match the continuation regex.
 type Format struct {
 	Prefix string `json:"prefix"` // the wildcard of the line prefix of a log record
+
+	// Preset the name of a format preset providing the prefix and continuation regex,
+	// or `auto` to detect the preset from the first lines.
+	Preset string `json:"preset,omitempty"`
+
+	// PrefixRegex the regular expression matching the start of the first line of a log record.
+	PrefixRegex string `json:"prefix_regex,omitempty"`
+
+	// ContinuationRegex the regular expression matching the start of the following lines of a log record,
+	// e.g. `\s+at ` or `Caused by:`, which are not new records even if they match the prefix.
+	ContinuationRegex string `json:"continuation_regex,omitempty"`
+
+	// TimeLayout the go time layout of the event time of a record, e.g. `2006-01-02 15:04:05.000`.
+	TimeLayout string `json:"time_layout,omitempty"`
+
+	// TimeRegex the regular expression locating the event time in the first line of a record,
+	// the first group is the time if any. The time is at the start of the line if not set.
+	TimeRegex string `json:"time_regex,omitempty"`
+
+	// LevelRegex the regular expression locating the level in the first line of a record, the first group if any.
+	LevelRegex string `json:"level_regex,omitempty"`
+
+	// LevelField the path of the level field of a JSON record, `level` by default.
+	LevelField string `json:"level_field,omitempty"`
+
+	// LevelTokens the tokens of the levels, e.g. `{"error": ["E", "Err"]}`,
+	// searched as whole words in the first line of a record before the default tokens.
+	LevelTokens map[string][]string `json:"level_tokens,omitempty"`
+
+	// Extract the patterns extracting the fields of a record, regular expressions with named groups,
+	// and grok references like `%{IP:client}` or `%{NUMBER}` are supported.
+	Extract []string `json:"extract,omitempty"`
+
+	compileOnce        sync.Once
+	prefixRegexp       *regexp.Regexp
+	continuationRegexp *regexp.Regexp
+	timeLayout         string
+	timeRegexp         *regexp.Regexp
+	levelRegexp        *regexp.Regexp
+	levelOf            func([]byte) Level
+	levelTokens        []*levelToken
+	extractRegexps     []*regexp.Regexp
+}
+
+// IsAuto whether the format preset should be detected.
+func (f *Format) IsAuto() bool {
+	return f.Preset == PresetAuto
+}
+
+// Detected returns the format with the detected preset, a copy of the auto format with only the preset replaced,
+// so that the other settings are kept. The preset is empty if none detected,
+// and nil is returned if nothing else is set either, to split the lines as no format.
+func (f *Format) Detected(preset string) *Format {
+	detected := &Format{
+		Prefix:            f.Prefix,
+		Preset:            preset,
+		PrefixRegex:       f.PrefixRegex,
+		ContinuationRegex: f.ContinuationRegex,
+		TimeLayout:        f.TimeLayout,
+		TimeRegex:         f.TimeRegex,
+		LevelRegex:        f.LevelRegex,
+		LevelField:        f.LevelField,
+		LevelTokens:       f.LevelTokens,
+		Extract:           f.Extract,
+	}
+
+	if preset == "" && detected.Prefix == "" && !detected.hasRegex() && detected.TimeLayout == "" &&
+		detected.TimeRegex == "" && detected.LevelRegex == "" && detected.LevelField == "" &&
+		len(detected.LevelTokens) == 0 && len(detected.Extract) == 0 {
+		return nil
+	}
+
+	return detected
+}
+
+// hasRegex whether the format has any regular expression, including the ones of the preset.
+func (f *Format) hasRegex() bool {
+	return f.PrefixRegex != "" || f.ContinuationRegex != "" || (f.Preset != "" && !f.IsAuto())
+}
+
+// compile the regular expressions, which should be checked before.
+// The regular expressions, the time layout and the extract patterns of the format override the ones of the preset.
+func (f *Format) compile() {
+	f.compileOnce.Do(func() {
+		prefixRegex, continuationRegex := f.PrefixRegex, f.ContinuationRegex
+		timeLayout, timeRegex := f.TimeLayout, f.TimeRegex
+		levelRegex, extracts := f.LevelRegex, f.Extract
+
+		if preset := findFormatPreset(f.Preset); preset != nil {
+			prefixRegex = orDefault(prefixRegex, preset.prefixRegex)
+			continuationRegex = orDefault(continuationRegex, preset.continuationRegex)
+
+			if timeLayout == "" {
+				timeLayout, timeRegex = preset.timeLayout, orDefault(timeRegex, preset.timeRegex)
+			}
+
+			if levelRegex == "" {
+				levelRegex, f.levelOf = preset.levelRegex, preset.levelOf
+			}
+
+			if len(extracts) == 0 && preset.extract != "" {
+				extracts = []string{preset.extract}
+			}
+		}
+
+		if prefixRegex != "" {
+			f.prefixRegexp = regexp.MustCompile(anchorRegex(prefixRegex))
+		}
+
+		if continuationRegex != "" {
+			f.continuationRegexp = regexp.MustCompile(anchorRegex(continuationRegex))
+		}
+
+		f.timeLayout = timeLayout
+
+		if timeRegex != "" {
+			f.timeRegexp = regexp.MustCompile(timeRegex)
+		}
+
+		if levelRegex != "" {
+			f.levelRegexp = regexp.MustCompile(levelRegex)
+		}
+
+		f.levelTokens = buildLevelTokens(f.parsedLevelTokens())
+
+		for _, extract := range extracts {
+			extractRegexp, err := CompileExtractPattern(extract)
+			if err != nil {
+				panic(err)
+			}
+
+			f.extractRegexps = append(f.extractRegexps, extractRegexp)
+		}
+	})
+}
+
+func (f *Format) parsedLevelTokens() map[Level][]string {
+	tokens := make(map[Level][]string, len(f.LevelTokens))
+
+	for name, values := range f.LevelTokens {
+		if level := ParseLevel(name); level != LevelUnknown {
+			tokens[level] = append(tokens[level], values...)
+		}
+	}
+
+	return tokens
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// anchorRegex anchors the regular expression at the start of the line.
+func anchorRegex(pattern string) string {
+	return "^(?:" + pattern + ")"
 }
 
 // PrefixMatch whether the given data has a prefix of a new record.
 func (f *Format) PrefixMatch(data []byte) bool {
-	return WildcardMatch(f.Prefix, data)
+	if !WildcardMatch(f.Prefix, data) {
+		return false
+	}
+
+	if !f.hasRegex() {
+		return true
+	}
+
+	f.compile()
+
+	line := data[:util.IndexLineEnd(data, len(data), 0)]
+
+	if f.prefixRegexp != nil && !f.prefixRegexp.Match(line) {
+		return false
+	}
+
+	return f.continuationRegexp == nil || !f.continuationRegexp.Match(line)
+}
+
+// ParseTime parses the event time in the first line of the record.
+// The current year is used if the time layout has no year.
+func (f *Format) ParseTime(data []byte) (time.Time, bool) {
+	f.compile()
+
+	if f.timeLayout == "" {
+		return time.Time{}, false
+	}
+
+	line := data[:util.IndexLineEnd(data, len(data), 0)]
+
+	var value []byte
+
+	if f.timeRegexp == nil {
+		value = line[:min(len(line), len(f.timeLayout))]
+	} else {
+		loc := f.timeRegexp.FindSubmatchIndex(line)
+		if loc == nil {
+			return time.Time{}, false
+		}
+
+		if len(loc) > 2 && loc[2] >= 0 {
+			value = line[loc[2]:loc[3]]
+		} else {
+			value = line[loc[0]:loc[1]]
+		}
+	}
+
+	t, err := time.ParseInLocation(f.timeLayout, string(value), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if t.Year() == 0 {
+		t = t.AddDate(time.Now().Year(), 0, 0)
+	}
+
+	return t, true
 }
 
 // String format string info.
 func (f *Format) String() string {
-	return fmt.Sprintf("format{prefix:%s}", f.Prefix)
+	if f.Preset != "" {
+		return fmt.Sprintf("format{preset:%s}", f.Preset)
+	}
+
+	if f.PrefixRegex == "" && f.ContinuationRegex == "" {
+		return fmt.Sprintf("format{prefix:%s}", f.Prefix)
+	}
+
+	return fmt.Sprintf("format{prefix:%s, prefix_regex:%s, continuation_regex:%s}",
+		f.Prefix, f.PrefixRegex, f.ContinuationRegex)
 }
 
 //nolint:varnamelen //ignore this.
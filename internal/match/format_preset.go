@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/vogo/logtail/internal/util"
+)
+
+// names of the format presets.
+const (
+	PresetAuto          = "auto"
+	PresetJavaLogback   = "java-logback"
+	PresetLog4j         = "log4j"
+	PresetGlog          = "glog"
+	PresetPythonLogging = "python-logging"
+	PresetNginxAccess   = "nginx-access"
+	PresetSyslogRFC3164 = "syslog-rfc3164"
+	PresetSyslogRFC5424 = "syslog-rfc5424"
+	PresetGoSlogText    = "go-slog-text"
+	PresetGoSlogJSON    = "go-slog-json"
+)
+
+const (
+	// FormatDetectSampleLines the max number of lines sampled to detect the format.
+	FormatDetectSampleLines = 20
+
+	// FormatDetectMinMatches the format is detected once a preset matches so many lines.
+	FormatDetectMinMatches = 3
+)
+
+type formatPreset struct {
+	name              string
+	prefixRegex       string
+	continuationRegex string
+	timeLayout        string
+	timeRegex         string
+	levelRegex        string
+	levelOf           func([]byte) Level
+	extract           string
+	prefixRegexp      *regexp.Regexp
+}
+
+// formatPresets the presets in the order to detect, the more specific ones first.
+//
+//nolint:gochecknoglobals // ignore this
+var formatPresets = buildFormatPresets([]*formatPreset{
+	{
+		name:        PresetGoSlogJSON,
+		prefixRegex: `\{"time":"[^"]*","level":"`,
+		timeLayout:  time.RFC3339Nano,
+		timeRegex:   `^\{"time":"([^"]+)"`,
+	},
+	{
+		name:        PresetGoSlogText,
+		prefixRegex: `time=\S+ level=[A-Z]+`,
+		timeLayout:  time.RFC3339Nano,
+		timeRegex:   `^time=(\S+)`,
+	},
+	{
+		name:        PresetGlog,
+		prefixRegex: `[IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d{6} +\d+ `,
+		timeLayout:  "0102 15:04:05.000000",
+		timeRegex:   `^[IWEF](\d{4} \d{2}:\d{2}:\d{2}\.\d{6})`,
+		levelRegex:  `^([IWEF])\d{4} `,
+	},
+	{
+		name:        PresetSyslogRFC5424,
+		prefixRegex: `<\d{1,3}>\d{1,2} (\d{4}-\d{2}-\d{2}T\S+|-) `,
+		timeLayout:  time.RFC3339Nano,
+		timeRegex:   `^<\d{1,3}>\d{1,2} (\S+)`,
+		levelRegex:  `^<(\d{1,3})>`,
+		levelOf:     syslogLevel,
+	},
+	{
+		name:        PresetSyslogRFC3164,
+		prefixRegex: `(<\d{1,3}>)?[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} `,
+		timeLayout:  time.Stamp,
+		timeRegex:   `^(?:<\d{1,3}>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`,
+		levelRegex:  `^<(\d{1,3})>`,
+		levelOf:     syslogLevel,
+	},
+	{
+		name:        PresetNginxAccess,
+		prefixRegex: `\S+ \S+ \S+ \[\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "`,
+		timeLayout:  "02/Jan/2006:15:04:05 -0700",
+		timeRegex:   `\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`,
+		levelRegex:  `" ([1-5])\d{2} `,
+		levelOf:     httpStatusLevel,
+		extract: `^%{NOTSPACE:client} \S+ %{NOTSPACE:user} \[[^\]]*\] "%{WORD:method} %{NOTSPACE:path} [^"]*" ` +
+			`%{INT:status} %{NOTSPACE:bytes}`,
+	},
+	{
+		name:        PresetPythonLogging,
+		prefixRegex: `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3} - |(DEBUG|INFO|WARNING|ERROR|CRITICAL):\S*:`,
+		timeLayout:  "2006-01-02 15:04:05,000",
+	},
+	{
+		name:              PresetLog4j,
+		prefixRegex:       `\[?\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}`,
+		continuationRegex: `\s+at |Caused by:`,
+		timeLayout:        "2006-01-02 15:04:05,000",
+		timeRegex:         `^\[?(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3})`,
+	},
+	{
+		name:              PresetJavaLogback,
+		prefixRegex:       `(\d{4}-\d{2}-\d{2}[ T])?\d{2}:\d{2}:\d{2}\.\d{3}`,
+		continuationRegex: `\s+at |Caused by:`,
+		timeLayout:        "2006-01-02 15:04:05.000",
+		timeRegex:         `^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3})`,
+	},
+})
+
+func buildFormatPresets(presets []*formatPreset) []*formatPreset {
+	for _, preset := range presets {
+		preset.prefixRegexp = regexp.MustCompile(anchorRegex(preset.prefixRegex))
+	}
+
+	return presets
+}
+
+func findFormatPreset(name string) *formatPreset {
+	for _, preset := range formatPresets {
+		if preset.name == name {
+			return preset
+		}
+	}
+
+	return nil
+}
+
+// IsFormatPreset whether the name is a format preset or auto.
+func IsFormatPreset(name string) bool {
+	return name == PresetAuto || findFormatPreset(name) != nil
+}
+
+// FormatPresets returns the names of the format presets.
+func FormatPresets() []string {
+	names := make([]string, 0, len(formatPresets))
+
+	for _, preset := range formatPresets {
+		names = append(names, preset.name)
+	}
+
+	return names
+}
+
+// FormatDetector detects the format preset by sampling the first lines.
+type FormatDetector struct {
+	sampled int
+	matches []int
+}
+
+func NewFormatDetector() *FormatDetector {
+	return &FormatDetector{
+		matches: make([]int, len(formatPresets)),
+	}
+}
+
+// Detect samples the lines of the data, and returns true if the detection is done,
+// with the format of the detected preset, or nil if no preset matches.
+func (d *FormatDetector) Detect(data []byte) (*Format, bool) {
+	length := len(data)
+
+	for index := 0; index < length && d.sampled < FormatDetectSampleLines; {
+		end := util.IndexLineEnd(data, length, index)
+		line := data[index:end]
+		index = util.IgnoreLineEnd(data, length, end)
+
+		if len(line) == 0 {
+			continue
+		}
+
+		d.sampled++
+
+		for i, preset := range formatPresets {
+			if preset.prefixRegexp.Match(line) {
+				d.matches[i]++
+			}
+		}
+
+		if best := d.best(); best >= 0 && d.matches[best] >= FormatDetectMinMatches {
+			return &Format{Preset: formatPresets[best].name}, true
+		}
+	}
+
+	if d.sampled < FormatDetectSampleLines {
+		return nil, false
+	}
+
+	if best := d.best(); best >= 0 {
+		return &Format{Preset: formatPresets[best].name}, true
+	}
+
+	return nil, true
+}
+
+// best returns the index of the preset matching most lines, -1 if none matches.
+func (d *FormatDetector) best() int {
+	best := -1
+
+	for i, count := range d.matches {
+		if count > 0 && (best < 0 || count > d.matches[best]) {
+			best = i
+		}
+	}
+
+	return best
+}
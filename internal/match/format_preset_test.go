@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/match"
+)
+
+//nolint:gochecknoglobals,lll // ignore this
+var presetSamples = map[string]string{
+	match.PresetJavaLogback:   "2024-01-15 10:30:45.123  INFO 1234 --- [main] c.e.App : started\n2024-01-15 10:30:45.456 ERROR 1234 --- [main] c.e.App : failed\njava.lang.IllegalStateException: x\n\tat c.e.App.run(App.java:10)\n10:30:46.001 [main] WARN c.e.App - retry\n",
+	match.PresetLog4j:         "2024-01-15 10:30:45,123 [main] INFO  c.e.App - started\n2024-01-15 10:30:45,456 [main] ERROR c.e.App - failed\n    at c.e.App.run(App.java:10)\n[2024-01-15 10:30:46,001] WARN retry\n",
+	match.PresetGlog:          "I0115 10:30:45.123456    1234 main.go:10] started\nE0115 10:30:45.456789    1234 main.go:20] failed\nW0115 10:30:46.000001    1234 main.go:30] retry\n",
+	match.PresetPythonLogging: "2024-01-15 10:30:45,123 - app - INFO - started\nTraceback (most recent call last):\n  File \"app.py\", line 1\n2024-01-15 10:30:45,456 - app - ERROR - failed\nWARNING:root:retry\n",
+	match.PresetNginxAccess:   "10.0.0.1 - - [15/Jan/2024:10:30:45 +0800] \"GET / HTTP/1.1\" 200 612\n10.0.0.2 - bob [15/Jan/2024:10:30:46 +0800] \"POST /api HTTP/1.1\" 502 0\n10.0.0.3 - - [15/Jan/2024:10:30:47 +0800] \"GET /ping HTTP/1.1\" 200 2\n",
+	match.PresetSyslogRFC3164: "<34>Jan 15 10:30:45 host app[123]: started\nJan  5 10:30:46 host app[123]: failed\n<13>Jan 15 10:30:47 host app[123]: retry\n",
+	match.PresetSyslogRFC5424: "<34>1 2024-01-15T10:30:45.003Z host app 123 ID47 - started\n<165>1 2024-01-15T10:30:46Z host app - - - failed\n<13>1 - host app - - - retry\n",
+	match.PresetGoSlogText:    "time=2024-01-15T10:30:45.123+08:00 level=INFO msg=started\ntime=2024-01-15T10:30:46.000+08:00 level=ERROR msg=failed err=\"x\"\ntime=2024-01-15T10:30:47.000+08:00 level=WARN msg=retry\n",
+	match.PresetGoSlogJSON:    "{\"time\":\"2024-01-15T10:30:45.123+08:00\",\"level\":\"INFO\",\"msg\":\"started\"}\n{\"time\":\"2024-01-15T10:30:46+08:00\",\"level\":\"ERROR\",\"msg\":\"failed\"}\n{\"time\":\"2024-01-15T10:30:47+08:00\",\"level\":\"WARN\",\"msg\":\"retry\"}\n",
+}
+
+func TestFormatDetector(t *testing.T) {
+	t.Parallel()
+
+	assert.Len(t, presetSamples, len(match.FormatPresets()))
+
+	for preset, sample := range presetSamples {
+		assert.True(t, match.IsFormatPreset(preset))
+
+		format, done := match.NewFormatDetector().Detect([]byte(sample))
+		assert.True(t, done, preset)
+
+		if assert.NotNil(t, format, preset) {
+			assert.Equal(t, preset, format.Preset)
+		}
+	}
+
+	assert.True(t, match.IsFormatPreset(match.PresetAuto))
+	assert.False(t, match.IsFormatPreset("nginx-error"))
+}
+
+func TestFormatDetector_Sampling(t *testing.T) {
+	t.Parallel()
+
+	detector := match.NewFormatDetector()
+
+	// not done until enough lines are sampled.
+	format, done := detector.Detect([]byte("I0115 10:30:45.123456    1234 main.go:10] started\n  detail\n"))
+	assert.False(t, done)
+	assert.Nil(t, format)
+
+	for range match.FormatDetectSampleLines {
+		format, done = detector.Detect([]byte("plain text line\n"))
+		if done {
+			break
+		}
+	}
+
+	// the best preset is chosen after sampling.
+	assert.True(t, done)
+	assert.Equal(t, match.PresetGlog, format.Preset)
+
+	detector = match.NewFormatDetector()
+	for range match.FormatDetectSampleLines {
+		format, done = detector.Detect([]byte("plain text line\n"))
+	}
+
+	assert.True(t, done)
+	assert.Nil(t, format)
+}
+
+func TestFormat_Detected(t *testing.T) {
+	t.Parallel()
+
+	format := &match.Format{Preset: match.PresetAuto, Prefix: "20*", Extract: []string{`id=(?P<id>\d+)`}}
+
+	detected := format.Detected(match.PresetLog4j)
+	assert.Equal(t, match.PresetLog4j, detected.Preset)
+	assert.Equal(t, "20*", detected.Prefix)
+	assert.Equal(t, []string{`id=(?P<id>\d+)`}, detected.Extract)
+	assert.Equal(t, match.PresetAuto, format.Preset)
+
+	// the settings are kept if no preset detected.
+	detected = format.Detected("")
+	assert.Empty(t, detected.Preset)
+	assert.Equal(t, "20*", detected.Prefix)
+
+	assert.Nil(t, (&match.Format{Preset: match.PresetAuto}).Detected(""))
+}
+
+func TestFormatPreset_Split(t *testing.T) {
+	t.Parallel()
+
+	format := &match.Format{Preset: match.PresetJavaLogback}
+	assert.Equal(t, "format{preset:java-logback}", format.String())
+
+	first, remain := match.SplitFirstLog(format, []byte(presetSamples[match.PresetJavaLogback]))
+	assert.Equal(t, "2024-01-15 10:30:45.123  INFO 1234 --- [main] c.e.App : started\n", string(first))
+
+	first, remain = match.SplitFirstLog(format, remain)
+	assert.Equal(t, "2024-01-15 10:30:45.456 ERROR 1234 --- [main] c.e.App : failed\n"+
+		"java.lang.IllegalStateException: x\n\tat c.e.App.run(App.java:10)\n", string(first))
+	assert.Equal(t, "10:30:46.001 [main] WARN c.e.App - retry\n", string(remain))
+
+	// the auto format splits line by line before detected.
+	first, _ = match.SplitFirstLog(&match.Format{Preset: match.PresetAuto}, []byte("a\n  b\n"))
+	assert.Equal(t, "a\n", string(first))
+}
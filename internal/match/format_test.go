@@ -19,6 +19,7 @@ package match_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/match"
@@ -141,3 +142,98 @@ func TestIsFollowingLine(t *testing.T) {
 	assert.True(t, match.IsFollowingLine(nil, []byte("\ttab")))
 	assert.False(t, match.IsFollowingLine(nil, []byte("normal")))
 }
+
+func TestFormatPrefixRegex(t *testing.T) {
+	t.Parallel()
+
+	format := &match.Format{PrefixRegex: `\[\d{4}-\d{2}-\d{2} |[IWEF]\d{4} `}
+
+	assert.True(t, format.PrefixMatch([]byte("[2024-01-01 10:00:00] ERROR a")))
+	assert.True(t, format.PrefixMatch([]byte("I0101 12:00:00.123 123 main.go:10] started")))
+	assert.False(t, format.PrefixMatch([]byte("  at [2024-01-01 10:00:00]")))
+	// only the first line is matched.
+	assert.False(t, format.PrefixMatch([]byte("stack\n[2024-01-01 10:00:00] ERROR a")))
+
+	s := "[2024-01-01 10:00:00] ERROR a\njava.lang.Exception\n  at Main\nE0101 12:00:01.000 failed\n"
+	first, remain := match.SplitFirstLog(format, []byte(s))
+	assert.Equal(t, "[2024-01-01 10:00:00] ERROR a\njava.lang.Exception\n  at Main\n", string(first))
+	assert.Equal(t, "E0101 12:00:01.000 failed\n", string(remain))
+
+	assert.Equal(t, "E0101 12:00:01.000 failed\n",
+		string(match.IndexToLineStart(format, []byte("  at Main\nE0101 12:00:01.000 failed\n"))))
+}
+
+func TestFormatContinuationRegex(t *testing.T) {
+	t.Parallel()
+
+	// the wildcard prefix works with the continuation regex.
+	format := &match.Format{Prefix: "!!!!-!!-!!", ContinuationRegex: `\d{4}-\d{2}-\d{2} \S+ +at `}
+
+	s := "2024-01-01 10:00:00 ERROR a\n2024-01-01 10:00:00   at Main\n2024-01-01 10:00:01 INFO b\n"
+	first, remain := match.SplitFirstLog(format, []byte(s))
+	assert.Equal(t, "2024-01-01 10:00:00 ERROR a\n2024-01-01 10:00:00   at Main\n", string(first))
+	assert.Equal(t, "2024-01-01 10:00:01 INFO b\n", string(remain))
+	assert.True(t, match.IsFollowingLine(format, []byte("2024-01-01 10:00:00   at Main")))
+
+	// only continuation regex, other lines start new records.
+	format = &match.Format{ContinuationRegex: `\s|Caused by:`}
+	first, remain = match.SplitFirstLog(format, []byte("ERROR a\n\tat Main\nCaused by: x\nINFO b\n"))
+	assert.Equal(t, "ERROR a\n\tat Main\nCaused by: x\n", string(first))
+	assert.Equal(t, "INFO b\n", string(remain))
+
+	assert.Equal(t, "format{prefix:, prefix_regex:, continuation_regex:\\s|Caused by:}", format.String())
+}
+
+func TestFormatParseTime(t *testing.T) {
+	t.Parallel()
+
+	// the time at the start of the line.
+	format := &match.Format{TimeLayout: "2006-01-02 15:04:05.000"}
+	parsed, ok := format.ParseTime([]byte("2024-01-15 10:30:45.123 ERROR failed\n\tat Main"))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.Local), parsed)
+
+	_, ok = format.ParseTime([]byte("\tat Main"))
+	assert.False(t, ok)
+
+	// the time located by the regex.
+	format = &match.Format{TimeLayout: time.RFC3339, TimeRegex: `ts=(\S+)`}
+	parsed, ok = format.ParseTime([]byte("level=error ts=2024-01-15T10:30:45+08:00 msg=failed"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(1705285845), parsed.Unix())
+
+	_, ok = format.ParseTime([]byte("level=error msg=failed\nts=2024-01-15T10:30:45+08:00"))
+	assert.False(t, ok)
+
+	// no time layout.
+	_, ok = (&match.Format{Prefix: "!!!!"}).ParseTime([]byte("2024-01-15 10:30:45"))
+	assert.False(t, ok)
+}
+
+func TestFormatParseTime_Preset(t *testing.T) {
+	t.Parallel()
+
+	for preset, line := range map[string]string{
+		match.PresetNginxAccess: `10.0.0.1 - - [15/Jan/2024:10:30:45 +0800] "GET / HTTP/1.1" 200`,
+		match.PresetGoSlogJSON:  `{"time":"2024-01-15T10:30:45.123+08:00","level":"INFO","msg":"ok"}`,
+		match.PresetGoSlogText:  `time=2024-01-15T10:30:45.123+08:00 level=INFO msg=ok`,
+		match.PresetLog4j:       `[2024-01-15 10:30:45,123] ERROR failed`,
+	} {
+		parsed, ok := (&match.Format{Preset: preset}).ParseTime([]byte(line))
+		assert.True(t, ok, preset)
+		assert.Equal(t, 2024, parsed.Year(), preset)
+		assert.Equal(t, 30, parsed.Minute(), preset)
+	}
+
+	// the current year is used for the layout without year.
+	parsed, ok := (&match.Format{Preset: match.PresetGlog}).ParseTime([]byte("E0115 10:30:45.123456 1 main.go:10] failed"))
+	assert.True(t, ok)
+	assert.Equal(t, time.Now().Year(), parsed.Year())
+	assert.Equal(t, time.January, parsed.Month())
+
+	// the time layout of the format overrides the preset.
+	format := &match.Format{Preset: match.PresetJavaLogback, TimeLayout: "15:04:05.000"}
+	parsed, ok = format.ParseTime([]byte("10:30:45.123 [main] ERROR failed"))
+	assert.True(t, ok)
+	assert.Equal(t, 45, parsed.Second())
+}
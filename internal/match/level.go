@@ -0,0 +1,259 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/vogo/logtail/internal/util"
+)
+
+// Level the severity of a record.
+type Level int
+
+// levels in ascending severity.
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// DefaultLevelField the path of the level field of a JSON record.
+const DefaultLevelField = "level"
+
+//nolint:gochecknoglobals // ignore this
+var levelNames = [...]string{"", "trace", "debug", "info", "warn", "error", "fatal"}
+
+// String returns the name of the level, empty for the unknown level.
+func (l Level) String() string {
+	if l < LevelUnknown || l > LevelFatal {
+		return ""
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel parses the level name case-insensitively, including the common aliases,
+// e.g. `warning`, `err`, `critical`, `panic`, and the initials of glog. It returns LevelUnknown if not a level.
+//
+//nolint:cyclop // ignore this
+func ParseLevel(name string) Level {
+	switch strings.ToLower(name) {
+	case "trace", "t", "trc", "finest":
+		return LevelTrace
+	case "debug", "d", "dbg", "fine":
+		return LevelDebug
+	case "info", "i", "inf", "information", "notice":
+		return LevelInfo
+	case "warn", "w", "wrn", "warning":
+		return LevelWarn
+	case "error", "e", "err", "severe":
+		return LevelError
+	case "fatal", "f", "ftl", "critical", "crit", "panic", "dpanic", "alert", "emerg", "emergency":
+		return LevelFatal
+	default:
+		return LevelUnknown
+	}
+}
+
+type levelToken struct {
+	token []byte
+	level Level
+}
+
+// defaultLevelTokens the tokens searched as whole words if the level is not detected otherwise.
+// They are upper case except `panic:`, so that words like `errorCount=0` are not taken as levels.
+//
+//nolint:gochecknoglobals // ignore this
+var defaultLevelTokens = buildLevelTokens(map[Level][]string{
+	LevelFatal: {"FATAL", "CRITICAL", "PANIC", "panic:"},
+	LevelError: {"ERROR", "ERR", "SEVERE"},
+	LevelWarn:  {"WARN", "WARNING"},
+	LevelInfo:  {"INFO", "NOTICE"},
+	LevelDebug: {"DEBUG"},
+	LevelTrace: {"TRACE"},
+})
+
+func buildLevelTokens(tokens map[Level][]string) []*levelToken {
+	var levelTokens []*levelToken
+
+	for level, values := range tokens {
+		for _, value := range values {
+			if value != "" {
+				levelTokens = append(levelTokens, &levelToken{token: []byte(value), level: level})
+			}
+		}
+	}
+
+	return levelTokens
+}
+
+// DetectLevel detects the level of the record from its first line, by the level regex of the format or its preset,
+// the level field of a JSON record, the level tokens of the format, and the default level tokens in order.
+func DetectLevel(format *Format, data []byte) Level {
+	line := data[:util.IndexLineEnd(data, len(data), 0)]
+	field := DefaultLevelField
+
+	if format != nil {
+		format.compile()
+
+		if level := format.regexLevel(line); level != LevelUnknown {
+			return level
+		}
+
+		if format.LevelField != "" {
+			field = format.LevelField
+		}
+	}
+
+	if level := jsonLevel(line, field); level != LevelUnknown {
+		return level
+	}
+
+	if format != nil {
+		if level := tokenLevel(line, format.levelTokens); level != LevelUnknown {
+			return level
+		}
+	}
+
+	return tokenLevel(line, defaultLevelTokens)
+}
+
+func (f *Format) regexLevel(line []byte) Level {
+	if f.levelRegexp == nil {
+		return LevelUnknown
+	}
+
+	match := f.levelRegexp.FindSubmatch(line)
+	if match == nil {
+		return LevelUnknown
+	}
+
+	value := match[0]
+
+	if len(match) > 1 {
+		value = match[1]
+	}
+
+	if f.levelOf != nil {
+		return f.levelOf(value)
+	}
+
+	return ParseLevel(string(value))
+}
+
+// jsonLevel returns the level of the field if the line is a JSON object.
+func jsonLevel(line []byte, field string) Level {
+	if trimmed := bytes.TrimLeft(line, " \t"); len(trimmed) == 0 || trimmed[0] != '{' {
+		return LevelUnknown
+	}
+
+	object := parseJSONObject(line)
+	if object == nil {
+		return LevelUnknown
+	}
+
+	value, ok := lookupField(object, strings.Split(field, "."))
+	if !ok {
+		return LevelUnknown
+	}
+
+	text, ok := fieldText(value)
+	if !ok {
+		return LevelUnknown
+	}
+
+	return ParseLevel(text)
+}
+
+// tokenLevel returns the level of the leftmost token found as a whole word in the line.
+func tokenLevel(line []byte, tokens []*levelToken) Level {
+	level, position := LevelUnknown, len(line)
+
+	for _, token := range tokens {
+		if index := indexWord(line, token.token); index >= 0 && (level == LevelUnknown || index < position) {
+			level, position = token.level, index
+		}
+	}
+
+	return level
+}
+
+// indexWord returns the index of the first occurrence of the token not adjacent to other word bytes.
+func indexWord(line, token []byte) int {
+	for offset := 0; offset+len(token) <= len(line); {
+		index := bytes.Index(line[offset:], token)
+		if index < 0 {
+			return -1
+		}
+
+		start, end := offset+index, offset+index+len(token)
+
+		if (start == 0 || !isWordByte(token[0]) || !isWordByte(line[start-1])) &&
+			(end == len(line) || !isWordByte(token[len(token)-1]) || !isWordByte(line[end])) {
+			return start
+		}
+
+		offset = start + 1
+	}
+
+	return -1
+}
+
+func isWordByte(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_'
+}
+
+// syslogLevel returns the level of the severity of the syslog priority.
+func syslogLevel(priority []byte) Level {
+	value := 0
+
+	for _, b := range priority {
+		value = value*10 + int(b-'0')
+	}
+
+	switch severity := value % 8; {
+	case severity <= 2:
+		return LevelFatal
+	case severity == 3:
+		return LevelError
+	case severity == 4:
+		return LevelWarn
+	case severity <= 6:
+		return LevelInfo
+	default:
+		return LevelDebug
+	}
+}
+
+// httpStatusLevel returns the level of the first digit of the http status.
+func httpStatusLevel(status []byte) Level {
+	switch status[0] {
+	case '5':
+		return LevelError
+	case '4':
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/match"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, match.LevelWarn, match.ParseLevel("WARNING"))
+	assert.Equal(t, match.LevelError, match.ParseLevel("err"))
+	assert.Equal(t, match.LevelFatal, match.ParseLevel("Critical"))
+	assert.Equal(t, match.LevelDebug, match.ParseLevel("D"))
+	assert.Equal(t, match.LevelUnknown, match.ParseLevel("high"))
+
+	assert.Equal(t, "warn", match.LevelWarn.String())
+	assert.Equal(t, "", match.LevelUnknown.String())
+	assert.True(t, match.LevelError > match.LevelWarn)
+}
+
+func TestDetectLevel(t *testing.T) {
+	t.Parallel()
+
+	for data, level := range map[string]match.Level{
+		"2024-01-15 10:30:45 ERROR order failed":                match.LevelError,
+		"2024-01-15 10:30:45 INFO errorCount=0":                 match.LevelInfo,
+		"2024-01-15 10:30:45 [WARN] retry, last ERROR: timeout": match.LevelWarn,
+		"panic: runtime error: index out of range\n\ngoroutine": match.LevelFatal,
+		"2024-01-15 10:30:45 sync done, errors=0":               match.LevelUnknown,
+		"2024-01-15 10:30:45 INFORMATION\nERROR in next line":   match.LevelUnknown,
+		`{"time":"2024-01-15","level":"warning","msg":"ERROR"}`: match.LevelWarn,
+		`{"time":"2024-01-15","msg":"FATAL exit"}`:              match.LevelFatal,
+		`{"time":"2024-01-15","level":"high","msg":"ok"}`:       match.LevelUnknown,
+	} {
+		assert.Equal(t, level, match.DetectLevel(nil, []byte(data)), data)
+	}
+}
+
+func TestDetectLevel_Format(t *testing.T) {
+	t.Parallel()
+
+	for preset, lines := range map[string]map[string]match.Level{
+		match.PresetGlog: {
+			"E0115 10:30:45.123456 1 main.go:10] failed": match.LevelError,
+			"W0115 10:30:45.123456 1 main.go:10] ERROR":  match.LevelWarn,
+		},
+		match.PresetSyslogRFC5424: {
+			"<11>1 2024-01-15T10:30:45Z host app 1 - - failed": match.LevelError,
+			"<14>1 2024-01-15T10:30:45Z host app 1 - - ERROR":  match.LevelInfo,
+		},
+		match.PresetNginxAccess: {
+			`10.0.0.1 - - [15/Jan/2024:10:30:45 +0800] "GET / HTTP/1.1" 502 0`: match.LevelError,
+			`10.0.0.1 - - [15/Jan/2024:10:30:45 +0800] "GET / HTTP/1.1" 404 0`: match.LevelWarn,
+		},
+	} {
+		format := &match.Format{Preset: preset}
+
+		for line, level := range lines {
+			assert.Equal(t, level, match.DetectLevel(format, []byte(line)), line)
+		}
+	}
+
+	// the level regex, the level field and the level tokens of the format.
+	format := &match.Format{
+		LevelRegex:  `\|([a-z]+)\|`,
+		LevelField:  "log.severity",
+		LevelTokens: map[string][]string{"error": {"Err", "E"}, "fatal": {"boom"}},
+	}
+	assert.Equal(t, match.LevelDebug, match.DetectLevel(format, []byte("10:30:45|debug| ERROR")))
+	assert.Equal(t, match.LevelError, match.DetectLevel(format, []byte(`{"log":{"severity":"E"},"level":"info"}`)))
+	assert.Equal(t, match.LevelError, match.DetectLevel(format, []byte("10:30:45 E something INFO")))
+	assert.Equal(t, match.LevelFatal, match.DetectLevel(format, []byte("10:30:45 boom")))
+	assert.Equal(t, match.LevelInfo, match.DetectLevel(format, []byte("10:30:45 Errand INFO")))
+}
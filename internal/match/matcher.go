@@ -17,6 +17,22 @@
 
 package match
 
+import "github.com/vogo/logtail/internal/record"
+
 type Matcher interface {
 	Match(bytes []byte) bool
 }
+
+// RecordMatcher is implemented by matchers using the metadata of records, e.g. the extracted fields.
+type RecordMatcher interface {
+	MatchRecord(rec *record.Record) bool
+}
+
+// MatchRecord matches the record by the matcher, or its data if the matcher is not a RecordMatcher.
+func MatchRecord(matcher Matcher, rec *record.Record) bool {
+	if recordMatcher, ok := matcher.(RecordMatcher); ok {
+		return recordMatcher.MatchRecord(rec)
+	}
+
+	return matcher.Match(rec.Data)
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import "github.com/vogo/logtail/internal/record"
+
+// CompositeOperator the logic operator to combine the results of matchers.
+type CompositeOperator int
+
+const (
+	// OperatorAll matches if all matchers match.
+	OperatorAll CompositeOperator = iota
+
+	// OperatorAny matches if any of the matchers matches.
+	OperatorAny
+
+	// OperatorNone matches if none of the matchers matches.
+	OperatorNone
+)
+
+// CompositeMatcher combines the results of matchers with a logic operator.
+type CompositeMatcher struct {
+	operator CompositeOperator
+	matchers []Matcher
+}
+
+func NewCompositeMatcher(operator CompositeOperator, matchers ...Matcher) *CompositeMatcher {
+	return &CompositeMatcher{
+		operator: operator,
+		matchers: matchers,
+	}
+}
+
+func (cm *CompositeMatcher) Match(bytes []byte) bool {
+	return cm.combine(func(m Matcher) bool {
+		return m.Match(bytes)
+	})
+}
+
+func (cm *CompositeMatcher) MatchRecord(rec *record.Record) bool {
+	return cm.combine(func(m Matcher) bool {
+		return MatchRecord(m, rec)
+	})
+}
+
+func (cm *CompositeMatcher) combine(matches func(Matcher) bool) bool {
+	switch cm.operator {
+	case OperatorAny:
+		for _, m := range cm.matchers {
+			if matches(m) {
+				return true
+			}
+		}
+
+		return false
+	case OperatorNone:
+		for _, m := range cm.matchers {
+			if matches(m) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		for _, m := range cm.matchers {
+			if !matches(m) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vogo/logtail/internal/record"
+)
+
+// FieldCondition the conditions on an extracted field or a field of a JSON record,
+// all the set conditions must be satisfied.
+type FieldCondition struct {
+	// Path the dot separated path of the field, e.g. `http.status`, array elements are indexed by number.
+	Path string `json:"path"`
+
+	// Equals the field value equals to.
+	Equals *string `json:"equals,omitempty"`
+
+	// In the field value is one of.
+	In []string `json:"in,omitempty"`
+
+	// Gt the numeric field value is greater than.
+	Gt *float64 `json:"gt,omitempty"`
+
+	// Lt the numeric field value is less than.
+	Lt *float64 `json:"lt,omitempty"`
+
+	// Regex the field value matches the regular expression.
+	Regex string `json:"regex,omitempty"`
+}
+
+// HasCondition whether any condition is set.
+func (c *FieldCondition) HasCondition() bool {
+	return c.Equals != nil || len(c.In) > 0 || c.Gt != nil || c.Lt != nil || c.Regex != ""
+}
+
+type fieldMatcher struct {
+	*FieldCondition
+	path   []string
+	regexp *regexp.Regexp
+}
+
+// JSONMatcher parses data as a JSON object and matches if all the field conditions are satisfied.
+// Scalar field values are compared in their text form, e.g. `500`, `true`, `null`.
+// When matching a record, the extracted fields of the record are looked up first by the whole path.
+type JSONMatcher struct {
+	ignoreCase bool
+	fields     []*fieldMatcher
+}
+
+// NewJSONMatcher new json matcher.
+// It panics if a condition is invalid, which should be checked before.
+func NewJSONMatcher(conditions []*FieldCondition, ignoreCase bool) *JSONMatcher {
+	matcher := &JSONMatcher{
+		ignoreCase: ignoreCase,
+		fields:     make([]*fieldMatcher, 0, len(conditions)),
+	}
+
+	for _, condition := range conditions {
+		if condition.Path == "" {
+			panic("field path nil")
+		}
+
+		field := &fieldMatcher{
+			FieldCondition: condition,
+			path:           strings.Split(condition.Path, "."),
+		}
+
+		if condition.Regex != "" {
+			pattern := condition.Regex
+			if ignoreCase {
+				pattern = "(?i)" + pattern
+			}
+
+			field.regexp = regexp.MustCompile(pattern)
+		}
+
+		matcher.fields = append(matcher.fields, field)
+	}
+
+	return matcher
+}
+
+func (jm *JSONMatcher) Match(data []byte) bool {
+	object := parseJSONObject(data)
+	if object == nil {
+		return false
+	}
+
+	for _, field := range jm.fields {
+		value, ok := lookupField(object, field.path)
+		if !ok || !jm.matchField(field, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchRecord matches the conditions on the extracted fields of the record,
+// and the data of the record is parsed as a JSON object only if a field is not extracted.
+func (jm *JSONMatcher) MatchRecord(rec *record.Record) bool {
+	var (
+		object map[string]any
+		parsed bool
+	)
+
+	for _, field := range jm.fields {
+		if value, ok := rec.Fields[field.Path]; ok {
+			if !jm.matchField(field, value) {
+				return false
+			}
+
+			continue
+		}
+
+		if !parsed {
+			object, parsed = parseJSONObject(rec.Data), true
+		}
+
+		if object == nil {
+			return false
+		}
+
+		value, ok := lookupField(object, field.path)
+		if !ok || !jm.matchField(field, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (jm *JSONMatcher) matchField(field *fieldMatcher, value any) bool {
+	text, ok := fieldText(value)
+	if !ok {
+		return false
+	}
+
+	if field.Equals != nil && !jm.equals(text, *field.Equals) {
+		return false
+	}
+
+	if len(field.In) > 0 && !jm.in(text, field.In) {
+		return false
+	}
+
+	if field.Gt != nil || field.Lt != nil {
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return false
+		}
+
+		if (field.Gt != nil && number <= *field.Gt) || (field.Lt != nil && number >= *field.Lt) {
+			return false
+		}
+	}
+
+	if field.regexp != nil && !field.regexp.MatchString(text) {
+		return false
+	}
+
+	return true
+}
+
+func (jm *JSONMatcher) equals(text, expected string) bool {
+	if jm.ignoreCase {
+		return strings.EqualFold(text, expected)
+	}
+
+	return text == expected
+}
+
+func (jm *JSONMatcher) in(text string, set []string) bool {
+	for _, expected := range set {
+		if jm.equals(text, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseJSONObject parses the first JSON object in data, returns nil if not a JSON object.
+// Text before the object (e.g. a timestamp prefix) and after it is ignored.
+func parseJSONObject(data []byte) map[string]any {
+	start := bytes.IndexByte(data, '{')
+	if start < 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data[start:]))
+	decoder.UseNumber()
+
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil
+	}
+
+	return object
+}
+
+func lookupField(object map[string]any, path []string) (any, bool) {
+	var value any = object
+
+	for _, key := range path {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+
+			value = child
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+
+			value = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// fieldText returns the text form of a scalar value.
+func fieldText(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "null", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+const bitsPerWord = 64
+
+// MultiContainsMatcher matches multiple contains and not-contains patterns by scanning data once
+// with an Aho-Corasick automaton. It matches if data contains all the contains patterns
+// and none of the not-contains patterns.
+// The case of ASCII letters only is ignored if ignoreCase is true, see BuildMatchers in internal/route
+// for the patterns of non-ASCII characters.
+type MultiContainsMatcher struct {
+	containsCount    int
+	notContainsCount int
+	classCount       int
+	classes          [256]int
+	transitions      []int32
+	outputs          [][]int32
+}
+
+// NewMultiContainsMatcher builds the automaton of the patterns.
+func NewMultiContainsMatcher(contains, notContains []string, ignoreCase bool) *MultiContainsMatcher {
+	patterns := make([]string, 0, len(contains)+len(notContains))
+	patterns = append(patterns, contains...)
+	patterns = append(patterns, notContains...)
+
+	m := &MultiContainsMatcher{
+		containsCount:    len(contains),
+		notContainsCount: len(notContains),
+	}
+
+	m.buildClasses(patterns, ignoreCase)
+	m.buildAutomaton(patterns)
+
+	return m
+}
+
+// buildClasses maps bytes to classes to reduce the size of the transition table,
+// class 0 is for bytes not in any pattern.
+func (m *MultiContainsMatcher) buildClasses(patterns []string, ignoreCase bool) {
+	m.classCount = 1
+
+	for _, pattern := range patterns {
+		if pattern == "" {
+			panic("pattern nil")
+		}
+
+		for i := range len(pattern) {
+			b := pattern[i]
+			if ignoreCase {
+				b = toLowerASCII(b)
+			}
+
+			if m.classes[b] == 0 {
+				m.classes[b] = m.classCount
+				m.classCount++
+			}
+		}
+	}
+
+	if ignoreCase {
+		for b := 'A'; b <= 'Z'; b++ {
+			m.classes[b] = m.classes[b+'a'-'A']
+		}
+	}
+}
+
+func (m *MultiContainsMatcher) buildAutomaton(patterns []string) {
+	// build trie.
+	children := []map[int]int32{{}}
+	m.outputs = [][]int32{nil}
+
+	for id, pattern := range patterns {
+		node := int32(0)
+
+		for i := range len(pattern) {
+			class := m.classes[pattern[i]]
+
+			next, ok := children[node][class]
+			if !ok {
+				next = int32(len(children))
+				children = append(children, map[int]int32{})
+				m.outputs = append(m.outputs, nil)
+				children[node][class] = next
+			}
+
+			node = next
+		}
+
+		m.outputs[node] = append(m.outputs[node], int32(id))
+	}
+
+	// build transitions with failure links in BFS order.
+	m.transitions = make([]int32, len(children)*m.classCount)
+	fail := make([]int32, len(children))
+	queue := make([]int32, 0, len(children))
+
+	for class := range m.classCount {
+		if next, ok := children[0][class]; ok {
+			m.transitions[class] = next
+			queue = append(queue, next)
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		base := int(node) * m.classCount
+		failBase := int(fail[node]) * m.classCount
+
+		for class := range m.classCount {
+			next, ok := children[node][class]
+			if !ok {
+				m.transitions[base+class] = m.transitions[failBase+class]
+
+				continue
+			}
+
+			m.transitions[base+class] = next
+			fail[next] = m.transitions[failBase+class]
+			m.outputs[next] = append(m.outputs[next], m.outputs[fail[next]]...)
+			queue = append(queue, next)
+		}
+	}
+}
+
+func (m *MultiContainsMatcher) Match(bytes []byte) bool {
+	var (
+		small   uint64
+		found   []uint64
+		matched int
+		state   int32
+	)
+
+	if m.containsCount > bitsPerWord {
+		found = make([]uint64, (m.containsCount+bitsPerWord-1)/bitsPerWord)
+	}
+
+	for _, b := range bytes {
+		state = m.transitions[int(state)*m.classCount+m.classes[b]]
+
+		for _, id := range m.outputs[state] {
+			if int(id) >= m.containsCount {
+				// contains a not-contains pattern.
+				return false
+			}
+
+			word, bit := &small, uint64(1)<<(id%bitsPerWord)
+			if found != nil {
+				word = &found[id/bitsPerWord]
+			}
+
+			if *word&bit == 0 {
+				*word |= bit
+				matched++
+			}
+		}
+
+		if matched == m.containsCount && m.notContainsCount == 0 {
+			return true
+		}
+	}
+
+	return matched == m.containsCount
+}
+
+func toLowerASCII(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+
+	return b
+}
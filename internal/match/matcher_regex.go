@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package match
+
+import "regexp"
+
+// RegexMatcher matches data by a regular expression.
+type RegexMatcher struct {
+	match  bool
+	regexp *regexp.Regexp
+}
+
+// NewRegexMatcher new regex matcher, the result is reversed if match is false.
+// It panics if the pattern is invalid, which should be checked before.
+func NewRegexMatcher(pattern string, match bool) *RegexMatcher {
+	if pattern == "" {
+		panic("pattern nil")
+	}
+
+	return &RegexMatcher{
+		match:  match,
+		regexp: regexp.MustCompile(pattern),
+	}
+}
+
+func (rm *RegexMatcher) Match(bytes []byte) bool {
+	return rm.regexp.Match(bytes) == rm.match
+}
@@ -18,10 +18,12 @@
 package match_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 )
 
 func TestMatch(t *testing.T) {
@@ -55,3 +57,161 @@ func TestMatch2(t *testing.T) {
 
 	assert.True(t, match.NewContainsMatcher("没问题", false).Match(data))
 }
+
+func TestRegexMatch(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`2020-12-25 14:54:38.523  INFO request status=503 took 1234ms`)
+
+	assert.True(t, match.NewRegexMatcher(`status=5\d\d`, true).Match(data))
+	assert.True(t, match.NewRegexMatcher(`took \d{4,}ms`, true).Match(data))
+	assert.False(t, match.NewRegexMatcher(`status=4\d\d`, true).Match(data))
+
+	assert.False(t, match.NewRegexMatcher(`status=5\d\d`, false).Match(data))
+	assert.True(t, match.NewRegexMatcher(`status=4\d\d`, false).Match(data))
+}
+
+func TestRegexMatch_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { match.NewRegexMatcher(`status=(`, true) })
+	assert.Panics(t, func() { match.NewRegexMatcher("", true) })
+}
+
+func TestCompositeMatch(t *testing.T) {
+	t.Parallel()
+
+	errorMatcher := match.NewContainsMatcher("ERROR", true)
+	fatalMatcher := match.NewContainsMatcher("FATAL", true)
+
+	anyMatcher := match.NewCompositeMatcher(match.OperatorAny, errorMatcher, fatalMatcher)
+	assert.True(t, anyMatcher.Match([]byte("ERROR abc")))
+	assert.True(t, anyMatcher.Match([]byte("FATAL abc")))
+	assert.False(t, anyMatcher.Match([]byte("INFO abc")))
+
+	allMatcher := match.NewCompositeMatcher(match.OperatorAll, errorMatcher, fatalMatcher)
+	assert.True(t, allMatcher.Match([]byte("ERROR FATAL abc")))
+	assert.False(t, allMatcher.Match([]byte("ERROR abc")))
+
+	noneMatcher := match.NewCompositeMatcher(match.OperatorNone, errorMatcher, fatalMatcher)
+	assert.True(t, noneMatcher.Match([]byte("INFO abc")))
+	assert.False(t, noneMatcher.Match([]byte("FATAL abc")))
+
+	// empty composite matchers
+	assert.False(t, match.NewCompositeMatcher(match.OperatorAny).Match([]byte("abc")))
+	assert.True(t, match.NewCompositeMatcher(match.OperatorAll).Match([]byte("abc")))
+	assert.True(t, match.NewCompositeMatcher(match.OperatorNone).Match([]byte("abc")))
+}
+
+func TestMultiContainsMatch(t *testing.T) {
+	t.Parallel()
+
+	matcher := match.NewMultiContainsMatcher([]string{"ERROR", "RROR code", "db"}, []string{"HealthCheck"}, false)
+	assert.True(t, matcher.Match([]byte("ERROR code=500 from db")))
+	assert.True(t, matcher.Match([]byte("db: ERROR code=500")))
+	assert.False(t, matcher.Match([]byte("ERROR code=500")))
+	assert.False(t, matcher.Match([]byte("error code=500 from db")))
+	assert.False(t, matcher.Match([]byte("ERROR code=500 from db HealthCheck")))
+
+	matcher = match.NewMultiContainsMatcher(nil, []string{"he", "she", "hers"}, false)
+	assert.True(t, matcher.Match([]byte("it is ok")))
+	assert.False(t, matcher.Match([]byte("ushers")))
+	assert.False(t, matcher.Match([]byte("washer")))
+	assert.True(t, matcher.Match([]byte("ash")))
+	assert.True(t, matcher.Match([]byte("as h")))
+
+	matcher = match.NewMultiContainsMatcher([]string{"exception", "Timeout"}, nil, true)
+	assert.True(t, matcher.Match([]byte("java.net.SocketTimeoutException")))
+	assert.True(t, matcher.Match([]byte("EXCEPTION: TIMEOUT")))
+	assert.False(t, matcher.Match([]byte("EXCEPTION: TIME OUT")))
+
+	// only the case of ASCII letters is ignored.
+	matcher = match.NewMultiContainsMatcher([]string{"ошибка"}, nil, true)
+	assert.True(t, matcher.Match([]byte("ошибка")))
+	assert.False(t, matcher.Match([]byte("ОШИБКА")))
+}
+
+func TestMultiContainsMatch_ManyPatterns(t *testing.T) {
+	t.Parallel()
+
+	patterns := make([]string, 100)
+	data := make([]byte, 0, 1000)
+
+	for i := range patterns {
+		patterns[i] = fmt.Sprintf("<%d>", i)
+		data = append(data, patterns[i]...)
+	}
+
+	matcher := match.NewMultiContainsMatcher(patterns, nil, false)
+	assert.True(t, matcher.Match(data))
+	assert.False(t, matcher.Match(data[:len(data)-1]))
+}
+
+func TestJSONMatch(t *testing.T) {
+	t.Parallel()
+
+	status := 499.0
+	level := "error"
+
+	matcher := match.NewJSONMatcher([]*match.FieldCondition{
+		{Path: "level", Equals: &level},
+		{Path: "http.status", Gt: &status},
+		{Path: "http.method", In: []string{"POST", "PUT"}},
+	}, false)
+
+	assert.True(t, matcher.Match([]byte(`{"level":"error","http":{"status":502,"method":"POST"}}`)))
+	assert.True(t, matcher.Match([]byte(`{ "http" : { "method" : "PUT", "status" : "503" }, "level" : "error" }`)))
+	assert.True(t, matcher.Match([]byte(`2024-01-01 10:00:00 {"level":"error","http":{"status":500,"method":"PUT"}}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"error","http":{"status":404,"method":"POST"}}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"ERROR","http":{"status":502,"method":"POST"}}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"error","http":{"status":502,"method":"GET"}}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"error","http":{"method":"POST"}}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"error","http":{"status":{"code":502},"method":"POST"}}`)))
+	assert.False(t, matcher.Match([]byte(`level=error http.status=502`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"error",`)))
+
+	limit := 1000.0
+	matcher = match.NewJSONMatcher([]*match.FieldCondition{
+		{Path: "level", Equals: &level},
+		{Path: "spans.0.cost", Lt: &limit},
+		{Path: "msg", Regex: `timeout|refused`},
+	}, true)
+
+	assert.True(t, matcher.Match([]byte(`{"level":"ERROR","spans":[{"cost":12.5}],"msg":"connection Refused"}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"ERROR","spans":[{"cost":1200}],"msg":"connection refused"}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"ERROR","spans":[],"msg":"connection refused"}`)))
+	assert.False(t, matcher.Match([]byte(`{"level":"ERROR","spans":[{"cost":12.5}],"msg":"ok"}`)))
+}
+
+func TestJSONMatchRecord(t *testing.T) {
+	t.Parallel()
+
+	status := 499.0
+	method := "POST"
+
+	matcher := match.NewJSONMatcher([]*match.FieldCondition{
+		{Path: "status", Gt: &status},
+		{Path: "method", Equals: &method},
+	}, false)
+
+	rec := record.New("", "", []byte(`10.0.0.1 - - "POST /api" 502`))
+	rec.Fields = map[string]string{"status": "502", "method": "POST"}
+	assert.True(t, match.MatchRecord(matcher, rec))
+	assert.False(t, matcher.Match(rec.Data))
+
+	rec.Fields = map[string]string{"status": "404", "method": "POST"}
+	assert.False(t, match.MatchRecord(matcher, rec))
+
+	// fields not extracted are looked up in the JSON data.
+	rec = record.New("", "", []byte(`{"method":"POST","cost":12}`))
+	rec.Fields = map[string]string{"status": "503"}
+	assert.True(t, match.MatchRecord(matcher, rec))
+
+	rec.Data = []byte(`method=POST`)
+	assert.False(t, match.MatchRecord(matcher, rec))
+
+	composite := match.NewCompositeMatcher(match.OperatorAll, matcher, match.NewContainsMatcher("POST", true))
+	rec = record.New("", "", []byte(`"POST /api" 502`))
+	rec.Fields = map[string]string{"status": "502", "method": "POST"}
+	assert.True(t, match.MatchRecord(composite, rec))
+}
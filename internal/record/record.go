@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package record
+
+import "time"
+
+// Record a log record with its metadata, passed from workers through routers to transfers.
+type Record struct {
+	// Data the raw bytes of the record.
+	Data []byte
+
+	// Source the id of the server the record comes from.
+	Source string
+
+	// Worker the id of the worker, or the path of the file, the record comes from.
+	Worker string
+
+	// Time the event time parsed from the record, zero if not parsed.
+	Time time.Time
+
+	// Level the level of the record, empty if unknown.
+	Level string
+
+	// Fields the fields extracted from the record, nil if none.
+	Fields map[string]string
+
+	// Router the name of the router transferring the record, set when passed to transfers.
+	Router string
+
+	// Arrival the time when the record arrived.
+	Arrival time.Time
+}
+
+// New new record arriving now.
+func New(source, worker string, data []byte) *Record {
+	return &Record{
+		Data:    data,
+		Source:  source,
+		Worker:  worker,
+		Arrival: time.Now(),
+	}
+}
+
+// EventTime returns the event time of the record, or the arrival time if the event time is not parsed.
+func (r *Record) EventTime() time.Time {
+	if r.Time.IsZero() {
+		return r.Arrival
+	}
+
+	return r.Time
+}
+
+// WithData returns a copy of the record with the data replaced.
+func (r *Record) WithData(data []byte) *Record {
+	dup := *r
+	dup.Data = data
+
+	return &dup
+}
+
+// Datas returns the data of the records.
+func Datas(records []*Record) [][]byte {
+	data := make([][]byte, len(records))
+
+	for i, r := range records {
+		data[i] = r.Data
+	}
+
+	return data
+}
@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package record_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/record"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	rec := record.New("app", "app-1", []byte("ERROR failed"))
+	assert.False(t, rec.Arrival.IsZero())
+	assert.Equal(t, rec.Arrival, rec.EventTime())
+
+	rec.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	assert.Equal(t, rec.Time, rec.EventTime())
+
+	masked := rec.WithData([]byte("ERROR ******"))
+	assert.Equal(t, "ERROR failed", string(rec.Data))
+	assert.Equal(t, "ERROR ******", string(masked.Data))
+	assert.Equal(t, "app-1", masked.Worker)
+	assert.Equal(t, rec.Time, masked.Time)
+
+	assert.Equal(t, [][]byte{[]byte("ERROR failed"), []byte("ERROR ******")},
+		record.Datas([]*record.Record{rec, masked}))
+}
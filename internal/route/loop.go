@@ -18,6 +18,8 @@
 package route
 
 import (
+	"time"
+
 	"github.com/vogo/logtail/internal/util"
 	"github.com/vogo/vogo/vlog"
 )
@@ -33,18 +35,31 @@ func (r *Router) StartLoop() {
 
 	vlog.Infof("Routers [%s] StartLoop", r.ID)
 
+	var tick <-chan time.Time
+
+	if r.hasTicker() {
+		ticker := time.NewTicker(StageTickInterval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
 	for {
 		select {
 		case <-r.Runner.C:
 			return
-		case data := <-r.Channel:
-			if data == nil {
+		case now := <-tick:
+			if err := r.Tick(now); err != nil {
+				vlog.Warnf("Routers [%s] tick error: %+v", r.ID, err)
+			}
+		case rec := <-r.Channel:
+			if rec == nil {
 				r.Stop()
 
 				return
 			}
 
-			if err := r.Route(data); err != nil {
+			if err := r.Route(rec); err != nil {
 				vlog.Warnf("Routers [%s] route error: %+v", r.ID, err)
 				r.Stop()
 			}
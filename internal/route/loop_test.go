@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/route"
 	"github.com/vogo/logtail/internal/trans"
 	"github.com/vogo/vogo/vsync/vrun"
@@ -55,13 +56,13 @@ func TestStartLoop_RoutesData(t *testing.T) {
 		Runner:    runner.NewChild(),
 		ID:        "loop-test",
 		Name:      "loop-test",
-		Channel:   make(chan []byte, 16),
+		Channel:   make(chan *record.Record, 16),
 		Transfers: []trans.Transfer{mockTransfer},
 	}
 
 	go router.StartLoop()
 
-	router.Channel <- []byte("hello world")
+	router.Channel <- record.New("", "", []byte("hello world"))
 
 	time.Sleep(50 * time.Millisecond)
 
@@ -83,7 +84,7 @@ func TestStartLoop_StopsOnNilData(t *testing.T) {
 		Runner:  runner.NewChild(),
 		ID:      "nil-test",
 		Name:    "nil-test",
-		Channel: make(chan []byte, 16),
+		Channel: make(chan *record.Record, 16),
 	}
 
 	done := make(chan struct{})
@@ -130,8 +131,8 @@ func TestRouteWithMatchers(t *testing.T) {
 		Transfers: []trans.Transfer{mockTrans},
 	}
 
-	_ = router.Route([]byte("ERROR something bad"))
-	_ = router.Route([]byte("INFO all good"))
+	_ = router.Route(record.New("", "", []byte("ERROR something bad")))
+	_ = router.Route(record.New("", "", []byte("INFO all good")))
 
 	mu.Lock()
 	assert.Equal(t, "ERROR something bad", string(received))
@@ -155,7 +156,7 @@ func TestRouteNoMatchers(t *testing.T) {
 		Transfers: []trans.Transfer{mockTrans},
 	}
 
-	_ = router.Route([]byte("anything"))
+	_ = router.Route(record.New("", "", []byte("anything")))
 	assert.Equal(t, 1, callCount)
 }
 
@@ -166,7 +167,7 @@ func TestTransNoTransfers(t *testing.T) {
 		Lock: sync.Mutex{},
 	}
 
-	err := router.Trans([]byte("data"))
+	err := router.Trans(record.New("", "", []byte("data")))
 	assert.NoError(t, err)
 }
 
@@ -203,9 +204,15 @@ type mockTransfer struct {
 
 func (m *mockTransfer) Name() string { return "mock" }
 
-func (m *mockTransfer) Trans(source string, data ...[]byte) error {
-	if m.transFn != nil {
-		return m.transFn(source, data...)
+func (m *mockTransfer) Trans(records ...*record.Record) error {
+	if m.transFn == nil {
+		return nil
+	}
+
+	for _, rec := range records {
+		if err := m.transFn(rec.Source, rec.Data); err != nil {
+			return err
+		}
 	}
 
 	return nil
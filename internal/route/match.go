@@ -18,6 +18,9 @@
 package route
 
 import (
+	"regexp"
+	"unicode/utf8"
+
 	"github.com/vogo/logtail/internal/conf"
 	"github.com/vogo/logtail/internal/match"
 )
@@ -30,31 +33,134 @@ func NewMatchers(configs []*conf.MatcherConfig) ([]match.Matcher, error) {
 	return BuildMatchers(configs), nil
 }
 
+// BuildMatchers builds the matchers of the configs, the contains and not-contains patterns
+// of all configs are built into one automaton for each case mode,
+// so that a record is scanned only once for them.
+// The automaton folds ASCII letters only, so the case-insensitive patterns of non-ASCII characters
+// are matched by regular expressions, to ignore the case the same as the regex patterns.
 func BuildMatchers(matcherConfigs []*conf.MatcherConfig) []match.Matcher {
-	var matchers []match.Matcher
+	var (
+		matchers    []match.Matcher
+		contains    [2][]string
+		notContains [2][]string
+	)
+
+	for _, config := range matcherConfigs {
+		mode := caseMode(config.IgnoreCase)
+
+		for _, pattern := range config.Contains {
+			if config.IgnoreCase && !isASCII(pattern) {
+				matchers = append(matchers, match.NewRegexMatcher("(?i)"+regexp.QuoteMeta(pattern), true))
+			} else {
+				contains[mode] = append(contains[mode], pattern)
+			}
+		}
 
-	for _, matchConfig := range matcherConfigs {
-		m := BuildMatcher(matchConfig)
-		if len(m) > 0 {
-			matchers = append(matchers, m...)
+		for _, pattern := range config.NotContains {
+			if config.IgnoreCase && !isASCII(pattern) {
+				matchers = append(matchers, match.NewRegexMatcher("(?i)"+regexp.QuoteMeta(pattern), false))
+			} else {
+				notContains[mode] = append(notContains[mode], pattern)
+			}
 		}
+
+		matchers = append(matchers, buildPatternMatchers(config)...)
 	}
 
-	return matchers
+	containsMatchers := make([]match.Matcher, 0, len(contains))
+
+	for mode := range contains {
+		if len(contains[mode]) > 0 || len(notContains[mode]) > 0 {
+			containsMatchers = append(containsMatchers,
+				match.NewMultiContainsMatcher(contains[mode], notContains[mode], mode == caseInsensitive))
+		}
+	}
+
+	if len(containsMatchers) == 0 {
+		return matchers
+	}
+
+	return append(containsMatchers, matchers...)
 }
 
 func BuildMatcher(config *conf.MatcherConfig) []match.Matcher {
-	matchers := make([]match.Matcher, len(config.Contains)+len(config.NotContains))
+	return BuildMatchers([]*conf.MatcherConfig{config})
+}
+
+const (
+	caseSensitive = iota
+	caseInsensitive
+)
+
+func caseMode(ignoreCase bool) int {
+	if ignoreCase {
+		return caseInsensitive
+	}
+
+	return caseSensitive
+}
+
+// buildPatternMatchers builds the regex, json field and group matchers of the config.
+func buildPatternMatchers(config *conf.MatcherConfig) []match.Matcher {
+	matchers := make([]match.Matcher, 0, len(config.Regex)+len(config.NotRegex))
+
+	for _, regex := range config.Regex {
+		matchers = append(matchers, match.NewRegexMatcher(regexPattern(regex, config.IgnoreCase), true))
+	}
+
+	for _, regex := range config.NotRegex {
+		matchers = append(matchers, match.NewRegexMatcher(regexPattern(regex, config.IgnoreCase), false))
+	}
 
-	for i, contains := range config.Contains {
-		matchers[i] = match.NewContainsMatcher(contains, true)
+	if len(config.Fields) > 0 {
+		matchers = append(matchers, match.NewJSONMatcher(config.Fields, config.IgnoreCase))
 	}
 
-	containsLen := len(config.Contains)
+	if len(config.AnyOf) > 0 {
+		matchers = append(matchers, buildGroupMatcher(match.OperatorAny, config.AnyOf))
+	}
+
+	if len(config.AllOf) > 0 {
+		matchers = append(matchers, buildGroupMatcher(match.OperatorAll, config.AllOf))
+	}
 
-	for i, contains := range config.NotContains {
-		matchers[i+containsLen] = match.NewContainsMatcher(contains, false)
+	if len(config.NoneOf) > 0 {
+		matchers = append(matchers, buildGroupMatcher(match.OperatorNone, config.NoneOf))
 	}
 
 	return matchers
 }
+
+func isASCII(pattern string) bool {
+	for i := range len(pattern) {
+		if pattern[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+func regexPattern(pattern string, ignoreCase bool) string {
+	if ignoreCase {
+		return "(?i)" + pattern
+	}
+
+	return pattern
+}
+
+// buildGroupMatcher build a composite matcher combining the nested matchers with the operator.
+func buildGroupMatcher(operator match.CompositeOperator, configs []*conf.MatcherConfig) match.Matcher {
+	matchers := make([]match.Matcher, 0, len(configs))
+
+	for _, config := range configs {
+		nested := BuildMatcher(config)
+		if len(nested) == 1 {
+			matchers = append(matchers, nested[0])
+		} else {
+			matchers = append(matchers, match.NewCompositeMatcher(match.OperatorAll, nested...))
+		}
+	}
+
+	return match.NewCompositeMatcher(operator, matchers...)
+}
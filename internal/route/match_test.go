@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/match"
 	"github.com/vogo/logtail/internal/route"
 )
 
@@ -36,7 +37,7 @@ func TestNewMatchers(t *testing.T) {
 		{Contains: []string{"ERROR"}, NotContains: []string{"IGNORE"}},
 	})
 	assert.NoError(t, err)
-	assert.Len(t, matchers, 2)
+	assert.Len(t, matchers, 1)
 }
 
 func TestBuildMatchers(t *testing.T) {
@@ -47,7 +48,51 @@ func TestBuildMatchers(t *testing.T) {
 		{NotContains: []string{"DEBUG"}},
 	})
 
+	// all contains patterns are built into one matcher.
+	assert.Len(t, matchers, 1)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("ERROR and WARN")))
+	assert.False(t, router.Matches([]byte("ERROR only")))
+	assert.False(t, router.Matches([]byte("ERROR and WARN DEBUG")))
+}
+
+func TestBuildMatchers_IgnoreCase(t *testing.T) {
+	t.Parallel()
+
+	matchers := route.BuildMatchers([]*conf.MatcherConfig{
+		{Contains: []string{"error"}, IgnoreCase: true},
+		{Contains: []string{"Timeout"}},
+		{NotRegex: []string{`healthcheck`}, IgnoreCase: true},
+	})
+
 	assert.Len(t, matchers, 3)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("ERROR Timeout")))
+	assert.True(t, router.Matches([]byte("Error Timeout")))
+	assert.False(t, router.Matches([]byte("ERROR TIMEOUT")))
+	assert.False(t, router.Matches([]byte("ERROR Timeout HealthCheck")))
+}
+
+func TestBuildMatchers_IgnoreCaseUnicode(t *testing.T) {
+	t.Parallel()
+
+	matchers := route.BuildMatchers([]*conf.MatcherConfig{
+		{Contains: []string{"error", "ошибка"}, NotContains: []string{"Ärger"}, IgnoreCase: true},
+	})
+
+	// the non-ASCII patterns are folded by unicode, as the regex patterns.
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("ERROR: ОШИБКА")))
+	assert.True(t, router.Matches([]byte("Error: Ошибка")))
+	assert.False(t, router.Matches([]byte("ERROR: ОШИБКА ärger")))
+	assert.False(t, router.Matches([]byte("ERROR only")))
+
+	regexRouter := &route.Router{Matchers: route.BuildMatchers([]*conf.MatcherConfig{
+		{Regex: []string{"ошибка"}, IgnoreCase: true},
+	})}
+	assert.Equal(t, regexRouter.Matches([]byte("ОШИБКА")), router.Matches([]byte("ERROR ОШИБКА")))
 }
 
 func TestBuildMatcher(t *testing.T) {
@@ -58,15 +103,12 @@ func TestBuildMatcher(t *testing.T) {
 		NotContains: []string{"IGNORE", "SKIP"},
 	})
 
-	assert.Len(t, matchers, 3)
+	assert.Len(t, matchers, 1)
 
-	// First is contains matcher
 	assert.True(t, matchers[0].Match([]byte("ERROR happened")))
 	assert.False(t, matchers[0].Match([]byte("INFO happened")))
-
-	// Second and third are not_contains matchers
-	assert.False(t, matchers[1].Match([]byte("IGNORE this")))
-	assert.True(t, matchers[1].Match([]byte("ERROR real")))
+	assert.False(t, matchers[0].Match([]byte("ERROR IGNORE this")))
+	assert.False(t, matchers[0].Match([]byte("ERROR SKIP this")))
 }
 
 func TestBuildMatcher_Empty(t *testing.T) {
@@ -75,3 +117,96 @@ func TestBuildMatcher_Empty(t *testing.T) {
 	matchers := route.BuildMatcher(&conf.MatcherConfig{})
 	assert.Empty(t, matchers)
 }
+
+func TestBuildMatcher_Regex(t *testing.T) {
+	t.Parallel()
+
+	matchers := route.BuildMatcher(&conf.MatcherConfig{
+		Contains: []string{"status="},
+		Regex:    []string{`status=5\d\d`},
+		NotRegex: []string{`HealthCheck|/ping`},
+	})
+
+	assert.Len(t, matchers, 3)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("GET /api status=502")))
+	assert.False(t, router.Matches([]byte("GET /api status=200")))
+	assert.False(t, router.Matches([]byte("GET /ping status=503")))
+}
+
+func TestNewMatchers_InvalidRegex(t *testing.T) {
+	t.Parallel()
+
+	_, err := route.NewMatchers([]*conf.MatcherConfig{{Regex: []string{"status=("}}})
+	assert.ErrorIs(t, err, conf.ErrRegexInvalid)
+}
+
+func TestBuildRouter_MatcherGroups(t *testing.T) {
+	t.Parallel()
+
+	routerConfig := &conf.RouterConfig{
+		Name: "error-or-fatal",
+		AnyOf: []*conf.MatcherConfig{
+			{Contains: []string{"ERROR"}},
+			{Contains: []string{"FATAL"}},
+			{
+				Contains: []string{"WARN"},
+				AllOf:    []*conf.MatcherConfig{{Regex: []string{`took \d{4,}ms`}}},
+			},
+		},
+		NoneOf: []*conf.MatcherConfig{
+			{Contains: []string{"HealthCheck"}},
+		},
+	}
+
+	matchers, err := route.NewMatchers(routerConfig.MatcherConfigs())
+	assert.NoError(t, err)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("ERROR db failed")))
+	assert.True(t, router.Matches([]byte("FATAL out of memory")))
+	assert.True(t, router.Matches([]byte("WARN request took 1234ms")))
+	assert.False(t, router.Matches([]byte("WARN request took 12ms")))
+	assert.False(t, router.Matches([]byte("INFO ok")))
+	assert.False(t, router.Matches([]byte("ERROR HealthCheck failed")))
+}
+
+func TestBuildRouter_MatchersAndGroups(t *testing.T) {
+	t.Parallel()
+
+	routerConfig := &conf.RouterConfig{
+		Name:     "mixed",
+		Matchers: []*conf.MatcherConfig{{NotContains: []string{"IGNORE"}}},
+		AnyOf: []*conf.MatcherConfig{
+			{Contains: []string{"ERROR"}},
+			{Contains: []string{"FATAL"}},
+		},
+	}
+
+	matchers, err := route.NewMatchers(routerConfig.MatcherConfigs())
+	assert.NoError(t, err)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte("ERROR abc")))
+	assert.False(t, router.Matches([]byte("ERROR IGNORE abc")))
+	assert.False(t, router.Matches([]byte("INFO abc")))
+}
+
+func TestBuildMatchers_Fields(t *testing.T) {
+	t.Parallel()
+
+	status := 499.0
+
+	matchers, err := route.NewMatchers([]*conf.MatcherConfig{{
+		Contains: []string{"order"},
+		Fields:   []*match.FieldCondition{{Path: "http.status", Gt: &status}},
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, matchers, 2)
+
+	router := &route.Router{Matchers: matchers}
+	assert.True(t, router.Matches([]byte(`{"msg":"create order","http":{"status":500}}`)))
+	assert.False(t, router.Matches([]byte(`{"msg":"create order","http":{"status":200}}`)))
+	assert.False(t, router.Matches([]byte(`{"msg":"login","http":{"status":500}}`)))
+}
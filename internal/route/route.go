@@ -22,7 +22,9 @@ import (
 	"sync/atomic"
 
 	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/mask"
 	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 	"github.com/vogo/vogo/vlog"
 	"github.com/vogo/vogo/vsync/vrun"
@@ -38,12 +40,18 @@ type Router struct {
 	ID           string
 	Name         string
 	Source       string
-	Channel      chan []byte
+	Channel      chan *record.Record
 	Matchers     []match.Matcher
+	Stages       []Stage
 	Transfers    []trans.Transfer
+	Masker       *mask.Masker
+	MinLevel     match.Level
 	DropCount    atomic.Int64
 	BufferSize   int
 	BlockingMode bool
+
+	// pipeline processes matched records through the stages, and then transfers them.
+	pipeline func(*record.Record) error
 }
 
 func BuildRouter(workerRunner *vrun.Runner,
@@ -51,7 +59,7 @@ func BuildRouter(workerRunner *vrun.Runner,
 	transfersFunc trans.TransferMatcher,
 	routerID string, source string,
 ) *Router {
-	matchers, err := NewMatchers(routerConfig.Matchers)
+	matchers, err := NewMatchers(routerConfig.MatcherConfigs())
 	if err != nil {
 		panic(err)
 	}
@@ -67,13 +75,23 @@ func BuildRouter(workerRunner *vrun.Runner,
 		Source:       source,
 		Lock:         sync.Mutex{},
 		Runner:       workerRunner.NewChild(),
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		Matchers:     matchers,
 		Transfers:    transfersFunc(routerConfig.Transfers),
 		BufferSize:   bufferSize,
 		BlockingMode: routerConfig.BlockingMode,
+		MinLevel:     match.ParseLevel(routerConfig.MinLevel),
+	}
+
+	if routerConfig.Mask != nil {
+		router.Masker, err = mask.NewMasker(routerConfig.Mask.Builtin, routerConfig.Mask.Rules)
+		if err != nil {
+			panic(err)
+		}
 	}
 
+	router.SetStages(BuildStages(routerConfig)...)
+
 	return router
 }
 
@@ -81,27 +99,48 @@ func (r *Router) SetMatchers(matchers []match.Matcher) {
 	r.Matchers = matchers
 }
 
-// Route match lines and transfer.
-func (r *Router) Route(data []byte) error {
-	if len(r.Matchers) == 0 {
-		return r.Trans(data)
+// Route match records and transfer.
+// Records below the min level are skipped if the min level is set, including the ones of unknown level.
+func (r *Router) Route(rec *record.Record) error {
+	r.observe(rec)
+
+	if r.MinLevel != match.LevelUnknown && match.ParseLevel(rec.Level) < r.MinLevel {
+		return nil
 	}
 
-	if !r.Matches(data) {
+	if len(r.Matchers) > 0 && !r.MatchesRecord(rec) {
 		return nil
 	}
 
-	return r.Trans(data)
+	if r.pipeline == nil {
+		return r.Trans(rec)
+	}
+
+	return r.pipeline(rec)
 }
 
-func (r *Router) Trans(data []byte) error {
+func (r *Router) Trans(rec *record.Record) error {
 	transfers := r.Transfers
 	if len(transfers) == 0 {
 		return nil
 	}
 
+	// the record may be shared by routers, so transfer a copy with the router name.
+	data := rec.Data
+	if r.Masker != nil {
+		data = r.Masker.Mask(data)
+	}
+
+	rec = rec.WithData(data)
+	rec.Router = r.Name
+
+	// the fields are extracted from the raw data, mask them as the data.
+	if r.Masker != nil {
+		rec.Fields = r.Masker.MaskFields(rec.Fields)
+	}
+
 	for _, t := range transfers {
-		if err := t.Trans(r.Source, data); err != nil {
+		if err := t.Trans(rec); err != nil {
 			return err
 		}
 	}
@@ -116,7 +155,7 @@ func (r *Router) Stop() {
 	})
 }
 
-func (r *Router) Receive(data []byte) {
+func (r *Router) Receive(rec *record.Record) {
 	defer func() {
 		_ = recover()
 	}()
@@ -125,13 +164,13 @@ func (r *Router) Receive(data []byte) {
 		select {
 		case <-r.Runner.C:
 			return
-		case r.Channel <- data:
+		case r.Channel <- rec:
 		}
 	} else {
 		select {
 		case <-r.Runner.C:
 			return
-		case r.Channel <- data:
+		case r.Channel <- rec:
 		default:
 			r.DropCount.Add(1)
 		}
@@ -152,3 +191,14 @@ func (r *Router) Matches(bytes []byte) bool {
 
 	return true
 }
+
+// MatchesRecord whether the record matches all the matchers, including the conditions on the extracted fields.
+func (r *Router) MatchesRecord(rec *record.Record) bool {
+	for _, m := range r.Matchers {
+		if !match.MatchRecord(m, rec) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/vogo/logtail/internal/conf"
 	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/route"
 	"github.com/vogo/logtail/internal/trans"
 	"github.com/vogo/vogo/vsync/vrun"
@@ -39,23 +40,50 @@ func TestRoute(t *testing.T) {
 		ID:      "test-router",
 		Name:    "test-router",
 		Source:  "",
-		Channel: make(chan []byte, route.DefaultChannelBufferSize),
+		Channel: make(chan *record.Record, route.DefaultChannelBufferSize),
 		Matchers: []match.Matcher{
 			match.NewContainsMatcher("ERROR", true),
 			match.NewContainsMatcher("参数错误", false),
 			match.NewContainsMatcher("不存在", false),
 		},
-		Transfers: []trans.Transfer{&trans.ConsoleTransfer{}},
+		Transfers: []trans.Transfer{trans.NewBytesAdapter(&trans.ConsoleTransfer{})},
 	}
 
 	//nolint:lll //ignore this.
 	testLogMessage := `2022-05-20 13:35:53.794 ERROR [ConsumeMessageThread_1] [-] h.t.c.c.i.Service - 发起失败!失败原因msg=订单不存在, 参数postMap={"data":"xxx","open_id":"d99bcfde2e727e5eee7d4b5488741234","open_key":"17b130ef168500054f02b814bf261234","sign":"03f17f6f57235a8e0181aaa71ef51234","timestamp":"1653024953"}, 返回结果result={"errcode":8018,"msg":"\u539f\u59cb\u8ba2\u5355\u4e0d\u5b58\u5728"}`
 
-	err := router.Route([]byte(testLogMessage))
+	err := router.Route(record.New("", "", []byte(testLogMessage)))
 
 	assert.Nil(t, err)
 }
 
+type recordsTransfer struct {
+	trans.NullTransfer
+	records []*record.Record
+}
+
+func (r *recordsTransfer) Trans(records ...*record.Record) error {
+	r.records = append(r.records, records...)
+
+	return nil
+}
+
+func TestRouterTrans_RouterName(t *testing.T) {
+	t.Parallel()
+
+	transfer := &recordsTransfer{}
+	router := &route.Router{Name: "alert", Transfers: []trans.Transfer{transfer}}
+
+	rec := record.New("app", "", []byte("ERROR"))
+	assert.NoError(t, router.Trans(rec))
+
+	// the shared record is not changed.
+	assert.Empty(t, rec.Router)
+	assert.Len(t, transfer.records, 1)
+	assert.Equal(t, "alert", transfer.records[0].Router)
+	assert.Equal(t, "ERROR", string(transfer.records[0].Data))
+}
+
 func TestReceiveDropCounting(t *testing.T) {
 	t.Parallel()
 
@@ -70,14 +98,14 @@ func TestReceiveDropCounting(t *testing.T) {
 		ID:           "drop-test",
 		Name:         "drop-test",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: false,
 	}
 
 	// Send messages rapidly without consuming
 	for range totalMessages {
-		router.Receive([]byte("msg"))
+		router.Receive(record.New("", "", []byte("msg")))
 	}
 
 	// Drain the channel to count delivered messages
@@ -106,19 +134,19 @@ func TestReceiveBlockingMode(t *testing.T) {
 		ID:           "blocking-test",
 		Name:         "blocking-test",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: true,
 	}
 
 	// Fill the channel
-	router.Channel <- []byte("fill")
+	router.Channel <- record.New("", "", []byte("fill"))
 
 	// Receive in a goroutine -- should block since channel is full
 	done := make(chan struct{})
 
 	go func() {
-		router.Receive([]byte("blocked"))
+		router.Receive(record.New("", "", []byte("blocked")))
 		close(done)
 	}()
 
@@ -158,19 +186,19 @@ func TestBlockingModeRespectsStop(t *testing.T) {
 		ID:           "stop-test",
 		Name:         "stop-test",
 		Source:       "test",
-		Channel:      make(chan []byte, bufferSize),
+		Channel:      make(chan *record.Record, bufferSize),
 		BufferSize:   bufferSize,
 		BlockingMode: true,
 	}
 
 	// Fill the channel
-	router.Channel <- []byte("fill")
+	router.Channel <- record.New("", "", []byte("fill"))
 
 	// Receive in a goroutine -- should block since channel is full
 	done := make(chan struct{})
 
 	go func() {
-		router.Receive([]byte("blocked"))
+		router.Receive(record.New("", "", []byte("blocked")))
 		close(done)
 	}()
 
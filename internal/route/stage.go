@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"time"
+
+	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/record"
+)
+
+// StageTickInterval the interval to call Tick of the stages.
+const StageTickInterval = time.Second
+
+// Stage processes matched records between Router.Matches and Router.Trans.
+type Stage interface {
+	// Process processes a matched record, and calls next to pass records to the next stage.
+	// A stage may hold, replace or pass the record.
+	Process(rec *record.Record, next func(*record.Record) error) error
+}
+
+// Ticker is implemented by stages acting on time, Tick is called periodically in the router loop.
+// The records emitted by Tick are transferred directly without passing the following stages,
+// and the source of the router is set if the emitted record has no source.
+type Ticker interface {
+	Tick(now time.Time, emit func(*record.Record) error) error
+}
+
+// Observer is implemented by stages observing all records received by the router, including unmatched ones.
+type Observer interface {
+	Observe(rec *record.Record)
+}
+
+// BuildStages builds the stages of the router config.
+func BuildStages(routerConfig *conf.RouterConfig) []Stage {
+	var stages []Stage
+
+	if routerConfig.Silence != nil {
+		stages = append(stages, NewSilenceStage(routerConfig.Silence.GetDuration(),
+			routerConfig.Silence.AnyRecord))
+	}
+
+	if routerConfig.Threshold != nil {
+		stages = append(stages, NewThresholdStage(routerConfig.Threshold.Count,
+			routerConfig.Threshold.GetWindow()))
+	}
+
+	if routerConfig.Dedup != nil {
+		stages = append(stages, NewDedupStage(routerConfig.Dedup.GetWindow()))
+	}
+
+	return stages
+}
+
+// SetStages sets the stages processing matched records in order before transferring.
+func (r *Router) SetStages(stages ...Stage) {
+	r.Stages = stages
+
+	next := r.Trans
+
+	for i := len(stages) - 1; i >= 0; i-- {
+		stage, stageNext := stages[i], next
+		next = func(rec *record.Record) error {
+			return stage.Process(rec, stageNext)
+		}
+	}
+
+	r.pipeline = next
+}
+
+// observe passes the received record to the observer stages.
+func (r *Router) observe(rec *record.Record) {
+	for _, stage := range r.Stages {
+		if observer, ok := stage.(Observer); ok {
+			observer.Observe(rec)
+		}
+	}
+}
+
+// hasTicker whether any stage is a ticker.
+func (r *Router) hasTicker() bool {
+	for _, stage := range r.Stages {
+		if _, ok := stage.(Ticker); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tick calls the ticker stages, and transfers the records they emit.
+func (r *Router) Tick(now time.Time) error {
+	for _, stage := range r.Stages {
+		if ticker, ok := stage.(Ticker); ok {
+			if err := ticker.Tick(now, r.emit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// emit transfers the record emitted by the ticker stages.
+func (r *Router) emit(rec *record.Record) error {
+	if rec.Source == "" {
+		rec.Source = r.Source
+	}
+
+	return r.Trans(rec)
+}
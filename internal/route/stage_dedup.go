@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
+)
+
+// dedupMaxGroups the max number of fingerprints tracked in a window,
+// records of new fingerprints are passed without deduplication when exceeded.
+const dedupMaxGroups = 10000
+
+type dedupGroup struct {
+	start  time.Time
+	count  int
+	sample *record.Record
+}
+
+// DedupStage passes the first record of a fingerprint in the window, and counts the repeated ones.
+// A summary with the repeat count is passed when the window closes.
+type DedupStage struct {
+	window time.Duration
+	groups map[uint64]*dedupGroup
+	now    func() time.Time
+}
+
+// NewDedupStage new dedup stage.
+func NewDedupStage(window time.Duration) *DedupStage {
+	if window <= 0 {
+		panic("invalid dedup window")
+	}
+
+	return &DedupStage{
+		window: window,
+		groups: make(map[uint64]*dedupGroup),
+		now:    time.Now,
+	}
+}
+
+func (s *DedupStage) Process(rec *record.Record, next func(*record.Record) error) error {
+	now := s.now()
+	fingerprint := Fingerprint(rec.Data)
+
+	if group, ok := s.groups[fingerprint]; ok {
+		if now.Sub(group.start) < s.window {
+			group.count++
+
+			return nil
+		}
+
+		delete(s.groups, fingerprint)
+
+		if err := s.summary(group, next); err != nil {
+			return err
+		}
+	}
+
+	if len(s.groups) < dedupMaxGroups {
+		s.groups[fingerprint] = &dedupGroup{
+			start:  now,
+			sample: rec,
+		}
+	}
+
+	return next(rec)
+}
+
+// Tick passes the summaries of the closed windows.
+func (s *DedupStage) Tick(now time.Time, emit func(*record.Record) error) error {
+	for fingerprint, group := range s.groups {
+		if now.Sub(group.start) < s.window {
+			continue
+		}
+
+		delete(s.groups, fingerprint)
+
+		if err := s.summary(group, emit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// summary passes the summary record with the metadata of the sample.
+func (s *DedupStage) summary(group *dedupGroup, emit func(*record.Record) error) error {
+	if group.count == 0 {
+		return nil
+	}
+
+	return emit(group.sample.WithData(fmt.Appendf(nil, "repeated %d more times in %s:\n%s",
+		group.count, s.window, group.sample.Data)))
+}
+
+// Fingerprint returns the fingerprint of the normalized record.
+func Fingerprint(data []byte) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write(Normalize(data))
+
+	return hash.Sum64()
+}
+
+// Normalize normalizes a record by replacing the variable parts with `#`,
+// including timestamps, numbers, hex numbers and ids, and uuids.
+// A word containing digits and only hex letters is replaced as a whole,
+// and digits are replaced in other words, e.g. `pod-7f9c8d-x2k4` is normalized to `pod-#-x#k#`.
+func Normalize(data []byte) []byte {
+	normalized := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); {
+		if !isWordByte(data[i]) {
+			normalized = append(normalized, data[i])
+			i++
+
+			continue
+		}
+
+		end, hex, digit := i, true, false
+
+		// skip the prefix of hex numbers, e.g. 0x7fa3.
+		if i+2 < len(data) && data[i] == '0' && (data[i+1] == 'x' || data[i+1] == 'X') {
+			end = i + 2
+		}
+
+		for ; end < len(data) && isWordByte(data[end]); end++ {
+			digit = digit || isDigit(data[end])
+			hex = hex && isHexByte(data[end])
+		}
+
+		if digit && hex {
+			normalized = append(normalized, '#')
+		} else {
+			normalized = appendWordDigitsReplaced(normalized, data[i:end])
+		}
+
+		i = end
+	}
+
+	return normalized
+}
+
+func appendWordDigitsReplaced(normalized, word []byte) []byte {
+	for i, b := range word {
+		switch {
+		case !isDigit(b):
+			normalized = append(normalized, b)
+		case i == 0 || !isDigit(word[i-1]):
+			normalized = append(normalized, '#')
+		}
+	}
+
+	return normalized
+}
+
+func isWordByte(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isHexByte(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
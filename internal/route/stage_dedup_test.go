@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/record"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	for data, expected := range map[string]string{
+		"2024-01-02 10:11:12.345 ERROR user 1234 failed":                  "#-#-# #:#:#.# ERROR user # failed",
+		"request id=550e8400-e29b-41d4-a716-446655440000 cost 12ms":       "request id=#-#-#-#-# cost #ms",
+		"trace 0x7fa3bc01 in pod-7f9c8d-x2k4":                             "trace # in pod-#-x#k#",
+		"java.lang.NullPointerException: at Service.java:123, added face": "java.lang.NullPointerException: at Service.java:#, added face",
+	} {
+		assert.Equal(t, expected, string(Normalize([]byte(data))))
+	}
+
+	assert.Equal(t,
+		Fingerprint([]byte("2024-01-02 10:11:12 order 1001 timeout, trace=3f2a9c1b")),
+		Fingerprint([]byte("2024-01-03 08:00:59 order 2002 timeout, trace=77aa0e12")))
+	assert.NotEqual(t,
+		Fingerprint([]byte("2024-01-02 10:11:12 order 1001 timeout")),
+		Fingerprint([]byte("2024-01-02 10:11:12 order 1001 refused")))
+}
+
+func TestDedupStage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stage := NewDedupStage(time.Minute)
+	stage.now = func() time.Time { return now }
+
+	var records []string
+
+	next := func(rec *record.Record) error {
+		records = append(records, string(rec.Data))
+
+		return nil
+	}
+
+	process := func(after time.Duration, data string) {
+		now = now.Add(after)
+		assert.NoError(t, stage.Process(record.New("", "", []byte(data)), next))
+	}
+
+	process(0, "ERROR order 1 timeout")
+	process(time.Second, "ERROR order 2 timeout")
+	process(time.Second, "ERROR db refused")
+	process(time.Second, "ERROR order 3 timeout")
+	assert.Equal(t, []string{"ERROR order 1 timeout", "ERROR db refused"}, records)
+
+	// the window of the first fingerprint closes.
+	assert.NoError(t, stage.Tick(now.Add(57*time.Second), next))
+	assert.Equal(t, "repeated 2 more times in 1m0s:\nERROR order 1 timeout", records[2])
+	assert.Len(t, records, 3)
+
+	// no summary for a fingerprint without repeats.
+	assert.NoError(t, stage.Tick(now.Add(2*time.Minute), next))
+	assert.Len(t, records, 3)
+
+	process(3*time.Minute, "ERROR order 4 timeout")
+	process(time.Second, "ERROR order 5 timeout")
+
+	// the closed window not ticked yet is summarized before passing the new record.
+	process(time.Minute, "ERROR order 6 timeout")
+	assert.Equal(t, []string{
+		"ERROR order 4 timeout",
+		"repeated 1 more times in 1m0s:\nERROR order 4 timeout",
+		"ERROR order 6 timeout",
+	}, records[3:])
+}
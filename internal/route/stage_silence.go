@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
+)
+
+// SilenceStage passes an alert if no record arrives within the duration,
+// and a recovery record when records resume.
+// It checks the matched records, or any record received by the router if anyRecord is true.
+type SilenceStage struct {
+	duration  time.Duration
+	anyRecord bool
+	lastSeen  time.Time
+	silent    bool
+
+	// silentSince the last seen time when the silence alert passed.
+	silentSince time.Time
+	now         func() time.Time
+}
+
+// NewSilenceStage new silence stage, the duration starts from now.
+func NewSilenceStage(duration time.Duration, anyRecord bool) *SilenceStage {
+	if duration <= 0 {
+		panic("invalid silence duration")
+	}
+
+	return &SilenceStage{
+		duration:  duration,
+		anyRecord: anyRecord,
+		lastSeen:  time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *SilenceStage) Observe(_ *record.Record) {
+	if s.anyRecord {
+		s.lastSeen = s.now()
+	}
+}
+
+func (s *SilenceStage) Process(rec *record.Record, next func(*record.Record) error) error {
+	if !s.anyRecord {
+		s.lastSeen = s.now()
+	}
+
+	return next(rec)
+}
+
+func (s *SilenceStage) Tick(now time.Time, emit func(*record.Record) error) error {
+	silence := now.Sub(s.lastSeen)
+
+	switch {
+	case !s.silent && silence >= s.duration:
+		s.silent = true
+		s.silentSince = s.lastSeen
+
+		return emit(record.New("", "", fmt.Appendf(nil, "silence alert: no %s in %s", s.recordKind(), s.duration)))
+	case s.silent && silence < s.duration:
+		s.silent = false
+
+		return emit(record.New("", "", fmt.Appendf(nil, "silence recovered: %s resumed after %s",
+			s.recordKind(), s.lastSeen.Sub(s.silentSince).Truncate(time.Second))))
+	default:
+		return nil
+	}
+}
+
+func (s *SilenceStage) recordKind() string {
+	if s.anyRecord {
+		return "records"
+	}
+
+	return "matched records"
+}
@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/record"
+)
+
+func TestSilenceStage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stage := NewSilenceStage(time.Minute, false)
+	stage.now = func() time.Time { return now }
+	stage.lastSeen = now
+
+	var records []string
+
+	next := func(rec *record.Record) error {
+		records = append(records, string(rec.Data))
+
+		return nil
+	}
+
+	tick := func(after time.Duration) {
+		now = now.Add(after)
+		assert.NoError(t, stage.Tick(now, next))
+	}
+
+	tick(30 * time.Second)
+	assert.NoError(t, stage.Process(record.New("", "", []byte("ERROR 1")), next))
+	assert.Equal(t, []string{"ERROR 1"}, records)
+
+	// unmatched records are not counted.
+	stage.Observe(record.New("", "", []byte("INFO 1")))
+	tick(59 * time.Second)
+	assert.Len(t, records, 1)
+
+	tick(time.Second)
+	assert.Equal(t, "silence alert: no matched records in 1m0s", records[1])
+
+	// alert only once.
+	tick(time.Minute)
+	assert.Len(t, records, 2)
+
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, stage.Process(record.New("", "", []byte("ERROR 2")), next))
+	tick(time.Second)
+	assert.Equal(t, []string{"ERROR 2", "silence recovered: matched records resumed after 2m30s"}, records[2:])
+}
+
+func TestSilenceStage_AnyRecord(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stage := NewSilenceStage(time.Minute, true)
+	stage.now = func() time.Time { return now }
+	stage.lastSeen = now
+
+	var records []string
+
+	next := func(rec *record.Record) error {
+		records = append(records, string(rec.Data))
+
+		return nil
+	}
+
+	now = now.Add(50 * time.Second)
+	stage.Observe(record.New("", "", []byte("INFO 1")))
+
+	now = now.Add(50 * time.Second)
+	assert.NoError(t, stage.Tick(now, next))
+	assert.Empty(t, records)
+
+	now = now.Add(10 * time.Second)
+	assert.NoError(t, stage.Tick(now, next))
+	assert.Equal(t, []string{"silence alert: no records in 1m0s"}, records)
+
+	now = now.Add(10 * time.Second)
+	stage.Observe(record.New("", "", []byte("INFO 2")))
+	assert.NoError(t, stage.Tick(now, next))
+	assert.Equal(t, "silence recovered: records resumed after 1m10s", records[1])
+
+	assert.Panics(t, func() { NewSilenceStage(0, true) })
+}
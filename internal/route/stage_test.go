@@ -0,0 +1,254 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/mask"
+	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/route"
+	"github.com/vogo/logtail/internal/trans"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+// collectTransfer returns a transfer collecting the transferred records.
+func collectTransfer(records *[]string) *mockTransfer {
+	return &mockTransfer{
+		transFn: func(_ string, data ...[]byte) error {
+			for _, d := range data {
+				*records = append(*records, string(d))
+			}
+
+			return nil
+		},
+	}
+}
+
+func TestBuildRouter_Threshold(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	routerConfig := &conf.RouterConfig{
+		Name:      "threshold",
+		Matchers:  []*conf.MatcherConfig{{Contains: []string{"ERROR"}}},
+		Threshold: &conf.ThresholdConfig{Count: 3, WindowSeconds: 60},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{collectTransfer(&records)}
+	}, "threshold-test", "source")
+	defer router.Stop()
+
+	assert.NoError(t, router.Route(record.New("", "", []byte("ERROR 1"))))
+	assert.NoError(t, router.Route(record.New("", "", []byte("INFO 1"))))
+	assert.NoError(t, router.Route(record.New("", "", []byte("ERROR 2"))))
+	assert.Empty(t, records)
+
+	assert.NoError(t, router.Route(record.New("", "", []byte("ERROR 3"))))
+	assert.Equal(t, []string{"threshold reached: 3 matches in 1m0s, sample:\nERROR 3"}, records)
+
+	// muted in the window.
+	assert.NoError(t, router.Route(record.New("", "", []byte("ERROR 4"))))
+	assert.Len(t, records, 1)
+}
+
+func TestSetStages(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	router := &route.Router{Transfers: []trans.Transfer{collectTransfer(&records)}}
+	router.SetStages(
+		stageFunc(func(rec *record.Record, next func(*record.Record) error) error {
+			return next(rec.WithData(append([]byte("1:"), rec.Data...)))
+		}),
+		stageFunc(func(rec *record.Record, next func(*record.Record) error) error {
+			if err := next(rec.WithData(append([]byte("2:"), rec.Data...))); err != nil {
+				return err
+			}
+
+			return next(rec.WithData(append([]byte("3:"), rec.Data...)))
+		}),
+	)
+
+	assert.NoError(t, router.Route(record.New("", "", []byte("data"))))
+	assert.Equal(t, []string{"2:1:data", "3:1:data"}, records)
+}
+
+type stageFunc func(rec *record.Record, next func(*record.Record) error) error
+
+func (f stageFunc) Process(rec *record.Record, next func(*record.Record) error) error {
+	return f(rec, next)
+}
+
+func TestBuildRouter_Silence(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	routerConfig := &conf.RouterConfig{
+		Name:      "silence",
+		Matchers:  []*conf.MatcherConfig{{Contains: []string{"ERROR"}}},
+		Silence:   &conf.SilenceConfig{DurationSeconds: 60, AnyRecord: true},
+		Threshold: &conf.ThresholdConfig{Count: 2, WindowSeconds: 60},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{collectTransfer(&records)}
+	}, "silence-test", "source")
+	defer router.Stop()
+
+	assert.Len(t, router.Stages, 2)
+
+	// the silence alert is transferred directly without passing the threshold stage.
+	assert.NoError(t, router.Route(record.New("", "", []byte("INFO 1"))))
+	assert.NoError(t, router.Tick(time.Now().Add(time.Minute+time.Second)))
+	assert.Equal(t, []string{"silence alert: no records in 1m0s"}, records)
+}
+
+func TestBuildRouter_Mask(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	routerConfig := &conf.RouterConfig{
+		Name:    "mask",
+		Silence: &conf.SilenceConfig{DurationSeconds: 60},
+		Mask: &conf.MaskConfig{
+			Builtin: []string{mask.RulePhone},
+			Rules:   []*mask.Rule{{Regex: `token=\w+`, Replacement: "token=***"}},
+		},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{collectTransfer(&records)}
+	}, "mask-test", "source")
+	defer router.Stop()
+
+	assert.NoError(t, router.Route(record.New("", "", []byte("ERROR user 13812345678 token=abc failed"))))
+	assert.Equal(t, []string{"ERROR user ****** token=*** failed"}, records)
+}
+
+func TestBuildRouter_MaskExtractedFields(t *testing.T) {
+	t.Parallel()
+
+	envelopes := make(chan *trans.Envelope, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		envelope := &trans.Envelope{}
+		_ = json.NewDecoder(r.Body).Decode(envelope)
+		envelopes <- envelope
+	}))
+	defer server.Close()
+
+	webhook := trans.NewWebhookTransfer("webhook", server.URL, "", trans.HTTPTransferOptions{Envelope: true})
+	defer func() { _ = webhook.Stop() }()
+
+	routerConfig := &conf.RouterConfig{
+		Name: "mask-fields",
+		Mask: &conf.MaskConfig{Builtin: []string{mask.RulePassword, mask.RulePhone}},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{webhook}
+	}, "mask-fields-test", "source")
+	defer router.Stop()
+
+	format := &match.Format{Extract: []string{`user=(?P<user>\w+) password=(?P<password>\S+) phone=(?P<phone>\d+)`}}
+
+	rec := record.New("app", "", []byte("ERROR login user=bob password=s3cret phone=13812345678"))
+	rec.Fields = format.ExtractFields(rec.Data)
+	assert.NoError(t, router.Route(rec))
+
+	envelope := <-envelopes
+	assert.Len(t, envelope.Records, 1)
+	assert.Equal(t, "ERROR login user=bob password=****** phone=******", envelope.Records[0].Message)
+	assert.Equal(t, map[string]string{"user": "bob", "password": "******", "phone": "******"},
+		envelope.Records[0].Fields)
+
+	// the fields of the record shared by routers are not changed.
+	assert.Equal(t, "s3cret", rec.Fields["password"])
+}
+
+func TestBuildRouter_MinLevel(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	routerConfig := &conf.RouterConfig{
+		Name:     "level",
+		MinLevel: "warn",
+		Matchers: []*conf.MatcherConfig{{NotContains: []string{"ignore"}}},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{collectTransfer(&records)}
+	}, "level-test", "source")
+	defer router.Stop()
+
+	for data, level := range map[string]match.Level{
+		"info":         match.LevelInfo,
+		"warn":         match.LevelWarn,
+		"fatal":        match.LevelFatal,
+		"error ignore": match.LevelError,
+		"unknown":      match.LevelUnknown,
+	} {
+		rec := record.New("", "", []byte(data))
+		rec.Level = level.String()
+		assert.NoError(t, router.Route(rec))
+	}
+
+	assert.ElementsMatch(t, []string{"warn", "fatal"}, records)
+}
+
+func TestBuildRouter_ExtractedFields(t *testing.T) {
+	t.Parallel()
+
+	var records []string
+
+	status := 499.0
+	routerConfig := &conf.RouterConfig{
+		Name:     "fields",
+		Matchers: []*conf.MatcherConfig{{Fields: []*match.FieldCondition{{Path: "status", Gt: &status}}}},
+	}
+
+	router := route.BuildRouter(vrun.New(), routerConfig, func(_ []string) []trans.Transfer {
+		return []trans.Transfer{collectTransfer(&records)}
+	}, "fields-test", "source")
+	defer router.Stop()
+
+	for data, status := range map[string]string{"GET /a 502": "502", "GET /b 200": "200", "GET /c": ""} {
+		rec := record.New("", "", []byte(data))
+		if status != "" {
+			rec.Fields = map[string]string{"status": status}
+		}
+
+		assert.NoError(t, router.Route(rec))
+	}
+
+	assert.Equal(t, []string{"GET /a 502"}, records)
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
+)
+
+// thresholdSlotNum the number of slots counting matches in the window.
+const thresholdSlotNum = 60
+
+// ThresholdStage passes an alert only if the matched records reach the count within the window.
+// The alert contains the count and the record reaching the threshold as a sample,
+// and no more alert is passed in the next window.
+type ThresholdStage struct {
+	count      int
+	window     time.Duration
+	slot       time.Duration
+	slotCounts [thresholdSlotNum]int
+	slotIndex  [thresholdSlotNum]int64
+	mutedUntil time.Time
+	now        func() time.Time
+}
+
+// NewThresholdStage new threshold stage.
+func NewThresholdStage(count int, window time.Duration) *ThresholdStage {
+	if count <= 0 || window <= 0 {
+		panic("invalid threshold")
+	}
+
+	slot := window / thresholdSlotNum
+	if slot <= 0 {
+		slot = 1
+	}
+
+	return &ThresholdStage{
+		count:  count,
+		window: window,
+		slot:   slot,
+		now:    time.Now,
+	}
+}
+
+func (s *ThresholdStage) Process(rec *record.Record, next func(*record.Record) error) error {
+	now := s.now()
+	count := s.incr(now)
+
+	if count < s.count || now.Before(s.mutedUntil) {
+		return nil
+	}
+
+	s.mutedUntil = now.Add(s.window)
+
+	return next(s.alert(count, rec))
+}
+
+// incr counts a match at the time, and returns the count of matches in the window.
+func (s *ThresholdStage) incr(now time.Time) int {
+	index := now.UnixNano() / int64(s.slot)
+	pos := index % thresholdSlotNum
+
+	if s.slotIndex[pos] != index {
+		s.slotIndex[pos] = index
+		s.slotCounts[pos] = 0
+	}
+
+	s.slotCounts[pos]++
+
+	count := 0
+
+	for i := range thresholdSlotNum {
+		if index-s.slotIndex[i] < thresholdSlotNum {
+			count += s.slotCounts[i]
+		}
+	}
+
+	return count
+}
+
+// alert returns the alert record with the metadata of the sample.
+func (s *ThresholdStage) alert(count int, sample *record.Record) *record.Record {
+	return sample.WithData(fmt.Appendf(nil, "threshold reached: %d matches in %s, sample:\n%s",
+		count, s.window, sample.Data))
+}
@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/record"
+)
+
+func TestThresholdStage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stage := NewThresholdStage(3, time.Minute)
+	stage.now = func() time.Time { return now }
+
+	var alerts []string
+
+	next := func(rec *record.Record) error {
+		alerts = append(alerts, string(rec.Data))
+
+		return nil
+	}
+
+	process := func(after time.Duration, data string) {
+		now = now.Add(after)
+		assert.NoError(t, stage.Process(record.New("", "", []byte(data)), next))
+	}
+
+	// matches out of the window are not counted.
+	process(0, "a")
+	process(40*time.Second, "b")
+	process(30*time.Second, "c")
+	assert.Empty(t, alerts)
+
+	process(time.Second, "d")
+	assert.Equal(t, []string{"threshold reached: 3 matches in 1m0s, sample:\nd"}, alerts)
+
+	// muted in the window after alerting, but still counted.
+	process(10*time.Second, "e")
+	process(10*time.Second, "f")
+	assert.Len(t, alerts, 1)
+
+	process(41*time.Second, "g")
+	assert.Equal(t, "threshold reached: 3 matches in 1m0s, sample:\ng", alerts[1])
+
+	assert.Panics(t, func() { NewThresholdStage(0, time.Minute) })
+}
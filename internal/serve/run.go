@@ -40,7 +40,7 @@ func (s *Server) Start(serverConfig *conf.ServerConfig) {
 		if fwatch.IsDir(serverConfig.File.Path) {
 			go s.startDirWorkers(serverConfig.File)
 		} else {
-			s.AddWorker(util.FollowRetryTailCommand(serverConfig.File.Path), false)
+			s.AddFileWorker(serverConfig.File.Path, s.Checkpoint.Position(serverConfig.File.Path))
 		}
 	case serverConfig.CommandGen != "":
 		go s.StartCommandGenLoop(serverConfig.CommandGen)
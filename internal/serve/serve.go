@@ -19,6 +19,7 @@ package serve
 
 import (
 	"sync"
+	"time"
 
 	"github.com/vogo/logtail/internal/conf"
 	"github.com/vogo/logtail/internal/match"
@@ -39,15 +40,22 @@ type Server struct {
 	MergingWorker     *work.Worker
 	WorkerIndex       int
 	Workers           map[string]*work.Worker
+	Checkpoint        *work.Checkpoint
+
+	// FlushTimeout, MaxRecordSize and SplitLargeRecord are passed to the workers.
+	FlushTimeout     time.Duration
+	MaxRecordSize    int
+	SplitLargeRecord bool
 }
 
 // NewRawServer StartLoop a new server.
 func NewRawServer(id string) *Server {
 	server := &Server{
-		ID:      id,
-		lock:    sync.Mutex{},
-		Runner:  vrun.New(),
-		Workers: make(map[string]*work.Worker, util.DefaultMapSize),
+		ID:         id,
+		lock:       sync.Mutex{},
+		Runner:     vrun.New(),
+		Workers:    make(map[string]*work.Worker, util.DefaultMapSize),
+		Checkpoint: work.NewCheckpoint(""),
 	}
 
 	return server
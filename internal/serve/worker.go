@@ -31,6 +31,20 @@ func (s *Server) AddWorker(command string, dynamic bool) *work.Worker {
 	return worker
 }
 
+// AddFileWorker add a worker following the file from the position, nil position means the end of the file.
+func (s *Server) AddFileWorker(file string, position *work.FilePosition) *work.Worker {
+	worker := s.buildWorker("", false)
+	worker.Follower = work.NewFileFollower(file, position)
+
+	s.Checkpoint.Register(worker.Follower)
+
+	go worker.StartLoop()
+
+	s.Workers[worker.ID] = worker
+
+	return worker
+}
+
 func (s *Server) buildWorker(command string, dynamic bool) *work.Worker {
 	s.WorkerIndex++
 	workerID := fmt.Sprintf("%s-%d", s.ID, s.WorkerIndex)
@@ -44,6 +58,9 @@ func (s *Server) buildWorker(command string, dynamic bool) *work.Worker {
 	worker.TransfersFunc = s.TransferMatcher
 	worker.RouterConfigsFunc = s.RouterConfigsFunc
 	worker.MergingWorker = s.MergingWorker
+	worker.FlushTimeout = s.FlushTimeout
+	worker.MaxRecordSize = s.MaxRecordSize
+	worker.SplitLargeRecord = s.SplitLargeRecord
 
 	return worker
 }
@@ -61,6 +78,10 @@ func (s *Server) StopWorkers() {
 	for k, w := range s.Workers {
 		w.Stop()
 
+		if w.Follower != nil {
+			s.Checkpoint.Unregister(w.Follower)
+		}
+
 		// fix nil exception
 		delete(s.Workers, k)
 	}
@@ -18,6 +18,8 @@
 package serve
 
 import (
+	"io/fs"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -45,14 +47,14 @@ func (s *Server) startDirWorkers(config *conf.FileConfig) {
 		vlog.Fatal(err)
 	}
 
-	// start watch loop first
-	go s.startDirWatchWorkers(config.Path, watcher)
-
 	matcher := func(name string) bool {
 		return (config.Prefix == "" || strings.HasPrefix(name, config.Prefix)) &&
 			(config.Suffix == "" || strings.HasSuffix(name, config.Suffix))
 	}
 
+	// start watch loop first
+	go s.startDirWatchWorkers(config.Path, watcher, listDirFiles(config.Path, config.Recursive))
+
 	vlog.Infof("server [%s] StartLoop watch directory: %s", s.ID, config.Path)
 
 	if err = watcher.WatchDir(config.Path, config.Recursive, matcher); err != nil {
@@ -66,7 +68,33 @@ const fileInactiveDeadline = time.Hour
 // file silence deadline, default one day.
 const fileSilenceDeadline = time.Hour * 24
 
-func (s *Server) startDirWatchWorkers(path string, watcher *fwatch.FileWatcher) {
+// listDirFiles returns the files existing before watching,
+// which will be followed from the end, while new files are followed from the start.
+func listDirFiles(dir string, recursive bool) map[string]struct{} {
+	files := make(map[string]struct{}, util.DefaultMapSize)
+
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		files[path] = struct{}{}
+
+		return nil
+	})
+
+	return files
+}
+
+func (s *Server) startDirWatchWorkers(path string, watcher *fwatch.FileWatcher, existFiles map[string]struct{}) {
 	defer func() {
 		_ = watcher.Stop()
 
@@ -94,8 +122,15 @@ func (s *Server) startDirWatchWorkers(path string, watcher *fwatch.FileWatcher)
 				if w, ok := fileWorkerMap[watchEvent.Name]; ok {
 					vlog.Infof("worker [%s] is already tailing file: %s", w.ID, watchEvent.Name)
 				} else {
-					// non-dynamic worker will retry self
-					w = s.AddWorker(util.FollowRetryTailCommand(watchEvent.Name), false)
+					position := s.Checkpoint.Position(watchEvent.Name)
+					if _, exist := existFiles[watchEvent.Name]; position == nil && !exist {
+						// follow new file from the start.
+						position = &work.FilePosition{}
+					}
+
+					delete(existFiles, watchEvent.Name)
+
+					w = s.AddFileWorker(watchEvent.Name, position)
 					fileWorkerMap[watchEvent.Name] = w
 				}
 			case fwatch.Inactive:
@@ -103,6 +138,7 @@ func (s *Server) startDirWatchWorkers(path string, watcher *fwatch.FileWatcher)
 
 				if w, ok := fileWorkerMap[watchEvent.Name]; ok {
 					w.Shutdown()
+					s.Checkpoint.Unregister(w.Follower)
 					delete(fileWorkerMap, watchEvent.Name)
 				}
 			case fwatch.Remove, fwatch.Silence:
@@ -112,6 +148,9 @@ func (s *Server) startDirWatchWorkers(path string, watcher *fwatch.FileWatcher)
 					w.Shutdown()
 					delete(fileWorkerMap, watchEvent.Name)
 				}
+
+				delete(existFiles, watchEvent.Name)
+				s.Checkpoint.Remove(watchEvent.Name)
 			default:
 				vlog.Warnf("unknown event: %s, %s", watchEvent.Event, watchEvent.Name)
 			}
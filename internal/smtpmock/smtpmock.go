@@ -0,0 +1,305 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package smtpmock provides a local SMTP server receiving mails for testing the email transfer.
+package smtpmock
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Config the config of the mock server.
+type Config struct {
+	// TLS enables STARTTLS, or implicit TLS if ImplicitTLS is true.
+	TLS         *tls.Config
+	ImplicitTLS bool
+
+	// Username and Password are required by AUTH PLAIN before sending if the username is not empty.
+	Username string
+	Password string
+}
+
+// Message the mail received by the server.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+
+	// TLS whether the mail is received over TLS.
+	TLS bool
+}
+
+// Server the mock SMTP server.
+type Server struct {
+	config   Config
+	listener net.Listener
+	handler  func(*Message)
+	wg       sync.WaitGroup
+}
+
+// Listen starts the server on the address, the handler is called for each mail received.
+func Listen(addr string, config Config, handler func(*Message)) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ImplicitTLS {
+		listener = tls.NewListener(listener, config.TLS)
+	}
+
+	s := &Server{config: config, listener: listener, handler: handler}
+
+	s.wg.Add(1)
+
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr the address the server listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close closes the listener and waits for the connections finished.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			defer func() { _ = conn.Close() }()
+
+			s.handle(conn)
+		}()
+	}
+}
+
+type session struct {
+	server *Server
+	conn   net.Conn
+	text   *textproto.Conn
+	tls    bool
+	authed bool
+	msg    *Message
+}
+
+func (s *Server) handle(conn net.Conn) {
+	_, isTLS := conn.(*tls.Conn)
+
+	ss := &session{server: s, conn: conn, text: textproto.NewConn(conn), tls: isTLS}
+
+	if err := ss.reply(220, "smtpmock ESMTP ready"); err != nil {
+		return
+	}
+
+	for {
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		quit, err := ss.command(strings.ToUpper(verb), arg)
+		if quit || err != nil {
+			return
+		}
+	}
+}
+
+//nolint:cyclop // ignore this
+func (ss *session) command(verb, arg string) (bool, error) {
+	config := ss.server.config
+
+	switch verb {
+	case "EHLO", "HELO":
+		return false, ss.hello()
+	case "STARTTLS":
+		if config.TLS == nil || ss.tls {
+			return false, ss.reply(502, "5.5.1 STARTTLS not supported")
+		}
+
+		if err := ss.reply(220, "2.0.0 ready to start TLS"); err != nil {
+			return false, err
+		}
+
+		tlsConn := tls.Server(ss.conn, config.TLS)
+		if err := tlsConn.Handshake(); err != nil {
+			return false, err
+		}
+
+		ss.conn, ss.text, ss.tls, ss.msg = tlsConn, textproto.NewConn(tlsConn), true, nil
+
+		return false, nil
+	case "AUTH":
+		return false, ss.auth(arg)
+	case "MAIL":
+		if config.Username != "" && !ss.authed {
+			return false, ss.reply(530, "5.7.0 authentication required")
+		}
+
+		ss.msg = &Message{From: address(arg), TLS: ss.tls}
+
+		return false, ss.reply(250, "2.1.0 ok")
+	case "RCPT":
+		if ss.msg == nil {
+			return false, ss.reply(503, "5.5.1 need MAIL first")
+		}
+
+		ss.msg.To = append(ss.msg.To, address(arg))
+
+		return false, ss.reply(250, "2.1.5 ok")
+	case "DATA":
+		return false, ss.data()
+	case "RSET":
+		ss.msg = nil
+
+		return false, ss.reply(250, "2.0.0 ok")
+	case "NOOP":
+		return false, ss.reply(250, "2.0.0 ok")
+	case "QUIT":
+		_ = ss.reply(221, "2.0.0 bye")
+
+		return true, nil
+	default:
+		return false, ss.reply(502, "5.5.2 command not recognized")
+	}
+}
+
+func (ss *session) hello() error {
+	lines := []string{"smtpmock"}
+
+	if ss.server.config.TLS != nil && !ss.tls {
+		lines = append(lines, "STARTTLS")
+	}
+
+	if ss.server.config.Username != "" {
+		lines = append(lines, "AUTH PLAIN")
+	}
+
+	lines = append(lines, "8BITMIME")
+
+	for i, line := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+
+		if err := ss.text.PrintfLine("250%s%s", sep, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ss *session) auth(arg string) error {
+	mechanism, response, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		return ss.reply(504, "5.5.4 unrecognized authentication type")
+	}
+
+	if response == "" {
+		if err := ss.reply(334, ""); err != nil {
+			return err
+		}
+
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		response = line
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(response)
+	if err != nil {
+		return ss.reply(501, "5.5.2 invalid base64")
+	}
+
+	// the plain response: authorization identity, username, password separated by NUL.
+	parts := bytes.Split(decoded, []byte{0})
+	config := ss.server.config
+
+	if len(parts) != 3 || string(parts[1]) != config.Username || string(parts[2]) != config.Password {
+		return ss.reply(535, "5.7.8 authentication failed")
+	}
+
+	ss.authed = true
+
+	return ss.reply(235, "2.7.0 authentication successful")
+}
+
+func (ss *session) data() error {
+	if ss.msg == nil || len(ss.msg.To) == 0 {
+		return ss.reply(503, "5.5.1 need RCPT first")
+	}
+
+	if err := ss.reply(354, "end data with <CR><LF>.<CR><LF>"); err != nil {
+		return err
+	}
+
+	data, err := ss.text.ReadDotBytes()
+	if err != nil {
+		return err
+	}
+
+	ss.msg.Data = data
+
+	if ss.server.handler != nil {
+		ss.server.handler(ss.msg)
+	}
+
+	ss.msg = nil
+
+	return ss.reply(250, "2.0.0 ok: queued")
+}
+
+func (ss *session) reply(code int, message string) error {
+	return ss.text.PrintfLine("%d %s", code, message)
+}
+
+// address parses the address of `FROM:<address>` or `TO:<address> params`.
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+
+	return strings.Trim(addr, "<>")
+}
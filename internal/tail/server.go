@@ -18,6 +18,8 @@
 package tail
 
 import (
+	"time"
+
 	"github.com/vogo/logtail/internal/conf"
 	"github.com/vogo/logtail/internal/serve"
 )
@@ -67,6 +69,10 @@ func buildServer(serverConfig *conf.ServerConfig, tailer *Tailer) *serve.Server
 	}
 
 	server.Format = format
+	server.Checkpoint = tailer.Checkpoint
+	server.FlushTimeout = time.Duration(serverConfig.FlushTimeoutMs) * time.Millisecond
+	server.MaxRecordSize = serverConfig.MaxRecordSize
+	server.SplitLargeRecord = serverConfig.SplitLargeRecord
 
 	if existsServer, ok := tailer.Servers[server.ID]; ok {
 		_ = existsServer.Stop()
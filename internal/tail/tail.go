@@ -25,15 +25,17 @@ import (
 	"github.com/vogo/logtail/internal/serve"
 	"github.com/vogo/logtail/internal/trans"
 	"github.com/vogo/logtail/internal/util"
+	"github.com/vogo/logtail/internal/work"
 	"github.com/vogo/vogo/vlog"
 )
 
 // Tailer the logtail tailer.
 type Tailer struct {
-	lock      sync.Mutex
-	Config    *conf.Config
-	Servers   map[string]*serve.Server
-	Transfers map[string]trans.Transfer
+	lock       sync.Mutex
+	Config     *conf.Config
+	Servers    map[string]*serve.Server
+	Transfers  map[string]trans.Transfer
+	Checkpoint *work.Checkpoint
 }
 
 // NewTailer new logtail tailer.
@@ -43,10 +45,11 @@ func NewTailer(config *conf.Config) (*Tailer, error) {
 	}
 
 	tailer := &Tailer{
-		lock:      sync.Mutex{},
-		Config:    config,
-		Servers:   make(map[string]*serve.Server, util.DefaultMapSize),
-		Transfers: make(map[string]trans.Transfer, util.DefaultMapSize),
+		lock:       sync.Mutex{},
+		Config:     config,
+		Servers:    make(map[string]*serve.Server, util.DefaultMapSize),
+		Transfers:  make(map[string]trans.Transfer, util.DefaultMapSize),
+		Checkpoint: work.NewCheckpoint(config.GetCheckpointFile()),
 	}
 
 	return tailer, nil
@@ -63,6 +66,12 @@ func (t *Tailer) Start() error {
 		return err
 	}
 
+	if err := t.Checkpoint.Load(); err != nil {
+		vlog.Warnf("load checkpoint error: %v", err)
+	}
+
+	go t.Checkpoint.StartFlushLoop(time.Duration(t.Config.CheckpointIntervalSeconds) * time.Second)
+
 	for _, serverConfig := range t.Config.Servers {
 		_, err := t.AddServer(serverConfig)
 		if err != nil {
@@ -81,6 +90,9 @@ type RouterStats struct {
 	DropCount    int64  `json:"drop_count"`
 	BufferSize   int    `json:"buffer_size"`
 	BlockingMode bool   `json:"blocking_mode"`
+
+	// Format the format preset of the worker, `auto` if it's still detecting.
+	Format string `json:"format,omitempty"`
 }
 
 // CollectRouterStats returns pipeline statistics for all active routers.
@@ -100,6 +112,7 @@ func (t *Tailer) CollectRouterStats() []RouterStats {
 					DropCount:    router.DroppedMessages(),
 					BufferSize:   router.BufferSize,
 					BlockingMode: router.BlockingMode,
+					Format:       worker.FormatName(),
 				})
 			}
 		}
@@ -119,6 +132,8 @@ func (t *Tailer) Stop() {
 		}
 	}
 
+	t.Checkpoint.Stop()
+
 	for _, t := range t.Transfers {
 		if err := t.Stop(); err != nil {
 			vlog.Errorf("transfer %s close error: %+v", t.Name(), err)
@@ -284,6 +284,91 @@ func TestBuildTransfer_Lark(t *testing.T) {
 	assert.Equal(t, "l", transfer.Name())
 }
 
+func TestBuildTransfer_Slack(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "s", Type: "slack", URL: "http://slack.example.com", Channel: "#alerts", Username: "logtail",
+	})
+	assert.Equal(t, "s", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_WeCom(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "w", Type: "wecom", URL: "http://wecom.example.com", MsgType: "markdown",
+		MentionedList: []string{"@all"},
+	})
+	assert.Equal(t, "w", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_Telegram(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "tg", Type: "telegram", BotToken: "123:abc", ChatID: "-100123", ParseMode: "HTML",
+	})
+	assert.Equal(t, "tg", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_Email(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "e", Type: "email", Host: "smtp.example.com", From: "logtail@example.com",
+		To: []string{"ops@example.com"}, DigestInterval: "1h",
+	})
+	assert.Equal(t, "e", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_Kafka(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "k", Type: "kafka", Brokers: []string{"127.0.0.1:9092"}, Topic: "logs-{{.Source}}",
+		Key: "source", Acks: "leader", Compression: "lz4", BatchTimeout: "100ms",
+	})
+	assert.Equal(t, "k", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_Elasticsearch(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "es", Type: "elasticsearch", URL: "http://127.0.0.1:9200", Index: "logs-{{.Source}}",
+		BatchSize: 100, BatchTimeout: "1s", APIKey: "key",
+	})
+	assert.Equal(t, "es", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_Loki(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "loki", Type: "loki", URL: "http://127.0.0.1:3100", Labels: map[string]string{"env": "prod"},
+		Encoding: "json", TenantID: "team", BatchSize: 100, BatchTimeout: "1s",
+	})
+	assert.Equal(t, "loki", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
+func TestBuildTransfer_OTLP(t *testing.T) {
+	t.Parallel()
+
+	transfer := tail.BuildTransfer(&conf.TransferConfig{
+		Name: "otlp", Type: "otlp", URL: "http://127.0.0.1:4318", Encoding: "json", BatchSize: 100, BatchTimeout: "1s",
+	})
+	assert.Equal(t, "otlp", transfer.Name())
+	assert.NoError(t, transfer.Stop())
+}
+
 func TestBuildTransfer_WithHTTPOptions(t *testing.T) {
 	t.Parallel()
 
@@ -157,16 +157,54 @@ func BuildTransfer(config *conf.TransferConfig) trans.Transfer {
 		opts := parseHTTPTransferOptions(config)
 
 		return trans.NewLarkTransfer(config.Name, config.URL, config.Prefix, opts)
+	case trans.TypeSlack:
+		opts := parseHTTPTransferOptions(config)
+
+		return trans.NewSlackTransfer(config.Name, config.URL, config.Prefix, opts)
+	case trans.TypeWeCom:
+		opts := parseHTTPTransferOptions(config)
+
+		return trans.NewWeComTransfer(config.Name, config.URL, config.Prefix, opts)
+	case trans.TypeTelegram:
+		opts := parseHTTPTransferOptions(config)
+
+		return trans.NewTelegramTransfer(config.Name, config.URL, config.Prefix, opts)
+	case trans.TypeEmail:
+		return trans.NewEmailTransfer(config.Name, config.Prefix, parseEmailTransferOptions(config))
+	case trans.TypeKafka:
+		return trans.NewKafkaTransfer(config.Name, config.Prefix, parseKafkaTransferOptions(config))
+	case trans.TypeElasticsearch:
+		return trans.NewElasticsearchTransfer(config.Name, config.URL, trans.ElasticsearchTransferOptions{
+			HTTPTransferOptions: parseHTTPTransferOptions(config),
+			Index:               config.Index,
+			Username:            config.AuthUsername,
+			Password:            config.AuthPassword,
+			APIKey:              config.APIKey,
+		})
+	case trans.TypeLoki:
+		return trans.NewLokiTransfer(config.Name, config.URL, trans.LokiTransferOptions{
+			HTTPTransferOptions: parseHTTPTransferOptions(config),
+			Labels:              config.Labels,
+			Encoding:            config.Encoding,
+			TenantID:            config.TenantID,
+			Username:            config.AuthUsername,
+			Password:            config.AuthPassword,
+		})
+	case trans.TypeOTLP:
+		return trans.NewOTLPTransfer(config.Name, config.URL, trans.OTLPTransferOptions{
+			HTTPTransferOptions: parseHTTPTransferOptions(config),
+			Encoding:            config.Encoding,
+		})
 	case trans.TypeFile:
-		return trans.NewFileTransfer(config.Name, config.Dir)
+		return trans.NewBytesAdapter(trans.NewFileTransfer(config.Name, config.Dir))
 	case trans.TypeConsole:
-		return &trans.ConsoleTransfer{
+		return trans.NewBytesAdapter(&trans.ConsoleTransfer{
 			ID: config.Name,
-		}
+		})
 	default:
-		return &trans.NullTransfer{
+		return trans.NewBytesAdapter(&trans.NullTransfer{
 			ID: config.Name,
-		}
+		})
 	}
 }
 
@@ -176,6 +214,17 @@ func parseHTTPTransferOptions(config *conf.TransferConfig) trans.HTTPTransferOpt
 		RateLimit:           config.RateLimit,
 		RateBurst:           config.RateBurst,
 		BatchSize:           config.BatchSize,
+		DisableDropInterval: config.DisableDropInterval,
+		Envelope:            config.Envelope,
+		Template:            config.Template,
+		Channel:             config.Channel,
+		Username:            config.Username,
+		MsgType:             config.MsgType,
+		MentionedList:       config.MentionedList,
+		MentionedMobileList: config.MentionedMobileList,
+		BotToken:            config.BotToken,
+		ChatID:              config.ChatID,
+		ParseMode:           config.ParseMode,
 	}
 
 	if config.IdleConnTimeout != "" {
@@ -196,3 +245,53 @@ func parseHTTPTransferOptions(config *conf.TransferConfig) trans.HTTPTransferOpt
 
 	return opts
 }
+
+func parseEmailTransferOptions(config *conf.TransferConfig) trans.EmailTransferOptions {
+	opts := trans.EmailTransferOptions{
+		Host:             config.Host,
+		Port:             config.Port,
+		TLSMode:          config.TLSMode,
+		TLSSkipVerify:    config.TLSSkipVerify,
+		Username:         config.AuthUsername,
+		Password:         config.AuthPassword,
+		From:             config.From,
+		To:               config.To,
+		Subject:          config.Subject,
+		Template:         config.Template,
+		DigestMaxRecords: config.BatchSize,
+		RateLimit:        config.RateLimit,
+		RateBurst:        config.RateBurst,
+	}
+
+	if config.DigestInterval != "" {
+		if d, err := time.ParseDuration(config.DigestInterval); err == nil {
+			opts.DigestInterval = d
+		} else {
+			vlog.Warnf("invalid digest_interval %q for transfer %s: %v", config.DigestInterval, config.Name, err)
+		}
+	}
+
+	return opts
+}
+
+func parseKafkaTransferOptions(config *conf.TransferConfig) trans.KafkaTransferOptions {
+	opts := trans.KafkaTransferOptions{
+		Brokers:            config.Brokers,
+		Topic:              config.Topic,
+		Key:                config.Key,
+		Acks:               config.Acks,
+		Compression:        config.Compression,
+		Envelope:           config.Envelope,
+		MaxBufferedRecords: config.MaxBufferedRecords,
+	}
+
+	if config.BatchTimeout != "" {
+		if d, err := time.ParseDuration(config.BatchTimeout); err == nil {
+			opts.Linger = d
+		} else {
+			vlog.Warnf("invalid batch_timeout %q for transfer %s: %v", config.BatchTimeout, config.Name, err)
+		}
+	}
+
+	return opts
+}
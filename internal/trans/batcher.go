@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/vogo/vlog"
 )
 
@@ -30,6 +31,7 @@ const defaultBatchTimeout = time.Second
 type batchEntry struct {
 	source string
 	data   []byte
+	rec    *record.Record // nil for entries added by Add
 }
 
 // Batcher buffers log lines and flushes them by count threshold or time window.
@@ -40,6 +42,7 @@ type Batcher struct {
 	batchTimeout time.Duration
 	timer        *time.Timer // nil when no timer is active
 	flushFunc    func(source string, data []byte) error
+	recordsFunc  func(records []*record.Record) error // flushes the records instead of the joined lines if not nil
 	stopped      bool
 }
 
@@ -57,8 +60,29 @@ func NewBatcher(batchSize int, batchTimeout time.Duration, flushFunc func(string
 	}
 }
 
+// NewRecordBatcher creates a new Batcher flushing the buffered records together, which are added by AddRecord.
+func NewRecordBatcher(batchSize int, batchTimeout time.Duration, flushFunc func([]*record.Record) error) *Batcher {
+	b := NewBatcher(batchSize, batchTimeout, nil)
+	b.recordsFunc = flushFunc
+
+	return b
+}
+
 // Add appends data to the batch buffer. It flushes when the batch size threshold is reached.
 func (b *Batcher) Add(source string, data []byte) {
+	// Copy data since the caller's buffer may be reused.
+	dataCopy := make([]byte, len(data))
+	copy(dataCopy, data)
+
+	b.add(batchEntry{source: source, data: dataCopy})
+}
+
+// AddRecord appends the record to the batch buffer. It flushes when the batch size threshold is reached.
+func (b *Batcher) AddRecord(rec *record.Record) {
+	b.add(batchEntry{source: rec.Source, data: rec.Data, rec: rec})
+}
+
+func (b *Batcher) add(entry batchEntry) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -66,11 +90,7 @@ func (b *Batcher) Add(source string, data []byte) {
 		return
 	}
 
-	// Copy data since the caller's buffer may be reused.
-	dataCopy := make([]byte, len(data))
-	copy(dataCopy, data)
-
-	b.buffer = append(b.buffer, batchEntry{source: source, data: dataCopy})
+	b.buffer = append(b.buffer, entry)
 
 	if len(b.buffer) >= b.batchSize {
 		b.flush()
@@ -95,16 +115,7 @@ func (b *Batcher) flush() {
 		return
 	}
 
-	source := b.buffer[0].source
-
-	parts := make([][]byte, len(b.buffer))
-	for i, entry := range b.buffer {
-		parts[i] = entry.data
-	}
-
-	payload := bytes.Join(parts, []byte("\n"))
-
-	if err := b.flushFunc(source, payload); err != nil {
+	if err := b.flushBuffer(); err != nil {
 		vlog.Warnf("batcher flush error: %v", err)
 	}
 
@@ -116,6 +127,30 @@ func (b *Batcher) flush() {
 	}
 }
 
+// flushBuffer passes the buffered records, or the buffered lines joined by new lines, to the flush function.
+func (b *Batcher) flushBuffer() error {
+	if b.recordsFunc != nil {
+		records := make([]*record.Record, 0, len(b.buffer))
+
+		for _, entry := range b.buffer {
+			if entry.rec != nil {
+				records = append(records, entry.rec)
+			} else {
+				records = append(records, record.New(entry.source, "", entry.data))
+			}
+		}
+
+		return b.recordsFunc(records)
+	}
+
+	parts := make([][]byte, len(b.buffer))
+	for i, entry := range b.buffer {
+		parts[i] = entry.data
+	}
+
+	return b.flushFunc(b.buffer[0].source, bytes.Join(parts, []byte("\n")))
+}
+
 // Stop flushes remaining data and marks the batcher as stopped.
 func (b *Batcher) Stop() {
 	b.mu.Lock()
@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 )
 
 func TestBatcherFlushOnThreshold(t *testing.T) {
@@ -270,3 +271,31 @@ func TestBatcherDataCopy(t *testing.T) {
 	// The batcher should have the original data, not the modified one
 	assert.Equal(t, "original\nsecond", flushed)
 }
+
+func TestRecordBatcher(t *testing.T) {
+	t.Parallel()
+
+	var flushes [][]*record.Record
+
+	b := NewRecordBatcher(3, 10*time.Second, func(records []*record.Record) error {
+		flushes = append(flushes, records)
+
+		return nil
+	})
+
+	first := record.New("src", "w1", []byte("line1"))
+	first.Level = "error"
+
+	b.AddRecord(first)
+	b.Add("src", []byte("line2"))
+	b.AddRecord(record.New("src", "", []byte("line3")))
+	b.AddRecord(record.New("src", "", []byte("line4")))
+	b.Stop()
+
+	require.Len(t, flushes, 2)
+	require.Len(t, flushes[0], 3)
+	assert.Same(t, first, flushes[0][0])
+	assert.Equal(t, "line2", string(flushes[0][1].Data))
+	assert.Equal(t, "src", flushes[0][1].Source)
+	assert.Equal(t, "line4", string(flushes[1][0].Data))
+}
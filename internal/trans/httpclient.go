@@ -30,6 +30,9 @@ import (
 const (
 	defaultMaxIdleConnsPerHost = 2
 	defaultIdleConnTimeout     = 90 * time.Second
+
+	contentTypeJSON = "application/json"
+	contentTypeText = "text/plain; charset=utf-8"
 )
 
 // HTTPClientConfig holds HTTP transport configuration.
@@ -47,6 +50,17 @@ type HTTPTransferOptions struct {
 	RateBurst           int           // burst size; defaults to 1
 	BatchSize           int           // lines per batch; 0 or 1 = disabled
 	BatchTimeout        time.Duration // max wait before flush; defaults to 1s
+	DisableDropInterval bool          // ding/lark: transfer every message instead of dropping messages for 5s after one
+	Envelope            bool          // webhook: post the records in a JSON envelope
+	Template            string        // text/template of the message of ding/lark/webhook/slack/wecom/telegram
+	Channel             string        // slack: channel overriding the default of the webhook
+	Username            string        // slack: username overriding the default of the webhook
+	MsgType             string        // wecom: message type, text or markdown; defaults to text
+	MentionedList       []string      // wecom: user ids to mention, `@all` for everyone
+	MentionedMobileList []string      // wecom: mobiles of the users to mention, text messages only
+	BotToken            string        // telegram: token of the bot
+	ChatID              string        // telegram: id of the chat, or @username of the channel
+	ParseMode           string        // telegram: parse mode of messages, MarkdownV2 or HTML; defaults to plain text
 }
 
 // NewHTTPClient creates an *http.Client with a configured transport.
@@ -71,12 +85,24 @@ func NewHTTPClient(cfg HTTPClientConfig) *http.Client {
 	}
 }
 
-// httpTransWithClient performs an HTTP POST using the provided client.
-// It fully drains and closes the response body to ensure connection reuse.
+// httpTransWithClient performs an HTTP POST of JSON data using the provided client.
 func httpTransWithClient(client *http.Client, url string, data ...[]byte) error {
-	res, err := client.Post(url, "application/json", vio.NewBytesReader(data...))
+	return httpPostWithClient(client, url, contentTypeJSON, data...)
+}
+
+// httpPostWithClient performs an HTTP POST using the provided client.
+func httpPostWithClient(client *http.Client, url, contentType string, data ...[]byte) error {
+	_, err := httpPostForBody(client, url, contentType, data...)
+
+	return err
+}
+
+// httpPostForBody performs an HTTP POST using the provided client, and returns the body of the OK response.
+// It fully reads and closes the response body to ensure connection reuse.
+func httpPostForBody(client *http.Client, url, contentType string, data ...[]byte) ([]byte, error) {
+	res, err := client.Post(url, contentType, vio.NewBytesReader(data...))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer func() { _ = res.Body.Close() }()
@@ -86,13 +112,10 @@ func httpTransWithClient(client *http.Client, url string, data ...[]byte) error
 			vlog.Warnf("http alert error! response: %s, request data length: %d", respBody, len(data))
 		}
 
-		return fmt.Errorf("http alert error, %w: %d", ErrHTTPStatusNonOK, res.StatusCode)
+		return nil, fmt.Errorf("http alert error, %w: %d", ErrHTTPStatusNonOK, res.StatusCode)
 	}
 
-	// Drain response body to enable connection reuse.
-	_, _ = io.Copy(io.Discard, res.Body)
-
-	return nil
+	return io.ReadAll(res.Body)
 }
 
 // closeHTTPClient closes idle connections on the client's transport.
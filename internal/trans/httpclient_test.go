@@ -28,6 +28,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 )
 
@@ -80,7 +81,7 @@ func TestHTTPClientConnectionReuse(t *testing.T) {
 	defer func() { _ = wh.Stop() }()
 
 	for range 10 {
-		err := wh.Trans("src", []byte("hello"))
+		err := wh.Trans(record.New("src", "", []byte("hello")))
 		require.NoError(t, err)
 	}
 
@@ -102,7 +103,7 @@ func TestWebhookTransferPostSuccess(t *testing.T) {
 	wh := trans.NewWebhookTransfer("test-wh", server.URL, "", trans.HTTPTransferOptions{})
 	defer func() { _ = wh.Stop() }()
 
-	err := wh.Trans("source1", []byte("test-data"))
+	err := wh.Trans(record.New("source1", "", []byte("test-data")))
 	require.NoError(t, err)
 	assert.Equal(t, "test-data", string(received))
 }
@@ -119,7 +120,7 @@ func TestWebhookTransferPostError(t *testing.T) {
 	wh := trans.NewWebhookTransfer("test-err", server.URL, "", trans.HTTPTransferOptions{})
 	defer func() { _ = wh.Stop() }()
 
-	err := wh.Trans("source1", []byte("test-data"))
+	err := wh.Trans(record.New("source1", "", []byte("test-data")))
 	assert.Error(t, err)
 }
 
@@ -144,14 +145,14 @@ func TestClientIsolation(t *testing.T) {
 	wh2 := trans.NewWebhookTransfer("wh2", server2.URL, "", trans.HTTPTransferOptions{})
 
 	// Send to both
-	require.NoError(t, wh1.Trans("s", []byte("a")))
-	require.NoError(t, wh2.Trans("s", []byte("b")))
+	require.NoError(t, wh1.Trans(record.New("s", "", []byte("a"))))
+	require.NoError(t, wh2.Trans(record.New("s", "", []byte("b"))))
 
 	// Stop wh1
 	_ = wh1.Stop()
 
 	// wh2 should still work
-	require.NoError(t, wh2.Trans("s", []byte("c")))
+	require.NoError(t, wh2.Trans(record.New("s", "", []byte("c"))))
 
 	_ = wh2.Stop()
 
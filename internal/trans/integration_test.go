@@ -30,6 +30,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 )
 
@@ -61,7 +62,7 @@ func TestIntegrationHTTPConnectionReuse(t *testing.T) {
 
 	// Send 10 messages sequentially.
 	for i := range 10 {
-		err := wh.Trans("src", fmt.Appendf(nil, "message-%d", i))
+		err := wh.Trans(record.New("src", "", fmt.Appendf(nil, "message-%d", i)))
 		require.NoError(t, err, "Trans call %d should not error", i)
 	}
 
@@ -94,7 +95,7 @@ func TestIntegrationDingRateLimiterDropsMessages(t *testing.T) {
 
 	// Call Trans() 5 times in rapid succession.
 	for i := range 5 {
-		_ = dt.Trans("src", fmt.Appendf(nil, "msg-%d", i))
+		_ = dt.Trans(record.New("src", "", fmt.Appendf(nil, "msg-%d", i)))
 	}
 
 	// Allow goroutines to complete.
@@ -139,9 +140,9 @@ func TestIntegrationBatcherThresholdFlush(t *testing.T) {
 	defer func() { _ = wh.Stop() }()
 
 	// Send exactly 3 messages to trigger threshold flush.
-	require.NoError(t, wh.Trans("src", []byte("line-1")))
-	require.NoError(t, wh.Trans("src", []byte("line-2")))
-	require.NoError(t, wh.Trans("src", []byte("line-3")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-2"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-3"))))
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -181,8 +182,8 @@ func TestIntegrationBatcherTimeoutFlush(t *testing.T) {
 	defer func() { _ = wh.Stop() }()
 
 	// Send 2 messages (below threshold of 100).
-	require.NoError(t, wh.Trans("src", []byte("line-1")))
-	require.NoError(t, wh.Trans("src", []byte("line-2")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-2"))))
 
 	// Wait for timeout to trigger flush.
 	time.Sleep(200 * time.Millisecond)
@@ -224,8 +225,8 @@ func TestIntegrationBatcherFlushOnStop(t *testing.T) {
 	})
 
 	// Send 2 messages (below threshold, long timeout).
-	require.NoError(t, wh.Trans("src", []byte("line-1")))
-	require.NoError(t, wh.Trans("src", []byte("line-2")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("line-2"))))
 
 	// Stop flushes remaining data.
 	_ = wh.Stop()
@@ -267,7 +268,7 @@ func TestIntegrationBackwardCompatibility(t *testing.T) {
 
 	// Send 5 messages.
 	for i := range 5 {
-		err := wh.Trans("src", fmt.Appendf(nil, "msg-%d", i))
+		err := wh.Trans(record.New("src", "", fmt.Appendf(nil, "msg-%d", i)))
 		require.NoError(t, err)
 	}
 
@@ -309,15 +310,15 @@ func TestIntegrationClientIsolation(t *testing.T) {
 	wh2 := trans.NewWebhookTransfer("int-iso-2", server2.URL, "", trans.HTTPTransferOptions{})
 
 	// Send to both.
-	require.NoError(t, wh1.Trans("s", []byte("a")))
-	require.NoError(t, wh2.Trans("s", []byte("b")))
+	require.NoError(t, wh1.Trans(record.New("s", "", []byte("a"))))
+	require.NoError(t, wh2.Trans(record.New("s", "", []byte("b"))))
 
 	// Stop wh1.
 	_ = wh1.Stop()
 
 	// wh2 should still work after wh1 is stopped.
-	require.NoError(t, wh2.Trans("s", []byte("c")))
-	require.NoError(t, wh2.Trans("s", []byte("d")))
+	require.NoError(t, wh2.Trans(record.New("s", "", []byte("c"))))
+	require.NoError(t, wh2.Trans(record.New("s", "", []byte("d"))))
 
 	_ = wh2.Stop()
 
@@ -351,7 +352,7 @@ func TestIntegrationLarkRateLimiting(t *testing.T) {
 
 	// Call Trans() 10 times rapidly.
 	for i := range 10 {
-		_ = lt.Trans("src", fmt.Appendf(nil, "lark-msg-%d", i))
+		_ = lt.Trans(record.New("src", "", fmt.Appendf(nil, "lark-msg-%d", i)))
 	}
 
 	// Allow goroutines to complete.
@@ -398,11 +399,11 @@ func TestIntegrationBatcherNoDuplicateTimer(t *testing.T) {
 	defer func() { _ = wh.Stop() }()
 
 	// Add one item, wait for timer flush.
-	require.NoError(t, wh.Trans("src", []byte("first")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("first"))))
 	time.Sleep(80 * time.Millisecond)
 
 	// Add another item, wait for timer flush.
-	require.NoError(t, wh.Trans("src", []byte("second")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("second"))))
 	time.Sleep(80 * time.Millisecond)
 
 	mu.Lock()
@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// consumeFields consumes the fields of a protobuf message, varint and fixed64 fields are passed as the varint.
+func consumeFields(data []byte, fn func(num protowire.Number, value []byte, varint uint64)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			value, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+
+			fn(num, value, 0)
+
+			data = data[m:]
+		case protowire.Fixed64Type:
+			fixed, m := protowire.ConsumeFixed64(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+
+			fn(num, nil, fixed)
+
+			data = data[m:]
+		default:
+			varint, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+
+			fn(num, nil, varint)
+
+			data = data[m:]
+		}
+	}
+
+	return nil
+}
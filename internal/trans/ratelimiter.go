@@ -23,7 +23,12 @@ import (
 	"time"
 )
 
-const tokenScale int64 = 1000
+const (
+	tokenScale int64 = 1000
+
+	// rateLimiterWaitInterval the interval to check tokens when waiting.
+	rateLimiterWaitInterval = 100 * time.Millisecond
+)
 
 // rateLimiter is a simple token-bucket rate limiter using atomic operations.
 type rateLimiter struct {
@@ -106,6 +111,24 @@ func (r *rateLimiter) Allow() bool {
 	}
 }
 
+// Wait blocks until a token is available, returns false if done is closed before.
+func (r *rateLimiter) Wait(done <-chan struct{}) bool {
+	for !r.Allow() {
+		select {
+		case <-done:
+			return false
+		case <-time.After(rateLimiterWaitInterval):
+		}
+	}
+
+	return true
+}
+
+// Drain takes all the tokens, e.g. when the receiver reports its rate limit is exceeded.
+func (r *rateLimiter) Drain() {
+	r.tokens.Store(0)
+}
+
 // Stop stops the refill ticker. Safe to call multiple times.
 func (r *rateLimiter) Stop() {
 	r.stopOnce.Do(func() {
@@ -117,3 +117,25 @@ func TestRateLimiterDoubleStop(t *testing.T) {
 	rl.Stop()
 	rl.Stop()
 }
+
+func TestRateLimiterWaitAndDrain(t *testing.T) {
+	t.Parallel()
+
+	rl := newRateLimiter(10, 2)
+	defer rl.Stop()
+
+	rl.Drain()
+	assert.False(t, rl.Allow())
+
+	// Wait blocks until refilled (rate=10/s, tick every 100ms)
+	start := time.Now()
+	assert.True(t, rl.Wait(make(chan struct{})))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Wait returns false when done
+	rl.Drain()
+
+	done := make(chan struct{})
+	close(done)
+	assert.False(t, rl.Wait(done))
+}
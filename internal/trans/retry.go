@@ -0,0 +1,216 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+)
+
+const (
+	// retryMaxWait the max wait before a retry, even if the server tells a longer Retry-After.
+	retryMaxWait = 10 * time.Second
+
+	// retryMaxPending the max failed requests waiting to retry, the oldest ones are dropped if exceeded.
+	retryMaxPending = 16
+)
+
+// retryFunc retries a failed request, and returns the records remaining to retry, 0 if sent or not retryable,
+// and the wait told by the server, 0 if not told.
+type retryFunc func() (int, time.Duration)
+
+// retryTask a failed request waiting to retry.
+type retryTask struct {
+	count   int // the records remaining to retry
+	retries int
+	wait    time.Duration
+	retry   retryFunc
+}
+
+// retryQueue retries the failed requests of the batched transfers in the background,
+// so that the flush of a batcher, which blocks the routers adding records, never waits for the retries.
+// A request is retried at most maxRetries times, after the wait told by the server,
+// or the interval doubled for each retry.
+type retryQueue struct {
+	name       string // the name in logs, e.g. `loki transfer app`
+	maxRetries int
+	interval   time.Duration
+
+	mu      sync.Mutex
+	pending []*retryTask
+	dropped int // the count of the records dropped for the queue full since the last retry
+
+	notify   chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func newRetryQueue(name string, maxRetries int, interval time.Duration) *retryQueue {
+	q := &retryQueue{
+		name:       name,
+		maxRetries: maxRetries,
+		interval:   interval,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		exited:     make(chan struct{}),
+	}
+
+	go q.loop()
+
+	return q
+}
+
+// add queues the failed request of the records, retried after the wait told by the server, 0 if not told.
+func (q *retryQueue) add(count int, retryAfter time.Duration, retry retryFunc) {
+	task := &retryTask{count: count, wait: q.backoff(0, retryAfter), retry: retry}
+
+	q.mu.Lock()
+
+	select {
+	case <-q.done:
+		q.mu.Unlock()
+		vlog.Errorf("%s: %d records dropped for stopping", q.name, count)
+
+		return
+	default:
+	}
+
+	vlog.Warnf("%s: %d records failed, retry after %v", q.name, count, task.wait)
+
+	q.pending = append(q.pending, task)
+
+	if exceeded := len(q.pending) - retryMaxPending; exceeded > 0 {
+		for _, dropped := range q.pending[:exceeded] {
+			q.dropped += dropped.count
+		}
+
+		q.pending = q.pending[exceeded:]
+	}
+
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// stop stops retrying, the pending requests are dropped.
+func (q *retryQueue) stop() {
+	q.stopOnce.Do(func() {
+		q.mu.Lock()
+		close(q.done)
+		q.mu.Unlock()
+
+		<-q.exited
+	})
+}
+
+func (q *retryQueue) loop() {
+	defer close(q.exited)
+
+	for {
+		task := q.next()
+		if task == nil {
+			select {
+			case <-q.done:
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+
+		if !q.run(task) {
+			q.dropPending()
+
+			return
+		}
+	}
+}
+
+// run retries the request until it is sent, not retryable or retried maxRetries times,
+// returns false if stopped while waiting.
+func (q *retryQueue) run(task *retryTask) bool {
+	for {
+		select {
+		case <-q.done:
+			vlog.Errorf("%s: %d records dropped for stopping", q.name, task.count)
+
+			return false
+		case <-time.After(task.wait):
+		}
+
+		remaining, retryAfter := task.retry()
+		if remaining == 0 {
+			return true
+		}
+
+		task.retries++
+		task.count = remaining
+
+		if task.retries >= q.maxRetries {
+			vlog.Errorf("%s: %d records dropped after %d retries", q.name, task.count, task.retries)
+
+			return true
+		}
+
+		task.wait = q.backoff(task.retries, retryAfter)
+
+		vlog.Warnf("%s: %d records failed, retry after %v", q.name, task.count, task.wait)
+	}
+}
+
+// backoff the wait before the retry, the wait told by the server at most retryMaxWait,
+// or the interval doubled for each retry.
+func (q *retryQueue) backoff(retries int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, retryMaxWait)
+	}
+
+	return min(q.interval<<retries, retryMaxWait)
+}
+
+// next takes the next pending request.
+func (q *retryQueue) next() *retryTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.dropped > 0 {
+		vlog.Errorf("%s: %d records dropped for the retry queue full", q.name, q.dropped)
+		q.dropped = 0
+	}
+
+	if len(q.pending) == 0 {
+		return nil
+	}
+
+	task := q.pending[0]
+	q.pending = q.pending[1:]
+
+	return task
+}
+
+// dropPending drops the pending requests for stopping.
+func (q *retryQueue) dropPending() {
+	for task := q.next(); task != nil; task = q.next() {
+		vlog.Errorf("%s: %d records dropped for stopping", q.name, task.count)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryQueueBackoff(t *testing.T) {
+	t.Parallel()
+
+	q := newRetryQueue("test", 3, 500*time.Millisecond)
+	defer q.stop()
+
+	assert.Equal(t, 500*time.Millisecond, q.backoff(0, 0))
+	assert.Equal(t, 2*time.Second, q.backoff(2, 0))
+	assert.Equal(t, 3*time.Second, q.backoff(1, 3*time.Second))
+
+	// the wait told by the server is capped.
+	assert.Equal(t, retryMaxWait, q.backoff(0, time.Hour))
+}
+
+func TestRetryQueueRetries(t *testing.T) {
+	t.Parallel()
+
+	q := newRetryQueue("test", 3, time.Millisecond)
+
+	var sent, failed atomic.Int32
+
+	// sent at the second retry.
+	q.add(2, 0, func() (int, time.Duration) {
+		if sent.Add(1) < 2 {
+			return 1, 0
+		}
+
+		return 0, 0
+	})
+
+	// dropped after the max retries.
+	q.add(1, 0, func() (int, time.Duration) {
+		failed.Add(1)
+
+		return 1, 0
+	})
+
+	assert.Eventually(t, func() bool {
+		return sent.Load() == 2 && failed.Load() == 3
+	}, time.Second, 5*time.Millisecond)
+
+	q.stop()
+
+	// the requests failed after stopping are dropped.
+	q.add(1, 0, func() (int, time.Duration) {
+		failed.Add(1)
+
+		return 0, 0
+	})
+
+	assert.Equal(t, int32(3), failed.Load())
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
+)
+
+// hostname the host name of the machine, empty if unknown.
+//
+//nolint:gochecknoglobals // ignore this
+var hostname = sync.OnceValue(func() string {
+	name, _ := os.Hostname()
+
+	return name
+})
+
+// templateFuncs the functions of message templates,
+// `json` encodes a value to JSON, e.g. `{"text":{{json .Text}}}`,
+// `markdownV2` escapes the special characters of Telegram MarkdownV2, e.g. `*{{markdownV2 .Source}}*`.
+//
+//nolint:gochecknoglobals // ignore this
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+
+		return string(data), err
+	},
+	"markdownV2": EscapeTelegramMarkdown,
+}
+
+// MessageData the data of message templates, the metadata is of the first record of the message.
+type MessageData struct {
+	// Source the server name of the records.
+	Source string
+
+	// Router the name of the router transferring the records.
+	Router string
+
+	// Hostname the host name of the machine running logtail.
+	Hostname string
+
+	// Prefix the prefix of the transfer.
+	Prefix string
+
+	// Timestamp the event time of the first record.
+	Timestamp time.Time
+
+	// Level the level of the first record, empty if unknown.
+	Level string
+
+	// Fields the extracted fields of the first record.
+	Fields map[string]string
+
+	// Text the data of the records joined by new lines.
+	Text string
+
+	// Count the count of the records.
+	Count int
+
+	// Records the records of the message.
+	Records []*record.Record
+}
+
+// NewMessageData new message data of the records, which must not be empty.
+func NewMessageData(prefix string, records []*record.Record) *MessageData {
+	first := records[0]
+
+	return &MessageData{
+		Source:    first.Source,
+		Router:    first.Router,
+		Hostname:  hostname(),
+		Prefix:    prefix,
+		Timestamp: first.EventTime(),
+		Level:     first.Level,
+		Fields:    first.Fields,
+		Text:      string(bytes.Join(record.Datas(records), []byte("\n"))),
+		Count:     len(records),
+		Records:   records,
+	}
+}
+
+// ParseTemplate parses the message template, a Go text/template executed with MessageData.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("message").Funcs(templateFuncs).Parse(text)
+}
+
+// MessageTemplate renders the message of records.
+type MessageTemplate struct {
+	prefix   string
+	template *template.Template
+}
+
+// NewMessageTemplate new message template, it panics if the template is invalid, which should be checked before.
+func NewMessageTemplate(text, prefix string) *MessageTemplate {
+	tpl, err := ParseTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+
+	return &MessageTemplate{prefix: prefix, template: tpl}
+}
+
+// Render renders the message of the records, which must not be empty.
+func (t *MessageTemplate) Render(records []*record.Record) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := t.template.Execute(&buf, NewMessageData(t.prefix, records)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	t.Parallel()
+
+	first := record.New("app", "", []byte("ERROR pay failed"))
+	first.Router = "pay-alert"
+	first.Level = "error"
+	first.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	first.Fields = map[string]string{"order": "1024"}
+
+	tpl := trans.NewMessageTemplate(
+		`[{{.Prefix}}{{.Source}}/{{.Router}}] {{.Count}} {{.Level}} at {{.Timestamp.Format "15:04:05"}} `+
+			`order={{index .Fields "order"}} host={{if .Hostname}}ok{{end}}{{range .Records}} <{{printf "%s" .Data}}>{{end}}`,
+		"p-")
+
+	message, err := tpl.Render([]*record.Record{first, record.New("app", "", []byte("ERROR retry failed"))})
+	require.NoError(t, err)
+	assert.Equal(t, "[p-app/pay-alert] 2 error at 10:30:45 order=1024 host=ok <ERROR pay failed> <ERROR retry failed>",
+		string(message))
+
+	message, err = trans.NewMessageTemplate(`{"text":{{json .Text}}}`, "").Render([]*record.Record{
+		record.New("app", "", []byte("line1\n\"line2\"")),
+		record.New("app", "", []byte("line3")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"line1\n\"line2\"\nline3"}`, string(message))
+
+	_, err = trans.ParseTemplate("{{.Source")
+	require.Error(t, err)
+
+	_, err = trans.NewMessageTemplate("{{.Unknown}}", "").Render([]*record.Record{first})
+	require.Error(t, err)
+}
@@ -17,17 +17,66 @@
 
 package trans
 
+import "github.com/vogo/logtail/internal/record"
+
+// Transfer transfers records.
 type Transfer interface {
+	Name() string
+	Trans(records ...*record.Record) error
+	Start() error
+	Stop() error
+}
+
+// BytesTransfer transfers the raw bytes of records from a source, without the other metadata.
+type BytesTransfer interface {
 	Name() string
 	Trans(source string, data ...[]byte) error
 	Start() error
 	Stop() error
 }
 
+// BytesAdapter adapts a BytesTransfer to a Transfer.
+type BytesAdapter struct {
+	BytesTransfer
+}
+
+// NewBytesAdapter new adapter of the bytes transfer.
+func NewBytesAdapter(transfer BytesTransfer) *BytesAdapter {
+	return &BytesAdapter{BytesTransfer: transfer}
+}
+
+// Trans transfers the data of the records, the successive records of a source are transferred together.
+func (a *BytesAdapter) Trans(records ...*record.Record) error {
+	return eachSource(records, func(source string, group []*record.Record) error {
+		return a.BytesTransfer.Trans(source, record.Datas(group)...)
+	})
+}
+
+// eachSource calls the function with each group of the successive records of a source.
+func eachSource(records []*record.Record, f func(source string, group []*record.Record) error) error {
+	for start := 0; start < len(records); {
+		end := start + 1
+		for end < len(records) && records[end].Source == records[start].Source {
+			end++
+		}
+
+		if err := f(records[start].Source, records[start:end]); err != nil {
+			return err
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
 // Types all transfer types.
 //
 //nolint:gochecknoglobals //ignore this.
-var Types = []string{TypeNull, TypeConsole, TypeFile, TypeWebhook, TypeDing, TypeLark}
+var Types = []string{
+	TypeNull, TypeConsole, TypeFile, TypeWebhook, TypeDing, TypeLark, TypeSlack, TypeWeCom, TypeTelegram, TypeEmail,
+	TypeKafka, TypeElasticsearch, TypeLoki, TypeOTLP,
+}
 
 const DefaultTransferPrefix = "logtail-"
 
@@ -19,26 +19,31 @@ package trans
 
 import (
 	"net/http"
+	"slices"
 	"sync/atomic"
 	"time"
 
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/vogo/vlog"
 )
 
 const TypeDing = "ding"
 
 const (
-	dingMessageDataFixedBytesNum = 5
+	dingMessageDataFixedBytesNum = 3
 	dingMessageDataMaxLength     = 1024
 )
 
 var (
 	//nolint:gochecknoglobals // ignore this
-	dingTextMessageDataPrefix = []byte(`{"msgtype":"text","text":{"content":"[logtail-`)
+	dingTextMessageDataPrefix = []byte(`{"msgtype":"text","text":{"content":"`)
 
 	//nolint:gochecknoglobals // ignore this
 	dingTextMessageDataSuffix = []byte(`"}}`)
 
+	//nolint:gochecknoglobals // ignore this
+	dingMessageTitlePrefix = []byte("[logtail-")
+
 	//nolint:gochecknoglobals // ignore this
 	messageTitleContentSplit = []byte("]: ")
 )
@@ -53,7 +58,9 @@ type DingTransfer struct {
 	prefix       []byte
 	transferring int32 // whether transferring message
 	client       *http.Client
-	limiter      *rateLimiter // nil when rate limiting disabled
+	limiter      *rateLimiter     // nil when rate limiting disabled
+	noDrop       bool             // transfer every message without dropping messages in the interval
+	template     *MessageTemplate // nil for the default message `[logtail-<prefix><source>]: <content>`
 }
 
 func (d *DingTransfer) Name() string {
@@ -72,10 +79,22 @@ func (d *DingTransfer) Stop() error {
 	return nil
 }
 
-// Trans transfer data to dingding.
-func (d *DingTransfer) Trans(source string, data ...[]byte) error {
+// Trans transfer records to dingding, the successive records of a source are transferred in a message.
+func (d *DingTransfer) Trans(records ...*record.Record) error {
+	return eachSource(records, d.transSource)
+}
+
+func (d *DingTransfer) transSource(source string, records []*record.Record) error {
 	d.CountIncr()
 
+	if d.noDrop {
+		if countMessage, ok := d.CountStat(); ok {
+			_ = d.execTrans(d.title(source), []byte(countMessage))
+		}
+
+		return d.transRecords(source, records)
+	}
+
 	if !atomic.CompareAndSwapInt32(&d.transferring, 0, 1) {
 		// ignore message when transferring
 		return nil
@@ -85,7 +104,7 @@ func (d *DingTransfer) Trans(source string, data ...[]byte) error {
 		<-time.After(dingMessageTransferInterval)
 
 		if countMessage, ok := d.CountStat(); ok {
-			_ = d.execTrans(source, []byte(countMessage))
+			_ = d.execTrans(d.title(source), []byte(countMessage))
 
 			<-time.After(dingMessageTransferInterval)
 		}
@@ -93,25 +112,42 @@ func (d *DingTransfer) Trans(source string, data ...[]byte) error {
 		atomic.StoreInt32(&d.transferring, 0)
 	}()
 
-	return d.execTrans(source, data...)
+	return d.transRecords(source, records)
+}
+
+// transRecords transfers the records in the message rendered by the template, or the default message.
+//
+//nolint:dupl // ignore duplicated code for easy maintenance for diff transfers.
+func (d *DingTransfer) transRecords(source string, records []*record.Record) error {
+	if d.template == nil {
+		return d.execTrans(d.title(source), record.Datas(records)...)
+	}
+
+	content, err := d.template.Render(records)
+	if err != nil {
+		vlog.Errorf("ding transfer %s: template error: %v", d.id, err)
+
+		return nil
+	}
+
+	return d.execTrans(nil, content)
+}
+
+func (d *DingTransfer) title(source string) []byte {
+	return slices.Concat(dingMessageTitlePrefix, d.prefix, []byte(source), messageTitleContentSplit)
 }
 
 //nolint:dupl // ignore duplicated code for easy maintenance for diff transfers.
-func (d *DingTransfer) execTrans(source string, data ...[]byte) error {
+func (d *DingTransfer) execTrans(title []byte, data ...[]byte) error {
 	if d.limiter != nil && !d.limiter.Allow() {
 		vlog.Warnf("ding transfer %s: rate limit exceeded, dropping message", d.id)
 
 		return nil
 	}
 
-	size := dingMessageDataFixedBytesNum + len(data)
-	list := make([][]byte, size)
-	list[0] = dingTextMessageDataPrefix
-	list[1] = d.prefix
-	list[2] = []byte(source)
-	list[3] = messageTitleContentSplit
+	list := make([][]byte, 0, dingMessageDataFixedBytesNum+len(data))
+	list = append(list, dingTextMessageDataPrefix, title)
 
-	idx := 4
 	messageRemainCapacity := dingMessageDataMaxLength
 
 	for _, bytes := range data {
@@ -121,15 +157,14 @@ func (d *DingTransfer) execTrans(source string, data ...[]byte) error {
 
 		bytes = EscapeLimitJSONBytes(bytes, messageRemainCapacity)
 
-		list[idx] = bytes
-		idx++
+		list = append(list, bytes)
 
 		messageRemainCapacity -= len(bytes)
 	}
 
-	list[idx] = dingTextMessageDataSuffix
+	list = append(list, dingTextMessageDataSuffix)
 
-	if err := httpTransWithClient(d.client, d.url, list[:idx+1]...); err != nil {
+	if err := httpTransWithClient(d.client, d.url, list...); err != nil {
 		vlog.Errorf("ding error: %v", err)
 	}
 
@@ -142,6 +177,7 @@ func NewDingTransfer(id, url, prefix string, opts HTTPTransferOptions) *DingTran
 		id:           id,
 		url:          url,
 		transferring: 0,
+		noDrop:       opts.DisableDropInterval,
 		client: NewHTTPClient(HTTPClientConfig{
 			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
 			IdleConnTimeout:     opts.IdleConnTimeout,
@@ -154,6 +190,10 @@ func NewDingTransfer(id, url, prefix string, opts HTTPTransferOptions) *DingTran
 
 	t.prefix = []byte(prefix)
 
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, prefix)
+	}
+
 	t.CountReset()
 
 	if opts.RateLimit > 0 {
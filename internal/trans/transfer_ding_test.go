@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 )
 
@@ -48,7 +49,7 @@ func TestDingTransferWithRateLimit(t *testing.T) {
 	defer func() { _ = dt.Stop() }()
 
 	// First call goes through the CAS + rate limiter
-	err := dt.Trans("src", []byte("msg1"))
+	err := dt.Trans(record.New("src", "", []byte("msg1")))
 	require.NoError(t, err)
 
 	// The first Trans triggers execTrans directly and sets transferring=1.
@@ -72,7 +73,7 @@ func TestDingTransferNoRateLimit(t *testing.T) {
 	dt := trans.NewDingTransfer("ding-norl", server.URL, "test-", trans.HTTPTransferOptions{})
 	defer func() { _ = dt.Stop() }()
 
-	err := dt.Trans("src", []byte("msg1"))
+	err := dt.Trans(record.New("src", "", []byte("msg1")))
 	require.NoError(t, err)
 
 	// Without rate limiting, the first message should go through
@@ -87,3 +88,61 @@ func TestDingTransferName(t *testing.T) {
 
 	assert.Equal(t, "my-ding", dt.Name())
 }
+
+func TestDingTransferDisableDropInterval(t *testing.T) {
+	t.Parallel()
+
+	var requestCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		requestCount.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dt := trans.NewDingTransfer("ding-nodrop", server.URL, "test-", trans.HTTPTransferOptions{
+		DisableDropInterval: true,
+	})
+	defer func() { _ = dt.Stop() }()
+
+	for range 3 {
+		require.NoError(t, dt.Trans(record.New("src", "", []byte("msg"))))
+	}
+
+	// every message is transferred without dropping in the interval
+	assert.Equal(t, int32(3), requestCount.Load())
+}
+
+func TestDingTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dt := trans.NewDingTransfer("ding-template", server.URL, "test-", trans.HTTPTransferOptions{
+		DisableDropInterval: true,
+		Template:            "{{.Level}} alert of {{.Source}} by {{.Router}}\n{{.Text}}",
+	})
+	defer func() { _ = dt.Stop() }()
+
+	rec := record.New("app", "", []byte(`failed "order"`))
+	rec.Level = "error"
+	rec.Router = "alert"
+	require.NoError(t, dt.Trans(rec))
+
+	assert.JSONEq(t, `{"msgtype":"text","text":{"content":"error alert of app by alert\nfailed \"order\""}}`, <-bodies)
+
+	dt = trans.NewDingTransfer("ding-default", server.URL, "test-", trans.HTTPTransferOptions{})
+	defer func() { _ = dt.Stop() }()
+
+	require.NoError(t, dt.Trans(record.New("app", "", []byte("msg1")), record.New("app", "", []byte("msg2"))))
+
+	assert.JSONEq(t, `{"msgtype":"text","text":{"content":"[logtail-test-app]: msg1msg2"}}`, <-bodies)
+}
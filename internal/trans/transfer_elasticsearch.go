@@ -0,0 +1,335 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeElasticsearch transfer type of elasticsearch or opensearch bulk indexing.
+const TypeElasticsearch = "elasticsearch"
+
+const (
+	// DefaultElasticsearchIndex the default index pattern, an index per day of the event time in UTC.
+	DefaultElasticsearchIndex = `logtail-{{.Timestamp.UTC.Format "2006.01.02"}}`
+
+	elasticsearchDefaultBulkSize      = 500
+	elasticsearchDefaultFlushInterval = 5 * time.Second
+
+	// elasticsearchMaxRetries the max retries of the failed items of a bulk.
+	elasticsearchMaxRetries = 3
+
+	// elasticsearchRetryInterval the interval before the first retry, doubled for each retry.
+	elasticsearchRetryInterval = 500 * time.Millisecond
+
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+var ErrElasticsearchBulk = errors.New("elasticsearch bulk error")
+
+// ElasticsearchTransferOptions holds parsed configuration for the elasticsearch transfer.
+// BuildTransfer in internal/tail parses conf.TransferConfig into this struct.
+type ElasticsearchTransferOptions struct {
+	HTTPTransferOptions
+
+	// Index the text/template of the index of each record, defaults to DefaultElasticsearchIndex.
+	Index string
+
+	// Username and Password for the basic authentication, APIKey for the api key authentication.
+	Username string
+	Password string
+	APIKey   string
+}
+
+// ElasticsearchDocument the document indexed of a record.
+type ElasticsearchDocument struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	Message   string            `json:"message"`
+	Source    string            `json:"source"`
+	Host      string            `json:"host,omitempty"`
+	Router    string            `json:"router,omitempty"`
+	Level     string            `json:"level,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// elasticsearchItem an action and document of a bulk request.
+type elasticsearchItem struct {
+	index    string
+	document []byte
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool                                      `json:"errors"`
+	Items  []map[string]*elasticsearchBulkItemResult `json:"items"`
+}
+
+type elasticsearchBulkItemResult struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// ElasticsearchTransfer indexes records in elasticsearch or opensearch by the _bulk API.
+// Records are collected by a batcher and indexed in bulks, and the items failed for the rate limit or
+// server errors are retried in the background.
+type ElasticsearchTransfer struct {
+	id       string
+	url      string // the url of the _bulk API
+	opts     ElasticsearchTransferOptions
+	index    *MessageTemplate
+	client   *http.Client
+	batcher  *Batcher
+	retries  *retryQueue
+	stopOnce sync.Once
+}
+
+func (d *ElasticsearchTransfer) Name() string {
+	return d.id
+}
+
+func (d *ElasticsearchTransfer) Start() error { return nil }
+
+// Stop indexes the collected records, and drops the items waiting for the retries.
+func (d *ElasticsearchTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		d.batcher.Stop()
+		d.retries.stop()
+		closeHTTPClient(d.client)
+	})
+
+	return nil
+}
+
+// Trans adds the records to the bulk.
+func (d *ElasticsearchTransfer) Trans(records ...*record.Record) error {
+	for _, rec := range records {
+		d.batcher.AddRecord(rec)
+	}
+
+	return nil
+}
+
+// bulk indexes the records, and queues the failed items to retry at most elasticsearchMaxRetries times.
+func (d *ElasticsearchTransfer) bulk(records []*record.Record) error {
+	items := make([]*elasticsearchItem, 0, len(records))
+
+	for _, rec := range records {
+		item, err := d.item(rec)
+		if err != nil {
+			vlog.Errorf("elasticsearch transfer %s: %v", d.id, err)
+
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	failed, err := d.send(items)
+	if err != nil {
+		vlog.Errorf("elasticsearch error: %v", err)
+	}
+
+	if len(failed) > 0 {
+		d.retries.add(len(failed), 0, d.retry(failed))
+	}
+
+	return nil
+}
+
+// retry the retry of the failed items, the items failed again are retried next time.
+func (d *ElasticsearchTransfer) retry(items []*elasticsearchItem) retryFunc {
+	return func() (int, time.Duration) {
+		failed, err := d.send(items)
+		if err != nil {
+			vlog.Errorf("elasticsearch error: %v", err)
+		}
+
+		items = failed
+
+		return len(failed), 0
+	}
+}
+
+func (d *ElasticsearchTransfer) item(rec *record.Record) (*elasticsearchItem, error) {
+	index, err := d.index.Render([]*record.Record{rec})
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := json.Marshal(&ElasticsearchDocument{
+		Timestamp: rec.EventTime(),
+		Message:   string(rec.Data),
+		Source:    rec.Source,
+		Host:      hostname(),
+		Router:    rec.Router,
+		Level:     rec.Level,
+		Fields:    rec.Fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// index names must be lowercase.
+	return &elasticsearchItem{index: strings.ToLower(strings.TrimSpace(string(index))), document: document}, nil
+}
+
+// send sends the bulk request, and returns the items to retry,
+// which are all items if the request failed for the rate limit or server errors, otherwise the failed ones.
+func (d *ElasticsearchTransfer) send(items []*elasticsearchItem) ([]*elasticsearchItem, error) {
+	var body bytes.Buffer
+
+	for _, item := range items {
+		action, err := json.Marshal(map[string]map[string]string{"create": {"_index": item.index}})
+		if err != nil {
+			return nil, err
+		}
+
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(item.document)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.url, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentTypeNDJSON)
+
+	if d.opts.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+d.opts.APIKey)
+	} else if d.opts.Username != "" {
+		req.SetBasicAuth(d.opts.Username, d.opts.Password)
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return items, err
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return items, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%w: %d %s", ErrElasticsearchBulk, res.StatusCode, data)
+
+		if elasticsearchRetryable(res.StatusCode) {
+			return items, err
+		}
+
+		return nil, err
+	}
+
+	return d.failedItems(items, data)
+}
+
+// failedItems parses the bulk response, logs the failed items, and returns the retryable ones.
+func (d *ElasticsearchTransfer) failedItems(items []*elasticsearchItem, data []byte) ([]*elasticsearchItem, error) {
+	var res elasticsearchBulkResponse
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrElasticsearchBulk, data)
+	}
+
+	if !res.Errors {
+		return nil, nil
+	}
+
+	if len(res.Items) != len(items) {
+		return nil, fmt.Errorf("%w: %d items responded for %d", ErrElasticsearchBulk, len(res.Items), len(items))
+	}
+
+	var failed []*elasticsearchItem
+
+	for i, result := range res.Items {
+		for _, item := range result {
+			if item.Status < http.StatusMultipleChoices {
+				continue
+			}
+
+			if elasticsearchRetryable(item.Status) {
+				failed = append(failed, items[i])
+			} else {
+				vlog.Errorf("elasticsearch transfer %s: index %s error: %d %s", d.id, items[i].index, item.Status, item.Error)
+			}
+		}
+	}
+
+	return failed, nil
+}
+
+// elasticsearchRetryable whether the status is for the rate limit or server errors.
+func elasticsearchRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// NewElasticsearchTransfer new elasticsearch trans indexing in the cluster of the url,
+// it panics if the index template is invalid.
+func NewElasticsearchTransfer(id, url string, opts ElasticsearchTransferOptions) *ElasticsearchTransfer {
+	t := &ElasticsearchTransfer{
+		id:   id,
+		url:  strings.TrimSuffix(url, "/") + "/_bulk",
+		opts: opts,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+	}
+
+	t.retries = newRetryQueue("elasticsearch transfer "+id, elasticsearchMaxRetries, elasticsearchRetryInterval)
+
+	index := opts.Index
+	if index == "" {
+		index = DefaultElasticsearchIndex
+	}
+
+	t.index = NewMessageTemplate(index, "")
+
+	bulkSize := opts.BatchSize
+	if bulkSize <= 1 {
+		bulkSize = elasticsearchDefaultBulkSize
+	}
+
+	flushInterval := opts.BatchTimeout
+	if flushInterval <= 0 {
+		flushInterval = elasticsearchDefaultFlushInterval
+	}
+
+	t.batcher = NewRecordBatcher(bulkSize, flushInterval, t.bulk)
+
+	return t
+}
@@ -0,0 +1,285 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+type bulkItem struct {
+	Index    string
+	Document *trans.ElasticsearchDocument
+}
+
+type elasticsearchServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bulks    [][]*bulkItem
+	statuses func(bulk int, item *bulkItem) int // the status of the item in the bulk, 201 if nil
+	auth     string
+}
+
+func newElasticsearchServer(t *testing.T) *elasticsearchServer {
+	t.Helper()
+
+	s := &elasticsearchServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var items []*bulkItem
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			action := map[string]map[string]string{}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			doc := &trans.ElasticsearchDocument{}
+			_ = json.Unmarshal(scanner.Bytes(), doc)
+
+			items = append(items, &bulkItem{Index: action["create"]["_index"], Document: doc})
+		}
+
+		s.mu.Lock()
+		s.auth = r.Header.Get("Authorization")
+		s.bulks = append(s.bulks, items)
+		bulk := len(s.bulks) - 1
+		s.mu.Unlock()
+
+		results := make([]string, len(items))
+		hasErrors := false
+
+		for i, item := range items {
+			status := http.StatusCreated
+			if s.statuses != nil {
+				status = s.statuses(bulk, item)
+			}
+
+			if status == http.StatusCreated {
+				results[i] = fmt.Sprintf(`{"create":{"_index":%q,"status":201}}`, item.Index)
+			} else {
+				hasErrors = true
+				results[i] = fmt.Sprintf(`{"create":{"_index":%q,"status":%d,"error":{"type":"error_%d"}}}`,
+					item.Index, status, status)
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, `{"took":1,"errors":%t,"items":[%s]}`, hasErrors, strings.Join(results, ","))
+	}))
+
+	return s
+}
+
+func (s *elasticsearchServer) received() [][]*bulkItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]*bulkItem(nil), s.bulks...)
+}
+
+func TestElasticsearchTransferBulk(t *testing.T) {
+	t.Parallel()
+
+	server := newElasticsearchServer(t)
+	defer server.Close()
+
+	et := trans.NewElasticsearchTransfer("es", server.URL, trans.ElasticsearchTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 2, BatchTimeout: time.Hour},
+		Username:            "elastic",
+		Password:            "secret",
+	})
+	assert.Equal(t, "es", et.Name())
+
+	eventTime := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Router = "alert"
+	rec.Level = "error"
+	rec.Time = eventTime
+	rec.Fields = map[string]string{"trace_id": "t-1"}
+
+	require.NoError(t, et.Trans(rec))
+	assert.Empty(t, server.received())
+
+	// flushed when reaching the bulk size.
+	require.NoError(t, et.Trans(record.New("db", "", []byte("slow query"))))
+
+	bulks := server.received()
+	require.Len(t, bulks, 1)
+	require.Len(t, bulks[0], 2)
+	assert.Equal(t, "logtail-2024.01.15", bulks[0][0].Index)
+	assert.Equal(t, "ERROR failed", bulks[0][0].Document.Message)
+	assert.Equal(t, "app", bulks[0][0].Document.Source)
+	assert.Equal(t, "alert", bulks[0][0].Document.Router)
+	assert.Equal(t, "error", bulks[0][0].Document.Level)
+	assert.True(t, eventTime.Equal(bulks[0][0].Document.Timestamp))
+	assert.Equal(t, map[string]string{"trace_id": "t-1"}, bulks[0][0].Document.Fields)
+	assert.NotEmpty(t, bulks[0][0].Document.Host)
+	assert.Equal(t, "db", bulks[0][1].Document.Source)
+	assert.Equal(t, "Basic ZWxhc3RpYzpzZWNyZXQ=", server.auth)
+
+	require.NoError(t, et.Stop())
+}
+
+func TestElasticsearchTransferIndexPattern(t *testing.T) {
+	t.Parallel()
+
+	server := newElasticsearchServer(t)
+	defer server.Close()
+
+	et := trans.NewElasticsearchTransfer("es-index", server.URL+"/", trans.ElasticsearchTransferOptions{
+		Index:  `logs-{{.Source}}-{{.Timestamp.Format "2006.01"}}`,
+		APIKey: "key",
+	})
+
+	rec := record.New("MyApp", "", []byte("msg"))
+	rec.Time = time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+	require.NoError(t, et.Trans(rec))
+
+	// flushed when stopped.
+	require.NoError(t, et.Stop())
+
+	bulks := server.received()
+	require.Len(t, bulks, 1)
+	assert.Equal(t, "logs-myapp-2024.03", bulks[0][0].Index)
+	assert.Equal(t, "ApiKey key", server.auth)
+}
+
+func TestElasticsearchTransferFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	server := newElasticsearchServer(t)
+	defer server.Close()
+
+	et := trans.NewElasticsearchTransfer("es-interval", server.URL, trans.ElasticsearchTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchTimeout: 100 * time.Millisecond},
+	})
+	defer func() { _ = et.Stop() }()
+
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestElasticsearchTransferRetryPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	server := newElasticsearchServer(t)
+	defer server.Close()
+
+	server.statuses = func(bulk int, item *bulkItem) int {
+		switch {
+		case item.Document.Message == "bad":
+			return http.StatusBadRequest
+		case item.Document.Message == "busy" && bulk == 0:
+			return http.StatusTooManyRequests
+		case item.Document.Message == "down" && bulk < 2:
+			return http.StatusServiceUnavailable
+		default:
+			return http.StatusCreated
+		}
+	}
+
+	et := trans.NewElasticsearchTransfer("es-retry", server.URL, trans.ElasticsearchTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchTimeout: 50 * time.Millisecond},
+	})
+	defer func() { _ = et.Stop() }()
+
+	require.NoError(t, et.Trans(
+		record.New("app", "", []byte("ok")),
+		record.New("app", "", []byte("busy")),
+		record.New("app", "", []byte("bad")),
+		record.New("app", "", []byte("down")),
+	))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	messages := func(items []*bulkItem) []string {
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Document.Message)
+		}
+
+		return result
+	}
+
+	// only the items failed for the rate limit or server errors are retried.
+	bulks := server.received()
+	require.Len(t, bulks, 3)
+	assert.Equal(t, []string{"ok", "busy", "bad", "down"}, messages(bulks[0]))
+	assert.Equal(t, []string{"busy", "down"}, messages(bulks[1]))
+	assert.Equal(t, []string{"down"}, messages(bulks[2]))
+}
+
+func TestElasticsearchTransferRetryInBackground(t *testing.T) {
+	t.Parallel()
+
+	server := newElasticsearchServer(t)
+	defer server.Close()
+
+	server.statuses = func(int, *bulkItem) int {
+		return http.StatusServiceUnavailable
+	}
+
+	et := trans.NewElasticsearchTransfer("es-retry-background", server.URL, trans.ElasticsearchTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 2},
+	})
+
+	// the bulks are flushed by the batch size, without waiting for the retries of the failed ones.
+	start := time.Now()
+
+	for i := range 6 {
+		require.NoError(t, et.Trans(record.New("app", "", []byte("msg"+strconv.Itoa(i)))))
+	}
+
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Len(t, server.received(), 3)
+
+	// the items waiting for the retries are dropped when stopped.
+	start = time.Now()
+
+	require.NoError(t, et.Stop())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
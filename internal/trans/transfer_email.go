@@ -0,0 +1,508 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vogo/logtail/internal/consts"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeEmail transfer type of emails sent by SMTP.
+const TypeEmail = "email"
+
+// TLS modes of the SMTP connection.
+const (
+	// EmailTLSModeStartTLS upgrades the connection by STARTTLS, which is required by the mode.
+	EmailTLSModeStartTLS = "starttls"
+
+	// EmailTLSModeImplicit connects by TLS, mostly to the port 465.
+	EmailTLSModeImplicit = "tls"
+
+	// EmailTLSModeNone sends in plain text, only for trusted networks.
+	EmailTLSModeNone = "none"
+)
+
+const (
+	emailDefaultPort         = 587
+	emailDefaultImplicitPort = 465
+	emailDefaultPlainPort    = 25
+
+	// emailDigestMaxRecords the default max records of a digest email.
+	emailDigestMaxRecords = 1000
+
+	emailDialTimeout = 10 * time.Second
+	emailSendTimeout = time.Minute
+
+	// the default rate limit of the emails without the digest, 10 emails per minute and 10 in a burst.
+	emailDefaultRateLimit = 10.0 / 60
+	emailDefaultRateBurst = 10
+
+	// emailMaxPending the max emails waiting to send, the oldest ones are dropped if exceeded.
+	emailMaxPending = 100
+
+	emailDefaultSubject       = "[{{.Prefix}}{{.Source}}{{with .Router}} / {{.}}{{end}}] {{.Count}} records"
+	emailDefaultDigestSubject = "[{{.Prefix}}digest] {{.Count}} records"
+)
+
+var ErrSMTPStartTLS = errors.New("smtp server does not support STARTTLS")
+
+// EmailTransferOptions holds parsed configuration for the email transfer.
+// BuildTransfer in internal/tail parses conf.TransferConfig into this struct.
+type EmailTransferOptions struct {
+	Host          string
+	Port          int    // defaults to 587 for starttls, 465 for tls, 25 for none
+	TLSMode       string // starttls, tls or none; defaults to tls for the port 465, otherwise starttls
+	TLSSkipVerify bool   // skips verifying the certificate of the server, e.g. self-signed ones
+	Username      string // authenticates by PLAIN if not empty
+	Password      string
+	From          string
+	To            []string
+	Subject       string // text/template of the subject, see MessageData
+	Template      string // text/template of the body, see MessageData
+
+	// DigestInterval collects the records for the interval and sends them in one email, 0 disables the digest.
+	DigestInterval time.Duration
+
+	// DigestMaxRecords sends the digest in advance when the records reach it, defaults to 1000.
+	DigestMaxRecords int
+
+	// RateLimit the emails per second and RateBurst the burst size, the records exceeded are dropped.
+	// Defaults to 10 emails per minute without the digest, and unlimited with it.
+	RateLimit float64
+	RateBurst int
+}
+
+// EmailTransfer sends records in emails by SMTP.
+// Emails are queued and sent in the background, so that the SMTP sessions never block the routers.
+type EmailTransfer struct {
+	id        string
+	prefix    string
+	opts      EmailTransferOptions
+	addr      string
+	from      string   // the address of the envelope sender
+	to        []string // the addresses of the envelope recipients
+	tlsConfig *tls.Config
+	subject   *MessageTemplate
+	template  *MessageTemplate // nil for the default body
+	batcher   *Batcher         // nil when the digest disabled
+	limiter   *rateLimiter     // nil when rate limiting disabled
+
+	mu      sync.Mutex
+	pending [][]byte
+	dropped int // the count of the emails dropped since the last sending
+
+	notify   chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func (d *EmailTransfer) Name() string {
+	return d.id
+}
+
+func (d *EmailTransfer) Start() error { return nil }
+
+// Stop sends the collected digest, and the pending emails.
+func (d *EmailTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		if d.batcher != nil {
+			d.batcher.Stop()
+		}
+
+		close(d.done)
+		<-d.exited
+
+		if d.limiter != nil {
+			d.limiter.Stop()
+		}
+	})
+
+	return nil
+}
+
+// Trans sends the successive records of a source in an email, or collects them into the digest.
+func (d *EmailTransfer) Trans(records ...*record.Record) error {
+	if d.batcher != nil {
+		for _, rec := range records {
+			d.batcher.AddRecord(rec)
+		}
+
+		return nil
+	}
+
+	return eachSource(records, func(_ string, group []*record.Record) error {
+		return d.post(group)
+	})
+}
+
+// post queues the email of the records, the records are dropped if the rate limit exceeded.
+func (d *EmailTransfer) post(records []*record.Record) error {
+	if d.limiter != nil && !d.limiter.Allow() {
+		vlog.Warnf("email transfer %s: rate limit exceeded, dropping %d records", d.id, len(records))
+
+		return nil
+	}
+
+	msg, err := d.message(records)
+	if err != nil {
+		vlog.Errorf("email transfer %s: %v", d.id, err)
+
+		return nil
+	}
+
+	d.mu.Lock()
+
+	d.pending = append(d.pending, msg)
+
+	if exceeded := len(d.pending) - emailMaxPending; exceeded > 0 {
+		d.pending = d.pending[exceeded:]
+		d.dropped += exceeded
+	}
+
+	d.mu.Unlock()
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// loop sends the pending emails, until stopped and the pending ones are sent.
+func (d *EmailTransfer) loop() {
+	defer close(d.exited)
+
+	for {
+		select {
+		case <-d.done:
+			d.sendPending()
+
+			return
+		case <-d.notify:
+			d.sendPending()
+		}
+	}
+}
+
+func (d *EmailTransfer) sendPending() {
+	for {
+		msg, ok := d.next()
+		if !ok {
+			return
+		}
+
+		if err := d.send(msg); err != nil {
+			vlog.Errorf("email error: %v", err)
+		}
+	}
+}
+
+// next takes the next pending email.
+func (d *EmailTransfer) next() ([]byte, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dropped > 0 {
+		vlog.Errorf("email transfer %s: %d emails dropped for the queue full", d.id, d.dropped)
+		d.dropped = 0
+	}
+
+	if len(d.pending) == 0 {
+		return nil, false
+	}
+
+	msg := d.pending[0]
+	d.pending = d.pending[1:]
+
+	return msg, true
+}
+
+// message builds the mail of the records, with the body in quoted-printable encoding.
+func (d *EmailTransfer) message(records []*record.Record) ([]byte, error) {
+	subject, err := d.subject.Render(records)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+
+	if d.template != nil {
+		if body, err = d.template.Render(records); err != nil {
+			return nil, err
+		}
+	} else {
+		body = d.defaultBody(records)
+	}
+
+	var buf bytes.Buffer
+
+	// a subject of multiple lines is joined into one line.
+	subjectLine := strings.Join(strings.Fields(string(subject)), " ")
+
+	buf.WriteString("From: " + d.opts.From + "\r\n")
+	buf.WriteString("To: " + strings.Join(d.opts.To, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subjectLine) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err = writer.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// defaultBody lists the records with their event time, level, source and router.
+func (d *EmailTransfer) defaultBody(records []*record.Record) []byte {
+	var buf bytes.Buffer
+
+	first, last := records[0].EventTime(), records[len(records)-1].EventTime()
+
+	_, _ = fmt.Fprintf(&buf, "%d records from %s to %s", len(records),
+		first.Format(consts.FormatDateTime), last.Format(consts.FormatDateTime))
+
+	if host := hostname(); host != "" {
+		buf.WriteString(" on " + host)
+	}
+
+	buf.WriteString("\n")
+
+	for _, rec := range records {
+		buf.WriteString("\n" + rec.EventTime().Format(consts.FormatDateTime))
+
+		if rec.Level != "" {
+			buf.WriteString(" [" + rec.Level + "]")
+		}
+
+		buf.WriteString(" " + d.prefix + rec.Source)
+
+		if rec.Router != "" {
+			buf.WriteString(" / " + rec.Router)
+		}
+
+		buf.WriteString("\n")
+		buf.Write(rec.Data)
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// send sends the mail by SMTP, upgrading the connection by STARTTLS and authenticating as configured.
+func (d *EmailTransfer) send(msg []byte) error {
+	client, err := d.dial()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = client.Close() }()
+
+	if host := hostname(); host != "" {
+		if err = client.Hello(host); err != nil {
+			return err
+		}
+	}
+
+	if d.opts.TLSMode == EmailTLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return ErrSMTPStartTLS
+		}
+
+		if err = client.StartTLS(d.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if d.opts.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", d.opts.Username, d.opts.Password, d.opts.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(d.from); err != nil {
+		return err
+	}
+
+	for _, to := range d.to {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = writer.Write(msg); err != nil {
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (d *EmailTransfer) dial() (*smtp.Client, error) {
+	dialer := &net.Dialer{Timeout: emailDialTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if d.opts.TLSMode == EmailTLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", d.addr, d.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", d.addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(emailSendTimeout)); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, d.opts.Host)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// envelopeAddress the address of the mail address with an optional name, e.g. `Logtail <logtail@example.com>`.
+func envelopeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+
+	return address
+}
+
+// NewEmailTransfer new email trans, it panics if the subject or body template is invalid.
+func NewEmailTransfer(id, prefix string, opts EmailTransferOptions) *EmailTransfer {
+	if opts.TLSMode == "" {
+		opts.TLSMode = EmailTLSModeStartTLS
+
+		if opts.Port == emailDefaultImplicitPort {
+			opts.TLSMode = EmailTLSModeImplicit
+		}
+	}
+
+	if opts.Port <= 0 {
+		switch opts.TLSMode {
+		case EmailTLSModeImplicit:
+			opts.Port = emailDefaultImplicitPort
+		case EmailTLSModeNone:
+			opts.Port = emailDefaultPlainPort
+		default:
+			opts.Port = emailDefaultPort
+		}
+	}
+
+	t := &EmailTransfer{
+		id:     id,
+		prefix: prefix,
+		opts:   opts,
+		addr:   net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+		tlsConfig: &tls.Config{
+			ServerName:         opts.Host,
+			InsecureSkipVerify: opts.TLSSkipVerify, //nolint:gosec // configured for self-signed certificates
+			MinVersion:         tls.VersionTLS12,
+		},
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+
+	if t.prefix == "" {
+		t.prefix = DefaultTransferPrefix
+	}
+
+	t.from = envelopeAddress(opts.From)
+	for _, to := range opts.To {
+		t.to = append(t.to, envelopeAddress(to))
+	}
+
+	subject := opts.Subject
+	if subject == "" {
+		subject = emailDefaultSubject
+
+		if opts.DigestInterval > 0 {
+			subject = emailDefaultDigestSubject
+		}
+	}
+
+	t.subject = NewMessageTemplate(subject, t.prefix)
+
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, t.prefix)
+	}
+
+	if opts.DigestInterval > 0 {
+		maxRecords := opts.DigestMaxRecords
+		if maxRecords <= 0 {
+			maxRecords = emailDigestMaxRecords
+		}
+
+		t.batcher = NewRecordBatcher(maxRecords, opts.DigestInterval, t.post)
+	}
+
+	if opts.RateLimit > 0 {
+		t.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	} else if opts.DigestInterval <= 0 {
+		t.limiter = newRateLimiter(emailDefaultRateLimit, emailDefaultRateBurst)
+	}
+
+	go t.loop()
+
+	return t
+}
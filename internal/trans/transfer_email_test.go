@@ -0,0 +1,402 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/smtpmock"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+type smtpServer struct {
+	*smtpmock.Server
+	mu       sync.Mutex
+	messages []*smtpmock.Message
+}
+
+func newSMTPServer(t *testing.T, config smtpmock.Config) *smtpServer {
+	t.Helper()
+
+	s := &smtpServer{}
+
+	server, err := smtpmock.Listen("127.0.0.1:0", config, func(msg *smtpmock.Message) {
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		s.mu.Unlock()
+	})
+	require.NoError(t, err)
+
+	s.Server = server
+
+	return s
+}
+
+func (s *smtpServer) received() []*smtpmock.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*smtpmock.Message(nil), s.messages...)
+}
+
+// options of the email transfer sending to the server.
+func (s *smtpServer) options(t *testing.T, tlsMode string) trans.EmailTransferOptions {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(s.Addr())
+	require.NoError(t, err)
+
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	return trans.EmailTransferOptions{
+		Host:          host,
+		Port:          portNum,
+		TLSMode:       tlsMode,
+		TLSSkipVerify: true,
+		From:          "Logtail <logtail@example.com>",
+		To:            []string{"ops@example.com", "Dev <dev@example.com>"},
+	}
+}
+
+// selfSignedTLSConfig the tls config of a self-signed certificate for 127.0.0.1.
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtpmock"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// parseMail parses the subject and the decoded body of the mail.
+func parseMail(t *testing.T, data []byte) (string, string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "quoted-printable", msg.Header.Get("Content-Transfer-Encoding"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+
+	return subject, strings.ReplaceAll(string(body), "\r\n", "\n")
+}
+
+func TestEmailTransferPlainAuth(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{Username: "user", Password: "pass"})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.Username, opts.Password = "user", "pass"
+
+	et := trans.NewEmailTransfer("email", "", opts)
+	assert.Equal(t, "email", et.Name())
+
+	rec := record.New("app", "", []byte("ERROR 数据库连接失败 "+strings.Repeat("x", 100)))
+	rec.Router = "alert"
+	rec.Level = "error"
+	rec.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local)
+	require.NoError(t, et.Trans(rec))
+	require.NoError(t, et.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.False(t, messages[0].TLS)
+	assert.Equal(t, "logtail@example.com", messages[0].From)
+	assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, messages[0].To)
+
+	subject, body := parseMail(t, messages[0].Data)
+	assert.Equal(t, "[logtail-app / alert] 1 records", subject)
+	assert.Contains(t, body, "1 records from 2024-01-15 10:30:45 to 2024-01-15 10:30:45")
+	assert.Contains(t, body, "\n\n2024-01-15 10:30:45 [error] logtail-app / alert\nERROR 数据库连接失败 ")
+	assert.Contains(t, body, strings.Repeat("x", 100))
+}
+
+func TestEmailTransferAuthFailed(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{Username: "user", Password: "pass"})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.Username, opts.Password = "user", "wrong"
+
+	et := trans.NewEmailTransfer("email-auth", "", opts)
+
+	// the error is logged without stopping the router.
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+	require.NoError(t, et.Stop())
+	assert.Empty(t, server.received())
+}
+
+func TestEmailTransferStartTLS(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{TLS: selfSignedTLSConfig(t), Username: "user", Password: "pass"})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeStartTLS)
+	opts.Username, opts.Password = "user", "pass"
+
+	et := trans.NewEmailTransfer("email-starttls", "", opts)
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+	require.NoError(t, et.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].TLS)
+}
+
+func TestEmailTransferStartTLSRequired(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{})
+	defer func() { _ = server.Close() }()
+
+	et := trans.NewEmailTransfer("email-no-starttls", "", server.options(t, ""))
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+	require.NoError(t, et.Stop())
+
+	// not sent in plain text if the server does not support STARTTLS.
+	assert.Empty(t, server.received())
+}
+
+func TestEmailTransferImplicitTLS(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{TLS: selfSignedTLSConfig(t), ImplicitTLS: true})
+	defer func() { _ = server.Close() }()
+
+	et := trans.NewEmailTransfer("email-tls", "", server.options(t, trans.EmailTLSModeImplicit))
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+	require.NoError(t, et.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].TLS)
+}
+
+func TestEmailTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.Subject = "{{.Level}} on\n{{.Source}}"
+	opts.Template = "{{range .Records}}- {{printf \"%s\" .Data}}\n{{end}}"
+
+	et := trans.NewEmailTransfer("email-template", "", opts)
+
+	rec := record.New("app", "", []byte("first"))
+	rec.Level = "error"
+	require.NoError(t, et.Trans(rec, record.New("app", "", []byte("second"))))
+	require.NoError(t, et.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+
+	subject, body := parseMail(t, messages[0].Data)
+	assert.Equal(t, "error on app", subject)
+	assert.Equal(t, "- first\n- second\n", body)
+}
+
+func TestEmailTransferDigest(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.DigestInterval = 200 * time.Millisecond
+
+	et := trans.NewEmailTransfer("email-digest", "", opts)
+	defer func() { _ = et.Stop() }()
+
+	require.NoError(t, et.Trans(record.New("app", "", []byte("msg1"))))
+	require.NoError(t, et.Trans(record.New("db", "", []byte("msg2")), record.New("app", "", []byte("msg3"))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	subject, body := parseMail(t, server.received()[0].Data)
+	assert.Equal(t, "[logtail-digest] 3 records", subject)
+
+	for _, expected := range []string{"logtail-app\nmsg1", "logtail-db\nmsg2", "logtail-app\nmsg3"} {
+		assert.Contains(t, body, expected)
+	}
+}
+
+func TestEmailTransferDigestStop(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.DigestInterval = time.Hour
+	opts.DigestMaxRecords = 2
+
+	et := trans.NewEmailTransfer("email-digest-stop", "", opts)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, et.Trans(record.New("app", "", []byte("msg"+strconv.Itoa(i)))))
+	}
+
+	// the digest is sent in advance when the records reach the max.
+	require.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// the rest is sent when stopped.
+	require.NoError(t, et.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 2)
+
+	subject, body := parseMail(t, messages[1].Data)
+	assert.Equal(t, "[logtail-digest] 1 records", subject)
+	assert.Contains(t, body, "msg3")
+}
+
+func TestEmailTransferRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, smtpmock.Config{})
+	defer func() { _ = server.Close() }()
+
+	opts := server.options(t, trans.EmailTLSModeNone)
+	opts.RateLimit = 0.001
+	opts.RateBurst = 2
+
+	et := trans.NewEmailTransfer("email-rate", "", opts)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, et.Trans(record.New("app", "", []byte("msg"+strconv.Itoa(i)))))
+	}
+
+	require.NoError(t, et.Stop())
+
+	// the records exceeded the rate limit are dropped, instead of an email per record.
+	messages := server.received()
+	require.Len(t, messages, 2)
+
+	_, body := parseMail(t, messages[1].Data)
+	assert.Contains(t, body, "msg2")
+}
+
+func TestEmailTransferQueue(t *testing.T) {
+	t.Parallel()
+
+	// the server accepts the connections without responding, hanging the SMTP sessions.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	conns := make(chan net.Conn, 10)
+
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+
+			conns <- conn
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+
+	et := trans.NewEmailTransfer("email-queue", "", trans.EmailTransferOptions{
+		Host:    addr.IP.String(),
+		Port:    addr.Port,
+		TLSMode: trans.EmailTLSModeNone,
+		From:    "logtail@example.com",
+		To:      []string{"ops@example.com"},
+	})
+
+	// the router is not blocked by the hanging session.
+	start := time.Now()
+
+	for range 3 {
+		require.NoError(t, et.Trans(record.New("app", "", []byte("msg"))))
+	}
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// closing the connections fails the pending sessions, for the transfer to stop.
+	require.NoError(t, listener.Close())
+
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		_ = et.Stop()
+	}()
+
+	for {
+		select {
+		case conn := <-conns:
+			_ = conn.Close()
+		case <-stopped:
+			return
+		}
+	}
+}
@@ -0,0 +1,397 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeKafka transfer type of kafka producers.
+const TypeKafka = "kafka"
+
+// keys of kafka records.
+const (
+	KafkaKeySource      = "source"
+	KafkaKeyHostname    = "hostname"
+	KafkaKeyFieldPrefix = "field:" // e.g. `field:trace_id` for the extracted field trace_id
+)
+
+// acks of kafka producers.
+const (
+	KafkaAcksAll    = "all"
+	KafkaAcksLeader = "leader"
+	KafkaAcksNone   = "none"
+)
+
+// compression codecs of kafka producers.
+const (
+	KafkaCompressionNone   = "none"
+	KafkaCompressionGzip   = "gzip"
+	KafkaCompressionSnappy = "snappy"
+	KafkaCompressionLz4    = "lz4"
+	KafkaCompressionZstd   = "zstd"
+)
+
+const (
+	// kafkaFlushTimeout the max duration to wait for the buffered records delivered when stopping.
+	kafkaFlushTimeout = 10 * time.Second
+
+	// kafkaMaxLinger the max linger allowed by the producer.
+	kafkaMaxLinger = time.Minute
+
+	// kafkaErrorLogInterval the min interval of logging the delivery errors.
+	kafkaErrorLogInterval = 10 * time.Second
+)
+
+var (
+	ErrKafkaOption = errors.New("invalid kafka option")
+	ErrKafkaTopic  = errors.New("invalid kafka topic")
+)
+
+// KafkaTransferOptions holds parsed configuration for the kafka transfer.
+// BuildTransfer in internal/tail parses conf.TransferConfig into this struct.
+type KafkaTransferOptions struct {
+	Brokers     []string
+	Topic       string        // topic, or text/template of the topic of each record, e.g. `logs-{{.Source}}`
+	Key         string        // source, hostname or field:<name>; no key if empty
+	Acks        string        // all, leader or none; defaults to all
+	Compression string        // none, gzip, snappy, lz4 or zstd; defaults to snappy
+	Envelope    bool          // produces the records in JSON with their metadata instead of the raw data
+	Linger      time.Duration // how long to wait for more records of a batch; defaults to 10ms, 1m at most
+
+	// MaxBufferedRecords the max records waiting for delivery, the exceeded records are dropped; defaults to 10000.
+	MaxBufferedRecords int
+}
+
+// KafkaStats the delivery statistics of the kafka transfer.
+type KafkaStats struct {
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`  // failed to deliver after retries
+	Dropped   int64 `json:"dropped"` // dropped for the buffer full or the topic unresolved
+}
+
+// KafkaTransfer produces records to kafka asynchronously, the records are batched by the producer.
+type KafkaTransfer struct {
+	id       string
+	opts     KafkaTransferOptions
+	topic    *MessageTemplate // nil if the topic is not a template
+	client   *kgo.Client
+	stopOnce sync.Once
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+
+	// errMu protects the delivery errors not logged yet.
+	errMu      sync.Mutex
+	errCount   int
+	lastErr    error
+	lastLogged time.Time
+}
+
+func (d *KafkaTransfer) Name() string {
+	return d.id
+}
+
+func (d *KafkaTransfer) Start() error { return nil }
+
+// Stop waits for the buffered records delivered in a timeout, and closes the producer.
+func (d *KafkaTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), kafkaFlushTimeout)
+		defer cancel()
+
+		if err := d.client.Flush(ctx); err != nil {
+			vlog.Errorf("kafka transfer %s: flush error: %v", d.id, err)
+		}
+
+		d.client.Close()
+		d.logErrors(true)
+	})
+
+	return nil
+}
+
+// Stats returns the delivery statistics.
+func (d *KafkaTransfer) Stats() KafkaStats {
+	return KafkaStats{
+		Delivered: d.delivered.Load(),
+		Failed:    d.failed.Load(),
+		Dropped:   d.dropped.Load(),
+	}
+}
+
+// Trans buffers the records to produce without blocking, the records are dropped if the buffer is full,
+// the delivery results are accounted asynchronously.
+func (d *KafkaTransfer) Trans(records ...*record.Record) error {
+	for _, rec := range records {
+		kafkaRecord, err := d.kafkaRecord(rec)
+		if err != nil {
+			d.dropped.Add(1)
+			d.onError(err)
+
+			continue
+		}
+
+		d.client.TryProduce(context.Background(), kafkaRecord, d.onDelivered)
+	}
+
+	return nil
+}
+
+func (d *KafkaTransfer) onDelivered(_ *kgo.Record, err error) {
+	switch {
+	case err == nil:
+		d.delivered.Add(1)
+	case errors.Is(err, kgo.ErrMaxBuffered):
+		d.dropped.Add(1)
+		d.onError(err)
+	default:
+		d.failed.Add(1)
+		d.onError(err)
+	}
+}
+
+// onError accounts the error, which is logged at most once in the log interval.
+func (d *KafkaTransfer) onError(err error) {
+	d.errMu.Lock()
+	d.errCount++
+	d.lastErr = err
+	d.errMu.Unlock()
+
+	d.logErrors(false)
+}
+
+func (d *KafkaTransfer) logErrors(force bool) {
+	d.errMu.Lock()
+	defer d.errMu.Unlock()
+
+	if d.errCount == 0 || (!force && time.Since(d.lastLogged) < kafkaErrorLogInterval) {
+		return
+	}
+
+	stats := d.Stats()
+
+	vlog.Errorf("kafka transfer %s: %d records not delivered, last error: %v, total failed: %d, dropped: %d",
+		d.id, d.errCount, d.lastErr, stats.Failed, stats.Dropped)
+
+	d.errCount = 0
+	d.lastErr = nil
+	d.lastLogged = time.Now()
+}
+
+func (d *KafkaTransfer) kafkaRecord(rec *record.Record) (*kgo.Record, error) {
+	kafkaRecord := &kgo.Record{
+		Topic:     d.opts.Topic,
+		Key:       d.key(rec),
+		Value:     rec.Data,
+		Timestamp: rec.EventTime(),
+		Headers: []kgo.RecordHeader{
+			{Key: "source", Value: []byte(rec.Source)},
+			{Key: "router", Value: []byte(rec.Router)},
+			{Key: "hostname", Value: []byte(hostname())},
+		},
+	}
+
+	if rec.Level != "" {
+		kafkaRecord.Headers = append(kafkaRecord.Headers, kgo.RecordHeader{Key: "level", Value: []byte(rec.Level)})
+	}
+
+	if d.topic != nil {
+		topic, err := d.topic.Render([]*record.Record{rec})
+		if err != nil {
+			return nil, err
+		}
+
+		kafkaRecord.Topic = sanitizeKafkaTopic(string(topic))
+		if kafkaRecord.Topic == "" {
+			return nil, fmt.Errorf("%w: empty topic of source %s", ErrKafkaTopic, rec.Source)
+		}
+	}
+
+	if d.opts.Envelope {
+		value, err := json.Marshal(NewEnvelopeRecord(rec))
+		if err != nil {
+			return nil, err
+		}
+
+		kafkaRecord.Value = value
+	}
+
+	return kafkaRecord, nil
+}
+
+// key the key of the record, nil for no key, which distributes the records in partitions.
+func (d *KafkaTransfer) key(rec *record.Record) []byte {
+	switch d.opts.Key {
+	case "":
+		return nil
+	case KafkaKeySource:
+		return []byte(rec.Source)
+	case KafkaKeyHostname:
+		return []byte(hostname())
+	default:
+		if value, ok := rec.Fields[strings.TrimPrefix(d.opts.Key, KafkaKeyFieldPrefix)]; ok {
+			return []byte(value)
+		}
+
+		return nil
+	}
+}
+
+// sanitizeKafkaTopic replaces the characters not allowed in kafka topics with underscores.
+func sanitizeKafkaTopic(topic string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+
+		return '_'
+	}, strings.TrimSpace(topic))
+}
+
+// IsKafkaKey whether the key selection is valid.
+func IsKafkaKey(key string) bool {
+	switch key {
+	case "", KafkaKeySource, KafkaKeyHostname:
+		return true
+	default:
+		return strings.HasPrefix(key, KafkaKeyFieldPrefix) && len(key) > len(KafkaKeyFieldPrefix)
+	}
+}
+
+func kafkaAcks(acks string) (kgo.Acks, bool) {
+	switch acks {
+	case "", KafkaAcksAll:
+		return kgo.AllISRAcks(), true
+	case KafkaAcksLeader:
+		return kgo.LeaderAck(), true
+	case KafkaAcksNone:
+		return kgo.NoAck(), true
+	default:
+		return kgo.Acks{}, false
+	}
+}
+
+func kafkaCompression(compression string) (kgo.CompressionCodec, bool) {
+	switch compression {
+	case "", KafkaCompressionSnappy:
+		return kgo.SnappyCompression(), true
+	case KafkaCompressionNone:
+		return kgo.NoCompression(), true
+	case KafkaCompressionGzip:
+		return kgo.GzipCompression(), true
+	case KafkaCompressionLz4:
+		return kgo.Lz4Compression(), true
+	case KafkaCompressionZstd:
+		return kgo.ZstdCompression(), true
+	default:
+		return kgo.CompressionCodec{}, false
+	}
+}
+
+// CheckKafkaOptions checks the brokers, acks, compression and key selection of the options.
+func CheckKafkaOptions(opts KafkaTransferOptions) error {
+	for _, broker := range opts.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			return fmt.Errorf("%w: broker %s", ErrKafkaOption, broker)
+		}
+	}
+
+	if _, ok := kafkaAcks(opts.Acks); !ok {
+		return fmt.Errorf("%w: acks %s", ErrKafkaOption, opts.Acks)
+	}
+
+	if _, ok := kafkaCompression(opts.Compression); !ok {
+		return fmt.Errorf("%w: compression %s", ErrKafkaOption, opts.Compression)
+	}
+
+	if !IsKafkaKey(opts.Key) {
+		return fmt.Errorf("%w: key %s", ErrKafkaOption, opts.Key)
+	}
+
+	return nil
+}
+
+// NewKafkaTransfer new kafka trans, it panics if the options are invalid, which should be checked before.
+func NewKafkaTransfer(id, prefix string, opts KafkaTransferOptions) *KafkaTransfer {
+	if err := CheckKafkaOptions(opts); err != nil {
+		panic(err)
+	}
+
+	acks, _ := kafkaAcks(opts.Acks)
+	compression, _ := kafkaCompression(opts.Compression)
+
+	kgoOpts := []kgo.Opt{
+		kgo.SeedBrokers(opts.Brokers...),
+		kgo.ClientID("logtail"),
+		kgo.RequiredAcks(acks),
+		kgo.ProducerBatchCompression(compression),
+	}
+
+	// the idempotent producer requires the acks of all in-sync replicas.
+	if opts.Acks != "" && opts.Acks != KafkaAcksAll {
+		kgoOpts = append(kgoOpts, kgo.DisableIdempotentWrite())
+	}
+
+	if opts.Linger > 0 {
+		kgoOpts = append(kgoOpts, kgo.ProducerLinger(min(opts.Linger, kafkaMaxLinger)))
+	}
+
+	// the topics of the sources are created on demand if the brokers allow.
+	if strings.Contains(opts.Topic, "{{") {
+		kgoOpts = append(kgoOpts, kgo.AllowAutoTopicCreation())
+	}
+
+	if opts.MaxBufferedRecords > 0 {
+		kgoOpts = append(kgoOpts, kgo.MaxBufferedRecords(opts.MaxBufferedRecords))
+	}
+
+	client, err := kgo.NewClient(kgoOpts...)
+	if err != nil {
+		panic(err)
+	}
+
+	t := &KafkaTransfer{
+		id:     id,
+		opts:   opts,
+		client: client,
+	}
+
+	if prefix == "" {
+		prefix = DefaultTransferPrefix
+	}
+
+	if strings.Contains(opts.Topic, "{{") {
+		t.topic = NewMessageTemplate(opts.Topic, prefix)
+	}
+
+	return t
+}
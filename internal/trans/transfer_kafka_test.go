@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+func newKafkaCluster(t *testing.T, opts ...kfake.Opt) *kfake.Cluster {
+	t.Helper()
+
+	cluster, err := kfake.NewCluster(append([]kfake.Opt{kfake.NumBrokers(1)}, opts...)...)
+	require.NoError(t, err)
+
+	return cluster
+}
+
+// consumeKafka consumes the count of records of the topics from the start.
+func consumeKafka(t *testing.T, cluster *kfake.Cluster, count int, topics ...string) []*kgo.Record {
+	t.Helper()
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.ConsumeTopics(topics...),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var records []*kgo.Record
+
+	for len(records) < count {
+		fetches := client.PollFetches(ctx)
+		require.NoError(t, ctx.Err())
+
+		records = append(records, fetches.Records()...)
+	}
+
+	return records
+}
+
+func kafkaHeader(rec *kgo.Record, key string) string {
+	for _, header := range rec.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func TestKafkaTransferTopicTemplate(t *testing.T) {
+	t.Parallel()
+
+	cluster := newKafkaCluster(t, kfake.AllowAutoTopicCreation())
+	defer cluster.Close()
+
+	kt := trans.NewKafkaTransfer("kafka", "", trans.KafkaTransferOptions{
+		Brokers:     cluster.ListenAddrs(),
+		Topic:       "logs-{{.Source}}",
+		Key:         trans.KafkaKeySource,
+		Acks:        trans.KafkaAcksLeader,
+		Compression: trans.KafkaCompressionGzip,
+	})
+	assert.Equal(t, "kafka", kt.Name())
+
+	eventTime := time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local)
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Router = "alert"
+	rec.Level = "error"
+	rec.Time = eventTime
+
+	require.NoError(t, kt.Trans(rec, record.New("db:1", "", []byte("slow query"))))
+	require.NoError(t, kt.Stop())
+	assert.Equal(t, trans.KafkaStats{Delivered: 2}, kt.Stats())
+
+	records := consumeKafka(t, cluster, 1, "logs-app")
+	assert.Equal(t, "app", string(records[0].Key))
+	assert.Equal(t, "ERROR failed", string(records[0].Value))
+	assert.True(t, eventTime.Equal(records[0].Timestamp))
+	assert.Equal(t, "app", kafkaHeader(records[0], "source"))
+	assert.Equal(t, "alert", kafkaHeader(records[0], "router"))
+	assert.Equal(t, "error", kafkaHeader(records[0], "level"))
+
+	// the characters not allowed in topics are replaced.
+	records = consumeKafka(t, cluster, 1, "logs-db_1")
+	assert.Equal(t, "db:1", string(records[0].Key))
+	assert.Equal(t, "slow query", string(records[0].Value))
+}
+
+func TestKafkaTransferEnvelopeFieldKey(t *testing.T) {
+	t.Parallel()
+
+	cluster := newKafkaCluster(t, kfake.SeedTopics(1, "logs"))
+	defer cluster.Close()
+
+	kt := trans.NewKafkaTransfer("kafka-envelope", "", trans.KafkaTransferOptions{
+		Brokers:  cluster.ListenAddrs(),
+		Topic:    "logs",
+		Key:      "field:trace_id",
+		Envelope: true,
+		Linger:   50 * time.Millisecond,
+	})
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Fields = map[string]string{"trace_id": "t-1"}
+
+	require.NoError(t, kt.Trans(rec, record.New("app", "", []byte("no trace"))))
+	require.NoError(t, kt.Stop())
+
+	records := consumeKafka(t, cluster, 2, "logs")
+	assert.Equal(t, "t-1", string(records[0].Key))
+	assert.Nil(t, records[1].Key)
+
+	envelope := &trans.EnvelopeRecord{}
+	require.NoError(t, json.Unmarshal(records[0].Value, envelope))
+	assert.Equal(t, "app", envelope.Source)
+	assert.Equal(t, "ERROR failed", envelope.Message)
+	assert.Equal(t, map[string]string{"trace_id": "t-1"}, envelope.Fields)
+}
+
+func TestKafkaTransferDropped(t *testing.T) {
+	t.Parallel()
+
+	cluster := newKafkaCluster(t, kfake.SeedTopics(1, "logs"))
+	defer cluster.Close()
+
+	kt := trans.NewKafkaTransfer("kafka-dropped", "", trans.KafkaTransferOptions{
+		Brokers:            cluster.ListenAddrs(),
+		Topic:              "logs",
+		Linger:             time.Minute,
+		MaxBufferedRecords: 1,
+	})
+
+	// the records exceeding the buffer are dropped instead of blocking.
+	for range 3 {
+		require.NoError(t, kt.Trans(record.New("app", "", []byte("msg"))))
+	}
+
+	assert.Eventually(t, func() bool {
+		return kt.Stats().Dropped == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// the buffered record is delivered when stopped.
+	require.NoError(t, kt.Stop())
+	assert.Equal(t, trans.KafkaStats{Delivered: 1, Dropped: 2}, kt.Stats())
+}
+
+func TestKafkaTransferFailed(t *testing.T) {
+	t.Parallel()
+
+	cluster := newKafkaCluster(t, kfake.SeedTopics(1, "logs"))
+	defer cluster.Close()
+
+	kt := trans.NewKafkaTransfer("kafka-failed", "", trans.KafkaTransferOptions{
+		Brokers: cluster.ListenAddrs(),
+		Topic:   "not-exists",
+	})
+	defer func() { _ = kt.Stop() }()
+
+	require.NoError(t, kt.Trans(record.New("app", "", []byte("msg"))))
+
+	assert.Eventually(t, func() bool {
+		return kt.Stats().Failed == 1
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestCheckKafkaOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{
+		Brokers: []string{"127.0.0.1:9092", "kafka:9092"}, Key: "hostname", Compression: "zstd",
+	}))
+	assert.ErrorIs(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{Brokers: []string{"kafka"}}),
+		trans.ErrKafkaOption)
+	assert.ErrorIs(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{Acks: "2"}), trans.ErrKafkaOption)
+	assert.ErrorIs(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{Compression: "brotli"}), trans.ErrKafkaOption)
+	assert.ErrorIs(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{Key: "field:"}), trans.ErrKafkaOption)
+	assert.ErrorIs(t, trans.CheckKafkaOptions(trans.KafkaTransferOptions{Key: "worker"}), trans.ErrKafkaOption)
+}
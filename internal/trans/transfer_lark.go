@@ -19,9 +19,11 @@ package trans
 
 import (
 	"net/http"
+	"slices"
 	"sync/atomic"
 	"time"
 
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/vogo/vlog"
 )
 
@@ -33,24 +35,29 @@ type LarkTransfer struct {
 	prefix       []byte
 	transferring int32 // whether transferring message
 	client       *http.Client
-	limiter      *rateLimiter // nil when rate limiting disabled
+	limiter      *rateLimiter     // nil when rate limiting disabled
+	noDrop       bool             // transfer every message without dropping messages in the interval
+	template     *MessageTemplate // nil for the default message `[<prefix><source>]: <content>`
 }
 
 // TypeLark transfer type lark.
 const TypeLark = "lark"
 
 const (
-	larkMessageDataFixedBytesNum = 5
+	larkMessageDataFixedBytesNum = 3
 	larkMessageDataMaxLength     = 1024
 	larkMessageTransferInterval  = time.Second * 5
 )
 
 var (
 	//nolint:gochecknoglobals // ignore this
-	larkTextMessageDataPrefix = []byte(`{"msg_type":"text","content":{"text":"[`)
+	larkTextMessageDataPrefix = []byte(`{"msg_type":"text","content":{"text":"`)
 
 	//nolint:gochecknoglobals // ignore this
 	larkTextMessageDataSuffix = []byte(`"}}`)
+
+	//nolint:gochecknoglobals // ignore this
+	larkMessageTitlePrefix = []byte("[")
 )
 
 func (d *LarkTransfer) Name() string {
@@ -69,10 +76,22 @@ func (d *LarkTransfer) Stop() error {
 	return nil
 }
 
-// Trans transfer data to Lark.
-func (d *LarkTransfer) Trans(source string, data ...[]byte) error {
+// Trans transfer records to Lark, the successive records of a source are transferred in a message.
+func (d *LarkTransfer) Trans(records ...*record.Record) error {
+	return eachSource(records, d.transSource)
+}
+
+func (d *LarkTransfer) transSource(source string, records []*record.Record) error {
 	d.CountIncr()
 
+	if d.noDrop {
+		if countMessage, ok := d.CountStat(); ok {
+			_ = d.execTrans(d.title(source), []byte(countMessage))
+		}
+
+		return d.transRecords(source, records)
+	}
+
 	if !atomic.CompareAndSwapInt32(&d.transferring, 0, 1) {
 		// ignore message when transferring
 		return nil
@@ -82,7 +101,7 @@ func (d *LarkTransfer) Trans(source string, data ...[]byte) error {
 		<-time.After(larkMessageTransferInterval)
 
 		if countMessage, ok := d.CountStat(); ok {
-			_ = d.execTrans(source, []byte(countMessage))
+			_ = d.execTrans(d.title(source), []byte(countMessage))
 
 			<-time.After(larkMessageTransferInterval)
 		}
@@ -90,25 +109,42 @@ func (d *LarkTransfer) Trans(source string, data ...[]byte) error {
 		atomic.StoreInt32(&d.transferring, 0)
 	}()
 
-	return d.execTrans(source, data...)
+	return d.transRecords(source, records)
+}
+
+// transRecords transfers the records in the message rendered by the template, or the default message.
+//
+//nolint:dupl // ignore duplicated code for easy maintenance for diff transfers.
+func (d *LarkTransfer) transRecords(source string, records []*record.Record) error {
+	if d.template == nil {
+		return d.execTrans(d.title(source), record.Datas(records)...)
+	}
+
+	content, err := d.template.Render(records)
+	if err != nil {
+		vlog.Errorf("lark transfer %s: template error: %v", d.id, err)
+
+		return nil
+	}
+
+	return d.execTrans(nil, content)
+}
+
+func (d *LarkTransfer) title(source string) []byte {
+	return slices.Concat(larkMessageTitlePrefix, d.prefix, []byte(source), messageTitleContentSplit)
 }
 
 //nolint:dupl // ignore duplicated code for easy maintenance for diff transfers.
-func (d *LarkTransfer) execTrans(source string, data ...[]byte) error {
+func (d *LarkTransfer) execTrans(title []byte, data ...[]byte) error {
 	if d.limiter != nil && !d.limiter.Allow() {
 		vlog.Warnf("lark transfer %s: rate limit exceeded, dropping message", d.id)
 
 		return nil
 	}
 
-	size := larkMessageDataFixedBytesNum + len(data)
-	list := make([][]byte, size)
-	list[0] = larkTextMessageDataPrefix
-	list[1] = d.prefix
-	list[2] = []byte(source)
-	list[3] = messageTitleContentSplit
+	list := make([][]byte, 0, larkMessageDataFixedBytesNum+len(data))
+	list = append(list, larkTextMessageDataPrefix, title)
 
-	idx := 4
 	messageRemainCapacity := larkMessageDataMaxLength
 
 	for _, bytes := range data {
@@ -118,15 +154,14 @@ func (d *LarkTransfer) execTrans(source string, data ...[]byte) error {
 
 		bytes = EscapeLimitJSONBytes(bytes, messageRemainCapacity)
 
-		list[idx] = bytes
-		idx++
+		list = append(list, bytes)
 
 		messageRemainCapacity -= len(bytes)
 	}
 
-	list[idx] = larkTextMessageDataSuffix
+	list = append(list, larkTextMessageDataSuffix)
 
-	if err := httpTransWithClient(d.client, d.url, list[:idx+1]...); err != nil {
+	if err := httpTransWithClient(d.client, d.url, list...); err != nil {
 		vlog.Errorf("lark error: %v", err)
 	}
 
@@ -139,6 +174,7 @@ func NewLarkTransfer(id, url, prefix string, opts HTTPTransferOptions) *LarkTran
 		id:           id,
 		url:          url,
 		transferring: 0,
+		noDrop:       opts.DisableDropInterval,
 		client: NewHTTPClient(HTTPClientConfig{
 			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
 			IdleConnTimeout:     opts.IdleConnTimeout,
@@ -151,6 +187,10 @@ func NewLarkTransfer(id, url, prefix string, opts HTTPTransferOptions) *LarkTran
 
 	t.prefix = []byte(prefix)
 
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, prefix)
+	}
+
 	t.CountReset()
 
 	if opts.RateLimit > 0 {
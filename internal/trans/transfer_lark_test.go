@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 )
 
@@ -47,7 +48,7 @@ func TestLarkTransferWithRateLimit(t *testing.T) {
 	})
 	defer func() { _ = lt.Stop() }()
 
-	err := lt.Trans("src", []byte("msg1"))
+	err := lt.Trans(record.New("src", "", []byte("msg1")))
 	require.NoError(t, err)
 
 	assert.GreaterOrEqual(t, int(requestCount.Load()), 1)
@@ -68,7 +69,7 @@ func TestLarkTransferNoRateLimit(t *testing.T) {
 	lt := trans.NewLarkTransfer("lark-norl", server.URL, "test-", trans.HTTPTransferOptions{})
 	defer func() { _ = lt.Stop() }()
 
-	err := lt.Trans("src", []byte("msg1"))
+	err := lt.Trans(record.New("src", "", []byte("msg1")))
 	require.NoError(t, err)
 
 	assert.Equal(t, int32(1), requestCount.Load())
@@ -82,3 +83,50 @@ func TestLarkTransferName(t *testing.T) {
 
 	assert.Equal(t, "my-lark", lt.Name())
 }
+
+func TestLarkTransferDisableDropInterval(t *testing.T) {
+	t.Parallel()
+
+	var requestCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		requestCount.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dt := trans.NewLarkTransfer("lark-nodrop", server.URL, "test-", trans.HTTPTransferOptions{
+		DisableDropInterval: true,
+	})
+	defer func() { _ = dt.Stop() }()
+
+	for range 3 {
+		require.NoError(t, dt.Trans(record.New("src", "", []byte("msg"))))
+	}
+
+	// every message is transferred without dropping in the interval
+	assert.Equal(t, int32(3), requestCount.Load())
+}
+
+func TestLarkTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	lt := trans.NewLarkTransfer("lark-template", server.URL, "test-", trans.HTTPTransferOptions{
+		Template: "{{.Count}} records of {{.Source}}: {{.Text}}",
+	})
+	defer func() { _ = lt.Stop() }()
+
+	require.NoError(t, lt.Trans(record.New("app", "", []byte("msg1")), record.New("app", "", []byte("msg2"))))
+
+	assert.JSONEq(t, `{"msg_type":"text","content":{"text":"2 records of app: msg1\nmsg2"}}`, <-bodies)
+}
@@ -0,0 +1,407 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TypeLoki transfer type of grafana loki.
+const TypeLoki = "loki"
+
+// encodings of loki push requests.
+const (
+	LokiEncodingProtobuf = "protobuf"
+	LokiEncodingJSON     = "json"
+)
+
+// labels of loki streams derived from the records.
+const (
+	LokiLabelServer = "server"
+	LokiLabelRouter = "router"
+	LokiLabelHost   = "host"
+)
+
+const (
+	lokiPushPath = "/loki/api/v1/push"
+
+	lokiDefaultBatchSize    = 100
+	lokiDefaultBatchTimeout = time.Second
+
+	// lokiMaxRetries the max retries in the background of a push responded with 429 or server errors.
+	lokiMaxRetries = 3
+
+	// lokiRetryInterval the interval before the first retry without Retry-After, doubled for each retry.
+	lokiRetryInterval = 500 * time.Millisecond
+
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrLokiPush = errors.New("loki push error")
+
+//nolint:gochecknoglobals // ignore this
+var lokiLabelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// IsLokiLabelName whether the name is a valid label name of loki.
+func IsLokiLabelName(name string) bool {
+	return lokiLabelNameRegex.MatchString(name)
+}
+
+// LokiTransferOptions holds parsed configuration for the loki transfer.
+// BuildTransfer in internal/tail parses conf.TransferConfig into this struct.
+type LokiTransferOptions struct {
+	HTTPTransferOptions
+
+	// Labels the static labels of all streams, overridden by the labels derived from the records.
+	Labels map[string]string
+
+	// Encoding protobuf (snappy compressed) or json, defaults to protobuf.
+	Encoding string
+
+	// TenantID the X-Scope-OrgID header of multi-tenant loki.
+	TenantID string
+
+	// Username and Password for the basic authentication.
+	Username string
+	Password string
+}
+
+// lokiStream the entries of a label set.
+type lokiStream struct {
+	labels  map[string]string
+	entries []*record.Record
+}
+
+// LokiTransfer pushes records to grafana loki, records are batched and pushed in streams per label set.
+type LokiTransfer struct {
+	id       string
+	url      string
+	opts     LokiTransferOptions
+	client   *http.Client
+	batcher  *Batcher
+	retries  *retryQueue
+	stopOnce sync.Once
+}
+
+func (d *LokiTransfer) Name() string {
+	return d.id
+}
+
+func (d *LokiTransfer) Start() error { return nil }
+
+// Stop pushes the batched records, and drops the pushes waiting for the retries.
+func (d *LokiTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		d.batcher.Stop()
+		d.retries.stop()
+		closeHTTPClient(d.client)
+	})
+
+	return nil
+}
+
+// Trans adds the records to the batch.
+func (d *LokiTransfer) Trans(records ...*record.Record) error {
+	for _, rec := range records {
+		d.batcher.AddRecord(rec)
+	}
+
+	return nil
+}
+
+// labels the labels of the stream of the record.
+func (d *LokiTransfer) labels(rec *record.Record) map[string]string {
+	labels := maps.Clone(d.opts.Labels)
+	if labels == nil {
+		labels = make(map[string]string, 3) //nolint:mnd // the derived labels
+	}
+
+	labels[LokiLabelServer] = rec.Source
+
+	if rec.Router != "" {
+		labels[LokiLabelRouter] = rec.Router
+	}
+
+	if host := hostname(); host != "" {
+		labels[LokiLabelHost] = host
+	}
+
+	return labels
+}
+
+// streams groups the records into streams per label set, the entries of a stream are sorted by the event time,
+// as loki rejects the entries out of order if unordered writes are disabled.
+func (d *LokiTransfer) streams(records []*record.Record) []*lokiStream {
+	var streams []*lokiStream
+
+	index := make(map[string]*lokiStream)
+
+	for _, rec := range records {
+		labels := d.labels(rec)
+		key := lokiLabelsString(labels)
+
+		stream, ok := index[key]
+		if !ok {
+			stream = &lokiStream{labels: labels}
+			index[key] = stream
+			streams = append(streams, stream)
+		}
+
+		stream.entries = append(stream.entries, rec)
+	}
+
+	for _, stream := range streams {
+		slices.SortStableFunc(stream.entries, func(a, b *record.Record) int {
+			return a.EventTime().Compare(b.EventTime())
+		})
+	}
+
+	return streams
+}
+
+// push pushes the records, and queues the push responded with 429 or server errors to retry in the background.
+// Other errors, e.g. 400 for the entries too old, are not retried, as the other entries have been accepted.
+func (d *LokiTransfer) push(records []*record.Record) error {
+	body, contentType, err := d.encode(d.streams(records))
+	if err != nil {
+		vlog.Errorf("loki transfer %s: %v", d.id, err)
+
+		return nil
+	}
+
+	retry := d.retry(len(records), body, contentType)
+
+	if remaining, retryAfter := retry(); remaining > 0 {
+		d.retries.add(remaining, retryAfter, retry)
+	}
+
+	return nil
+}
+
+// retry the push of the body, returns the records to retry if the push is retryable.
+func (d *LokiTransfer) retry(count int, body []byte, contentType string) retryFunc {
+	return func() (int, time.Duration) {
+		retryAfter, err := d.send(body, contentType)
+		if err == nil {
+			return 0, 0
+		}
+
+		if retryAfter < 0 {
+			vlog.Errorf("loki error: %v", err)
+
+			return 0, 0
+		}
+
+		vlog.Warnf("loki transfer %s: %v", d.id, err)
+
+		return count, retryAfter
+	}
+}
+
+// send posts the body, and returns the duration to wait for retrying if failed,
+// which is 0 if not told by the response, and negative if not retryable.
+func (d *LokiTransfer) send(body []byte, contentType string) (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	if d.opts.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", d.opts.TenantID)
+	}
+
+	if d.opts.Username != "" {
+		req.SetBasicAuth(d.opts.Username, d.opts.Password)
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	data, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode/100 == 2 { //nolint:mnd // 2xx
+		return 0, nil
+	}
+
+	err = fmt.Errorf("%w: %d %s", ErrLokiPush, res.StatusCode, bytes.TrimSpace(data))
+
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < http.StatusInternalServerError {
+		return -1, err
+	}
+
+	if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, err
+	}
+
+	return 0, err
+}
+
+func (d *LokiTransfer) encode(streams []*lokiStream) ([]byte, string, error) {
+	if d.opts.Encoding == LokiEncodingJSON {
+		data, err := encodeLokiJSON(streams)
+
+		return data, contentTypeJSON, err
+	}
+
+	return snappy.Encode(nil, encodeLokiProtobuf(streams)), contentTypeProtobuf, nil
+}
+
+// encodeLokiJSON encodes the streams in the JSON push request,
+// e.g. `{"streams":[{"stream":{"server":"app"},"values":[["1700000000000000000","line"]]}]}`.
+func encodeLokiJSON(streams []*lokiStream) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	request := struct {
+		Streams []*jsonStream `json:"streams"`
+	}{Streams: make([]*jsonStream, len(streams))}
+
+	for i, stream := range streams {
+		values := make([][2]string, len(stream.entries))
+		for j, rec := range stream.entries {
+			values[j] = [2]string{strconv.FormatInt(rec.EventTime().UnixNano(), 10), string(rec.Data)}
+		}
+
+		request.Streams[i] = &jsonStream{Stream: stream.labels, Values: values}
+	}
+
+	return json.Marshal(request)
+}
+
+// encodeLokiProtobuf encodes the streams in the protobuf push request of loki:
+//
+//	message PushRequest { repeated Stream streams = 1; }
+//	message Stream { string labels = 1; repeated Entry entries = 2; }
+//	message Entry { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func encodeLokiProtobuf(streams []*lokiStream) []byte {
+	var request []byte
+
+	for _, stream := range streams {
+		var streamData []byte
+
+		streamData = protowire.AppendTag(streamData, 1, protowire.BytesType)
+		streamData = protowire.AppendString(streamData, lokiLabelsString(stream.labels))
+
+		for _, rec := range stream.entries {
+			eventTime := rec.EventTime()
+
+			var timestamp []byte
+
+			timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+			timestamp = protowire.AppendVarint(timestamp, uint64(eventTime.Unix()))
+			timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+			timestamp = protowire.AppendVarint(timestamp, uint64(eventTime.Nanosecond()))
+
+			var entry []byte
+
+			entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, timestamp)
+			entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, rec.Data)
+
+			streamData = protowire.AppendTag(streamData, 2, protowire.BytesType)
+			streamData = protowire.AppendBytes(streamData, entry)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, streamData)
+	}
+
+	return request
+}
+
+// lokiLabelsString formats the labels in the sorted label set of loki, e.g. `{host="h", server="app"}`.
+func lokiLabelsString(labels map[string]string) string {
+	var sb strings.Builder
+
+	sb.WriteByte('{')
+
+	for i, name := range slices.Sorted(maps.Keys(labels)) {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[name]))
+	}
+
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+// NewLokiTransfer new loki trans pushing to the loki of the url,
+// the push path /loki/api/v1/push is appended to the url if absent.
+func NewLokiTransfer(id, url string, opts LokiTransferOptions) *LokiTransfer {
+	url = strings.TrimSuffix(url, "/")
+	if !strings.HasSuffix(url, lokiPushPath) {
+		url += lokiPushPath
+	}
+
+	t := &LokiTransfer{
+		id:   id,
+		url:  url,
+		opts: opts,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+	}
+
+	t.retries = newRetryQueue("loki transfer "+id, lokiMaxRetries, lokiRetryInterval)
+
+	batchSize := opts.BatchSize
+	if batchSize <= 1 {
+		batchSize = lokiDefaultBatchSize
+	}
+
+	batchTimeout := opts.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = lokiDefaultBatchTimeout
+	}
+
+	t.batcher = NewRecordBatcher(batchSize, batchTimeout, t.push)
+
+	return t
+}
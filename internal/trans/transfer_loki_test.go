@@ -0,0 +1,376 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type lokiEntry struct {
+	Time time.Time
+	Line string
+}
+
+type lokiPush struct {
+	Header  http.Header
+	Streams map[string][]lokiEntry // entries by the label string of the streams
+}
+
+type lokiServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	pushes   []*lokiPush
+	statuses func(push int) int // the status of the push, 204 if nil
+	wait     string             // the Retry-After of the failed pushes if not empty
+}
+
+func newLokiServer(t *testing.T) *lokiServer {
+	t.Helper()
+
+	s := &lokiServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var (
+			streams map[string][]lokiEntry
+			err     error
+		)
+
+		switch r.Header.Get("Content-Type") {
+		case "application/x-protobuf":
+			streams, err = decodeLokiProtobuf(body)
+		case "application/json":
+			streams, err = decodeLokiJSON(body)
+		default:
+			err = io.ErrUnexpectedEOF
+		}
+
+		if r.URL.Path != "/loki/api/v1/push" || err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		s.mu.Lock()
+		s.pushes = append(s.pushes, &lokiPush{Header: r.Header, Streams: streams})
+		push := len(s.pushes) - 1
+		s.mu.Unlock()
+
+		status := http.StatusNoContent
+		if s.statuses != nil {
+			status = s.statuses(push)
+		}
+
+		if status != http.StatusNoContent && s.wait != "" {
+			w.Header().Set("Retry-After", s.wait)
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return s
+}
+
+func (s *lokiServer) received() []*lokiPush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*lokiPush(nil), s.pushes...)
+}
+
+func decodeLokiJSON(body []byte) (map[string][]lokiEntry, error) {
+	request := struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}{}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	streams := map[string][]lokiEntry{}
+
+	for _, stream := range request.Streams {
+		labels, _ := json.Marshal(stream.Stream)
+
+		for _, value := range stream.Values {
+			nanos, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			streams[string(labels)] = append(streams[string(labels)], lokiEntry{Time: time.Unix(0, nanos), Line: value[1]})
+		}
+	}
+
+	return streams, nil
+}
+
+func decodeLokiProtobuf(body []byte) (map[string][]lokiEntry, error) {
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := map[string][]lokiEntry{}
+
+	return streams, consumeFields(data, func(_ protowire.Number, stream []byte, _ uint64) {
+		var (
+			labels  string
+			entries []lokiEntry
+		)
+
+		_ = consumeFields(stream, func(num protowire.Number, value []byte, _ uint64) {
+			if num == 1 {
+				labels = string(value)
+
+				return
+			}
+
+			var entry lokiEntry
+
+			_ = consumeFields(value, func(num protowire.Number, value []byte, _ uint64) {
+				if num == 2 {
+					entry.Line = string(value)
+
+					return
+				}
+
+				var seconds, nanos uint64
+
+				_ = consumeFields(value, func(num protowire.Number, _ []byte, varint uint64) {
+					if num == 1 {
+						seconds = varint
+					} else {
+						nanos = varint
+					}
+				})
+
+				entry.Time = time.Unix(int64(seconds), int64(nanos))
+			})
+
+			entries = append(entries, entry)
+		})
+
+		streams[labels] = append(streams[labels], entries...)
+	})
+}
+
+func lokiHostname(t *testing.T) string {
+	t.Helper()
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	return host
+}
+
+func newLokiRecord(source, router, data string, eventTime time.Time) *record.Record {
+	rec := record.New(source, "", []byte(data))
+	rec.Router = router
+	rec.Time = eventTime
+
+	return rec
+}
+
+func TestLokiTransferProtobuf(t *testing.T) {
+	t.Parallel()
+
+	server := newLokiServer(t)
+	defer server.Close()
+
+	lt := trans.NewLokiTransfer("loki", server.URL, trans.LokiTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 3, BatchTimeout: time.Hour},
+		Labels:              map[string]string{"env": "prod", "server": "overridden"},
+		TenantID:            "team-a",
+		Username:            "loki",
+		Password:            "secret",
+	})
+	assert.Equal(t, "loki", lt.Name())
+
+	now := time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC)
+
+	require.NoError(t, lt.Trans(
+		newLokiRecord("app", "error", "second", now.Add(time.Second)),
+		newLokiRecord("db", "", "other", now),
+		newLokiRecord("app", "error", "first", now),
+	))
+
+	pushes := server.received()
+	require.Len(t, pushes, 1)
+	assert.Equal(t, "team-a", pushes[0].Header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("loki:secret")),
+		pushes[0].Header.Get("Authorization"))
+
+	host := trans.LokiLabelHost + "=" + strconv.Quote(lokiHostname(t))
+
+	// streams per label set, the entries are sorted by the event time.
+	assert.Equal(t, map[string][]lokiEntry{
+		`{env="prod", ` + host + `, router="error", server="app"}`: {
+			{Time: now.Local(), Line: "first"},
+			{Time: now.Add(time.Second).Local(), Line: "second"},
+		},
+		`{env="prod", ` + host + `, server="db"}`: {{Time: now.Local(), Line: "other"}},
+	}, pushes[0].Streams)
+
+	require.NoError(t, lt.Stop())
+}
+
+func TestLokiTransferJSON(t *testing.T) {
+	t.Parallel()
+
+	server := newLokiServer(t)
+	defer server.Close()
+
+	lt := trans.NewLokiTransfer("loki-json", server.URL+"/loki/api/v1/push", trans.LokiTransferOptions{
+		Encoding: trans.LokiEncodingJSON,
+	})
+
+	now := time.Date(2024, 1, 15, 10, 30, 45, 1, time.UTC)
+
+	require.NoError(t, lt.Trans(newLokiRecord("app", "", "msg", now)))
+
+	// flushed when stopped.
+	require.NoError(t, lt.Stop())
+
+	pushes := server.received()
+	require.Len(t, pushes, 1)
+	assert.Empty(t, pushes[0].Header.Get("X-Scope-OrgID"))
+
+	labels, _ := json.Marshal(map[string]string{"host": lokiHostname(t), "server": "app"})
+	assert.Equal(t, map[string][]lokiEntry{string(labels): {{Time: now.Local(), Line: "msg"}}}, pushes[0].Streams)
+}
+
+func TestLokiTransferRetry(t *testing.T) {
+	t.Parallel()
+
+	server := newLokiServer(t)
+	defer server.Close()
+
+	server.statuses = func(push int) int {
+		switch push {
+		case 0:
+			return http.StatusTooManyRequests
+		case 1:
+			return http.StatusServiceUnavailable
+		default:
+			return http.StatusNoContent
+		}
+	}
+
+	lt := trans.NewLokiTransfer("loki-retry", server.URL, trans.LokiTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchTimeout: 50 * time.Millisecond},
+	})
+	defer func() { _ = lt.Stop() }()
+
+	require.NoError(t, lt.Trans(newLokiRecord("app", "", "msg", time.Now())))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// the same entries are pushed in the retries.
+	pushes := server.received()
+	assert.Equal(t, pushes[0].Streams, pushes[2].Streams)
+}
+
+func TestLokiTransferRetryInBackground(t *testing.T) {
+	t.Parallel()
+
+	server := newLokiServer(t)
+	defer server.Close()
+
+	server.statuses = func(int) int {
+		return http.StatusTooManyRequests
+	}
+	server.wait = "3600"
+
+	lt := trans.NewLokiTransfer("loki-retry-background", server.URL, trans.LokiTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 2},
+	})
+
+	// the batches are flushed without waiting for the Retry-After of the failed pushes.
+	start := time.Now()
+
+	for range 4 {
+		require.NoError(t, lt.Trans(newLokiRecord("app", "", "msg", time.Now())))
+	}
+
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Len(t, server.received(), 2)
+
+	// the pushes waiting for the retries are dropped when stopped.
+	start = time.Now()
+
+	require.NoError(t, lt.Stop())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestLokiTransferOutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	server := newLokiServer(t)
+	defer server.Close()
+
+	// loki responds 400 for the entries out of order or too old, while the other entries are accepted.
+	server.statuses = func(int) int { return http.StatusBadRequest }
+
+	lt := trans.NewLokiTransfer("loki-order", server.URL, trans.LokiTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchTimeout: 20 * time.Millisecond},
+	})
+	defer func() { _ = lt.Stop() }()
+
+	require.NoError(t, lt.Trans(newLokiRecord("app", "", "old", time.Now().Add(-time.Hour))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// not retried to avoid duplicating the accepted entries.
+	time.Sleep(700 * time.Millisecond)
+	assert.Len(t, server.received(), 1)
+}
+
+func TestIsLokiLabelName(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, trans.IsLokiLabelName("env"))
+	assert.True(t, trans.IsLokiLabelName("_app_1"))
+	assert.False(t, trans.IsLokiLabelName("1app"))
+	assert.False(t, trans.IsLokiLabelName("app-env"))
+	assert.False(t, trans.IsLokiLabelName(""))
+}
@@ -0,0 +1,500 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TypeOTLP transfer type of the OpenTelemetry OTLP/HTTP logs exporter.
+const TypeOTLP = "otlp"
+
+// encodings of otlp export requests.
+const (
+	OTLPEncodingProtobuf = "protobuf"
+	OTLPEncodingJSON     = "json"
+)
+
+// attributes of otlp log records.
+const (
+	OTLPAttributeServiceName = "service.name"
+	OTLPAttributeHostName    = "host.name"
+	OTLPAttributeRouter      = "logtail.router"
+)
+
+const (
+	// DefaultOTLPEndpoint the logs endpoint of a local collector.
+	DefaultOTLPEndpoint = "http://localhost:4318/v1/logs"
+
+	otlpLogsPath  = "/v1/logs"
+	otlpScopeName = "logtail"
+
+	// the defaults of the batch log record processor of opentelemetry sdks.
+	otlpDefaultBatchSize    = 512
+	otlpDefaultBatchTimeout = time.Second
+
+	// otlpMaxRetries the max retries in the background of an export responded with the retryable status.
+	otlpMaxRetries = 3
+
+	// otlpRetryInterval the interval before the first retry without Retry-After, doubled for each retry.
+	otlpRetryInterval = 500 * time.Millisecond
+)
+
+var ErrOTLPExport = errors.New("otlp export error")
+
+// OTLPTransferOptions holds parsed configuration for the otlp transfer.
+// BuildTransfer in internal/tail parses conf.TransferConfig into this struct.
+type OTLPTransferOptions struct {
+	HTTPTransferOptions
+
+	// Encoding protobuf or json, defaults to protobuf.
+	Encoding string
+}
+
+// OTLPSeverityNumber the severity number of the level of a record, 0 (unspecified) for the unknown level.
+func OTLPSeverityNumber(level string) int {
+	switch match.ParseLevel(level) {
+	case match.LevelTrace:
+		return 1 //nolint:mnd // SEVERITY_NUMBER_TRACE
+	case match.LevelDebug:
+		return 5 //nolint:mnd // SEVERITY_NUMBER_DEBUG
+	case match.LevelInfo:
+		return 9 //nolint:mnd // SEVERITY_NUMBER_INFO
+	case match.LevelWarn:
+		return 13 //nolint:mnd // SEVERITY_NUMBER_WARN
+	case match.LevelError:
+		return 17 //nolint:mnd // SEVERITY_NUMBER_ERROR
+	case match.LevelFatal:
+		return 21 //nolint:mnd // SEVERITY_NUMBER_FATAL
+	default:
+		return 0
+	}
+}
+
+// otlpKeyValue a string attribute of otlp.
+type otlpKeyValue struct {
+	key   string
+	value string
+}
+
+// otlpResourceLogs the log records of a resource, i.e. a server.
+type otlpResourceLogs struct {
+	attributes []otlpKeyValue
+	records    []*record.Record
+}
+
+// OTLPTransfer exports records as otlp log records over http, records are batched and exported
+// in the resource logs per server.
+type OTLPTransfer struct {
+	id       string
+	url      string
+	opts     OTLPTransferOptions
+	client   *http.Client
+	batcher  *Batcher
+	retries  *retryQueue
+	stopOnce sync.Once
+}
+
+func (d *OTLPTransfer) Name() string {
+	return d.id
+}
+
+func (d *OTLPTransfer) Start() error { return nil }
+
+// Stop exports the batched records, and drops the exports waiting for the retries.
+func (d *OTLPTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		d.batcher.Stop()
+		d.retries.stop()
+		closeHTTPClient(d.client)
+	})
+
+	return nil
+}
+
+// Trans adds the records to the batch.
+func (d *OTLPTransfer) Trans(records ...*record.Record) error {
+	for _, rec := range records {
+		d.batcher.AddRecord(rec)
+	}
+
+	return nil
+}
+
+// resourceLogs groups the records by the server, with the resource attributes of the service and host name.
+func (d *OTLPTransfer) resourceLogs(records []*record.Record) []*otlpResourceLogs {
+	var resources []*otlpResourceLogs
+
+	index := make(map[string]*otlpResourceLogs)
+
+	for _, rec := range records {
+		resource, ok := index[rec.Source]
+		if !ok {
+			attributes := []otlpKeyValue{{key: OTLPAttributeServiceName, value: rec.Source}}
+
+			if host := hostname(); host != "" {
+				attributes = append(attributes, otlpKeyValue{key: OTLPAttributeHostName, value: host})
+			}
+
+			resource = &otlpResourceLogs{attributes: attributes}
+			index[rec.Source] = resource
+			resources = append(resources, resource)
+		}
+
+		resource.records = append(resource.records, rec)
+	}
+
+	return resources
+}
+
+// export exports the records, and queues the export responded with the retryable status to retry
+// in the background.
+func (d *OTLPTransfer) export(records []*record.Record) error {
+	body, contentType, err := d.encode(d.resourceLogs(records))
+	if err != nil {
+		vlog.Errorf("otlp transfer %s: %v", d.id, err)
+
+		return nil
+	}
+
+	retry := d.retry(len(records), body, contentType)
+
+	if remaining, retryAfter := retry(); remaining > 0 {
+		d.retries.add(remaining, retryAfter, retry)
+	}
+
+	return nil
+}
+
+// retry the export of the body, returns the records to retry if the export is retryable.
+func (d *OTLPTransfer) retry(count int, body []byte, contentType string) retryFunc {
+	return func() (int, time.Duration) {
+		retryAfter, err := d.send(body, contentType)
+		if err == nil {
+			return 0, 0
+		}
+
+		if retryAfter < 0 {
+			vlog.Errorf("otlp error: %v", err)
+
+			return 0, 0
+		}
+
+		vlog.Warnf("otlp transfer %s: %v", d.id, err)
+
+		return count, retryAfter
+	}
+}
+
+// send posts the body, and returns the duration to wait for retrying if failed,
+// which is 0 if not told by the response, and negative if not retryable.
+// As the otlp specification, only 429, 502, 503 and 504 are retryable.
+func (d *OTLPTransfer) send(body []byte, contentType string) (time.Duration, error) {
+	res, err := d.client.Post(d.url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	data, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode/100 == 2 { //nolint:mnd // 2xx
+		return 0, nil
+	}
+
+	err = fmt.Errorf("%w: %d %s", ErrOTLPExport, res.StatusCode, bytes.TrimSpace(data))
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return -1, err
+	}
+
+	if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, err
+	}
+
+	return 0, err
+}
+
+func (d *OTLPTransfer) encode(resources []*otlpResourceLogs) ([]byte, string, error) {
+	if d.opts.Encoding == OTLPEncodingJSON {
+		data, err := encodeOTLPJSON(resources)
+
+		return data, contentTypeJSON, err
+	}
+
+	return encodeOTLPProtobuf(resources), contentTypeProtobuf, nil
+}
+
+// otlpTimeUnixNano the event time of the log record, 0 for unknown if not parsed from the log.
+func otlpTimeUnixNano(rec *record.Record) int64 {
+	if rec.Time.IsZero() {
+		return 0
+	}
+
+	return rec.Time.UnixNano()
+}
+
+// otlpLogAttributes the attributes of the log record, the router and the extracted fields sorted by the name.
+func otlpLogAttributes(rec *record.Record) []otlpKeyValue {
+	var attributes []otlpKeyValue
+
+	if rec.Router != "" {
+		attributes = append(attributes, otlpKeyValue{key: OTLPAttributeRouter, value: rec.Router})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(rec.Fields)) {
+		attributes = append(attributes, otlpKeyValue{key: name, value: rec.Fields[name]})
+	}
+
+	return attributes
+}
+
+// otlpJSONKeyValue the KeyValue of otlp/json with a string value.
+type otlpJSONKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpJSONAttributes(attributes []otlpKeyValue) []*otlpJSONKeyValue {
+	result := make([]*otlpJSONKeyValue, len(attributes))
+
+	for i, attribute := range attributes {
+		result[i] = &otlpJSONKeyValue{Key: attribute.key}
+		result[i].Value.StringValue = attribute.value
+	}
+
+	return result
+}
+
+// encodeOTLPJSON encodes the resource logs in the otlp/json ExportLogsServiceRequest,
+// of which the field names are lowerCamelCase and the 64 bits integers are strings.
+func encodeOTLPJSON(resources []*otlpResourceLogs) ([]byte, error) {
+	type jsonLogRecord struct {
+		TimeUnixNano         string `json:"timeUnixNano"`
+		ObservedTimeUnixNano string `json:"observedTimeUnixNano"`
+		SeverityNumber       int    `json:"severityNumber,omitempty"`
+		SeverityText         string `json:"severityText,omitempty"`
+		Body                 struct {
+			StringValue string `json:"stringValue"`
+		} `json:"body"`
+		Attributes []*otlpJSONKeyValue `json:"attributes,omitempty"`
+	}
+
+	type jsonScopeLogs struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		LogRecords []*jsonLogRecord `json:"logRecords"`
+	}
+
+	type jsonResourceLogs struct {
+		Resource struct {
+			Attributes []*otlpJSONKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []*jsonScopeLogs `json:"scopeLogs"`
+	}
+
+	request := struct {
+		ResourceLogs []*jsonResourceLogs `json:"resourceLogs"`
+	}{ResourceLogs: make([]*jsonResourceLogs, len(resources))}
+
+	for i, resource := range resources {
+		scope := &jsonScopeLogs{LogRecords: make([]*jsonLogRecord, len(resource.records))}
+		scope.Scope.Name = otlpScopeName
+
+		for j, rec := range resource.records {
+			logRecord := &jsonLogRecord{
+				TimeUnixNano:         strconv.FormatInt(otlpTimeUnixNano(rec), 10),
+				ObservedTimeUnixNano: strconv.FormatInt(rec.Arrival.UnixNano(), 10),
+				SeverityNumber:       OTLPSeverityNumber(rec.Level),
+				SeverityText:         strings.ToUpper(rec.Level),
+				Attributes:           otlpJSONAttributes(otlpLogAttributes(rec)),
+			}
+			logRecord.Body.StringValue = string(rec.Data)
+			scope.LogRecords[j] = logRecord
+		}
+
+		resourceLogs := &jsonResourceLogs{ScopeLogs: []*jsonScopeLogs{scope}}
+		resourceLogs.Resource.Attributes = otlpJSONAttributes(resource.attributes)
+		request.ResourceLogs[i] = resourceLogs
+	}
+
+	return json.Marshal(request)
+}
+
+// appendOTLPString appends the protobuf of a KeyValue or AnyValue field with a string value.
+func appendOTLPString(b []byte, num protowire.Number, value string) []byte {
+	var anyValue []byte
+
+	anyValue = protowire.AppendTag(anyValue, 1, protowire.BytesType) // AnyValue.string_value
+	anyValue = protowire.AppendString(anyValue, value)
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, anyValue)
+}
+
+func appendOTLPAttributes(b []byte, num protowire.Number, attributes []otlpKeyValue) []byte {
+	for _, attribute := range attributes {
+		var keyValue []byte
+
+		keyValue = protowire.AppendTag(keyValue, 1, protowire.BytesType)
+		keyValue = protowire.AppendString(keyValue, attribute.key)
+		keyValue = appendOTLPString(keyValue, 2, attribute.value) //nolint:mnd // KeyValue.value
+
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, keyValue)
+	}
+
+	return b
+}
+
+// encodeOTLPProtobuf encodes the resource logs in the protobuf ExportLogsServiceRequest of otlp:
+//
+//	message ExportLogsServiceRequest { repeated ResourceLogs resource_logs = 1; }
+//	message ResourceLogs { Resource resource = 1; repeated ScopeLogs scope_logs = 2; }
+//	message Resource { repeated KeyValue attributes = 1; }
+//	message ScopeLogs { InstrumentationScope scope = 1; repeated LogRecord log_records = 2; }
+//	message InstrumentationScope { string name = 1; }
+//	message LogRecord {
+//	  fixed64 time_unix_nano = 1; SeverityNumber severity_number = 2; string severity_text = 3;
+//	  AnyValue body = 5; repeated KeyValue attributes = 6; fixed64 observed_time_unix_nano = 11;
+//	}
+//
+//nolint:mnd // the field numbers
+func encodeOTLPProtobuf(resources []*otlpResourceLogs) []byte {
+	var request []byte
+
+	for _, resource := range resources {
+		var scope []byte
+
+		scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+		scope = protowire.AppendString(scope, otlpScopeName)
+
+		var scopeLogs []byte
+
+		scopeLogs = protowire.AppendTag(scopeLogs, 1, protowire.BytesType)
+		scopeLogs = protowire.AppendBytes(scopeLogs, scope)
+
+		for _, rec := range resource.records {
+			var logRecord []byte
+
+			if nanos := otlpTimeUnixNano(rec); nanos != 0 {
+				logRecord = protowire.AppendTag(logRecord, 1, protowire.Fixed64Type)
+				logRecord = protowire.AppendFixed64(logRecord, uint64(nanos))
+			}
+
+			if severity := OTLPSeverityNumber(rec.Level); severity != 0 {
+				logRecord = protowire.AppendTag(logRecord, 2, protowire.VarintType)
+				logRecord = protowire.AppendVarint(logRecord, uint64(severity))
+			}
+
+			if rec.Level != "" {
+				logRecord = protowire.AppendTag(logRecord, 3, protowire.BytesType)
+				logRecord = protowire.AppendString(logRecord, strings.ToUpper(rec.Level))
+			}
+
+			logRecord = appendOTLPString(logRecord, 5, string(rec.Data))
+			logRecord = appendOTLPAttributes(logRecord, 6, otlpLogAttributes(rec))
+			logRecord = protowire.AppendTag(logRecord, 11, protowire.Fixed64Type)
+			logRecord = protowire.AppendFixed64(logRecord, uint64(rec.Arrival.UnixNano()))
+
+			scopeLogs = protowire.AppendTag(scopeLogs, 2, protowire.BytesType)
+			scopeLogs = protowire.AppendBytes(scopeLogs, logRecord)
+		}
+
+		var resourceLogs []byte
+
+		resourceLogs = protowire.AppendTag(resourceLogs, 1, protowire.BytesType)
+		resourceLogs = protowire.AppendBytes(resourceLogs, appendOTLPAttributes(nil, 1, resource.attributes))
+		resourceLogs = protowire.AppendTag(resourceLogs, 2, protowire.BytesType)
+		resourceLogs = protowire.AppendBytes(resourceLogs, scopeLogs)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, resourceLogs)
+	}
+
+	return request
+}
+
+// OTLPEndpoint the logs endpoint of the url, the path /v1/logs is appended to the url without a path,
+// and DefaultOTLPEndpoint is used if the url is empty.
+func OTLPEndpoint(endpoint string) string {
+	if endpoint == "" {
+		return DefaultOTLPEndpoint
+	}
+
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		u.Path = otlpLogsPath
+
+		return u.String()
+	}
+
+	return endpoint
+}
+
+// NewOTLPTransfer new otlp trans exporting to the logs endpoint of the url, see OTLPEndpoint.
+func NewOTLPTransfer(id, endpoint string, opts OTLPTransferOptions) *OTLPTransfer {
+	t := &OTLPTransfer{
+		id:   id,
+		url:  OTLPEndpoint(endpoint),
+		opts: opts,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+	}
+
+	t.retries = newRetryQueue("otlp transfer "+id, otlpMaxRetries, otlpRetryInterval)
+
+	batchSize := opts.BatchSize
+	if batchSize <= 1 {
+		batchSize = otlpDefaultBatchSize
+	}
+
+	batchTimeout := opts.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = otlpDefaultBatchTimeout
+	}
+
+	t.batcher = NewRecordBatcher(batchSize, batchTimeout, t.export)
+
+	return t
+}
@@ -0,0 +1,473 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type otlpLogRecord struct {
+	Time           time.Time
+	ObservedTime   time.Time
+	SeverityNumber int
+	SeverityText   string
+	Body           string
+	Attributes     map[string]string
+}
+
+type otlpResourceLogs struct {
+	Attributes map[string]string
+	Scope      string
+	Records    []*otlpLogRecord
+}
+
+type otlpServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	exports  [][]*otlpResourceLogs
+	statuses func(export int) int // the status of the export, 200 if nil
+	wait     string               // the Retry-After of the failed exports if not empty
+}
+
+func newOTLPServer(t *testing.T) *otlpServer {
+	t.Helper()
+
+	s := &otlpServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var (
+			resources []*otlpResourceLogs
+			err       error
+		)
+
+		switch r.Header.Get("Content-Type") {
+		case "application/x-protobuf":
+			resources, err = decodeOTLPProtobuf(body)
+		case "application/json":
+			resources, err = decodeOTLPJSON(body)
+		default:
+			err = io.ErrUnexpectedEOF
+		}
+
+		if r.URL.Path != "/v1/logs" || err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		s.mu.Lock()
+		s.exports = append(s.exports, resources)
+		export := len(s.exports) - 1
+		s.mu.Unlock()
+
+		status := http.StatusOK
+		if s.statuses != nil {
+			status = s.statuses(export)
+		}
+
+		if status != http.StatusOK && s.wait != "" {
+			w.Header().Set("Retry-After", s.wait)
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return s
+}
+
+func (s *otlpServer) received() [][]*otlpResourceLogs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]*otlpResourceLogs(nil), s.exports...)
+}
+
+// otlpTime the time of the unix nanos, 0 is the unset time.
+func otlpTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+func decodeOTLPJSON(body []byte) ([]*otlpResourceLogs, error) {
+	type keyValue struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+
+	attributes := func(keyValues []keyValue) map[string]string {
+		if len(keyValues) == 0 {
+			return nil
+		}
+
+		result := map[string]string{}
+		for _, kv := range keyValues {
+			result[kv.Key] = kv.Value.StringValue
+		}
+
+		return result
+	}
+
+	unixNano := func(s string) time.Time {
+		nanos, _ := strconv.ParseInt(s, 10, 64)
+
+		return otlpTime(nanos)
+	}
+
+	request := struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []keyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				LogRecords []struct {
+					TimeUnixNano         string `json:"timeUnixNano"`
+					ObservedTimeUnixNano string `json:"observedTimeUnixNano"`
+					SeverityNumber       int    `json:"severityNumber"`
+					SeverityText         string `json:"severityText"`
+					Body                 struct {
+						StringValue string `json:"stringValue"`
+					} `json:"body"`
+					Attributes []keyValue `json:"attributes"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}{}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	var resources []*otlpResourceLogs
+
+	for _, resourceLogs := range request.ResourceLogs {
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			resource := &otlpResourceLogs{
+				Attributes: attributes(resourceLogs.Resource.Attributes),
+				Scope:      scopeLogs.Scope.Name,
+			}
+
+			for _, logRecord := range scopeLogs.LogRecords {
+				resource.Records = append(resource.Records, &otlpLogRecord{
+					Time:           unixNano(logRecord.TimeUnixNano),
+					ObservedTime:   unixNano(logRecord.ObservedTimeUnixNano),
+					SeverityNumber: logRecord.SeverityNumber,
+					SeverityText:   logRecord.SeverityText,
+					Body:           logRecord.Body.StringValue,
+					Attributes:     attributes(logRecord.Attributes),
+				})
+			}
+
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// consumeOTLPAnyString consumes the string value of an AnyValue.
+func consumeOTLPAnyString(data []byte) string {
+	var value string
+
+	_ = consumeFields(data, func(_ protowire.Number, b []byte, _ uint64) { value = string(b) })
+
+	return value
+}
+
+// consumeOTLPKeyValue consumes a KeyValue of a string value into the attributes.
+func consumeOTLPKeyValue(data []byte, attributes map[string]string) {
+	var key, value string
+
+	_ = consumeFields(data, func(num protowire.Number, b []byte, _ uint64) {
+		if num == 1 {
+			key = string(b)
+		} else {
+			value = consumeOTLPAnyString(b)
+		}
+	})
+
+	attributes[key] = value
+}
+
+func decodeOTLPLogRecord(data []byte) *otlpLogRecord {
+	logRecord := &otlpLogRecord{Attributes: map[string]string{}}
+
+	_ = consumeFields(data, func(num protowire.Number, b []byte, varint uint64) {
+		switch num {
+		case 1:
+			logRecord.Time = otlpTime(int64(varint))
+		case 2:
+			logRecord.SeverityNumber = int(varint)
+		case 3:
+			logRecord.SeverityText = string(b)
+		case 5:
+			logRecord.Body = consumeOTLPAnyString(b)
+		case 6:
+			consumeOTLPKeyValue(b, logRecord.Attributes)
+		case 11:
+			logRecord.ObservedTime = time.Unix(0, int64(varint))
+		}
+	})
+
+	return logRecord
+}
+
+func decodeOTLPProtobuf(body []byte) ([]*otlpResourceLogs, error) {
+	var resources []*otlpResourceLogs
+
+	return resources, consumeFields(body, func(_ protowire.Number, resourceLogs []byte, _ uint64) {
+		attributes := map[string]string{}
+
+		_ = consumeFields(resourceLogs, func(num protowire.Number, b []byte, _ uint64) {
+			if num == 1 {
+				_ = consumeFields(b, func(_ protowire.Number, keyValue []byte, _ uint64) {
+					consumeOTLPKeyValue(keyValue, attributes)
+				})
+
+				return
+			}
+
+			resource := &otlpResourceLogs{Attributes: attributes}
+
+			_ = consumeFields(b, func(num protowire.Number, b []byte, _ uint64) {
+				if num == 1 {
+					resource.Scope = consumeOTLPAnyString(b)
+
+					return
+				}
+
+				logRecord := decodeOTLPLogRecord(b)
+				if len(logRecord.Attributes) == 0 {
+					logRecord.Attributes = nil
+				}
+
+				resource.Records = append(resource.Records, logRecord)
+			})
+
+			resources = append(resources, resource)
+		})
+	})
+}
+
+func newOTLPRecords() []*record.Record {
+	eventTime := time.Date(2024, 1, 15, 10, 30, 45, 123456789, time.UTC)
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Router = "alert"
+	rec.Level = "error"
+	rec.Time = eventTime
+	rec.Fields = map[string]string{"trace_id": "t-1", "order": "o-1"}
+
+	return []*record.Record{
+		rec,
+		record.New("db", "", []byte("slow query")),
+		record.New("app", "", []byte("retrying")),
+	}
+}
+
+func assertOTLPExport(t *testing.T, records []*record.Record, resources []*otlpResourceLogs) {
+	t.Helper()
+
+	// resource logs per server, with the service and host name.
+	require.Len(t, resources, 2)
+	assert.Equal(t, map[string]string{"service.name": "app", "host.name": lokiHostname(t)}, resources[0].Attributes)
+	assert.Equal(t, map[string]string{"service.name": "db", "host.name": lokiHostname(t)}, resources[1].Attributes)
+	assert.Equal(t, "logtail", resources[0].Scope)
+	require.Len(t, resources[0].Records, 2)
+	require.Len(t, resources[1].Records, 1)
+
+	assert.Equal(t, &otlpLogRecord{
+		Time:           records[0].Time.Local(),
+		ObservedTime:   time.Unix(0, records[0].Arrival.UnixNano()),
+		SeverityNumber: 17,
+		SeverityText:   "ERROR",
+		Body:           "ERROR failed",
+		Attributes:     map[string]string{"logtail.router": "alert", "trace_id": "t-1", "order": "o-1"},
+	}, resources[0].Records[0])
+
+	// the event time is unset if not parsed, and the severity is unspecified for the unknown level.
+	assert.Equal(t, &otlpLogRecord{
+		ObservedTime: time.Unix(0, records[2].Arrival.UnixNano()),
+		Body:         "retrying",
+	}, resources[0].Records[1])
+	assert.Equal(t, "slow query", resources[1].Records[0].Body)
+}
+
+func TestOTLPTransferProtobuf(t *testing.T) {
+	t.Parallel()
+
+	server := newOTLPServer(t)
+	defer server.Close()
+
+	ot := trans.NewOTLPTransfer("otlp", server.URL, trans.OTLPTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 3, BatchTimeout: time.Hour},
+	})
+	assert.Equal(t, "otlp", ot.Name())
+
+	records := newOTLPRecords()
+
+	require.NoError(t, ot.Trans(records[:2]...))
+	assert.Empty(t, server.received())
+
+	// exported when reaching the batch size.
+	require.NoError(t, ot.Trans(records[2]))
+
+	exports := server.received()
+	require.Len(t, exports, 1)
+	assertOTLPExport(t, records, exports[0])
+
+	require.NoError(t, ot.Stop())
+}
+
+func TestOTLPTransferJSON(t *testing.T) {
+	t.Parallel()
+
+	server := newOTLPServer(t)
+	defer server.Close()
+
+	ot := trans.NewOTLPTransfer("otlp-json", server.URL+"/v1/logs", trans.OTLPTransferOptions{
+		Encoding: trans.OTLPEncodingJSON,
+	})
+
+	records := newOTLPRecords()
+
+	require.NoError(t, ot.Trans(records...))
+
+	// exported when stopped.
+	require.NoError(t, ot.Stop())
+
+	exports := server.received()
+	require.Len(t, exports, 1)
+	assertOTLPExport(t, records, exports[0])
+}
+
+func TestOTLPTransferRetry(t *testing.T) {
+	t.Parallel()
+
+	server := newOTLPServer(t)
+	defer server.Close()
+
+	server.statuses = func(export int) int {
+		switch export {
+		case 0:
+			return http.StatusServiceUnavailable
+		case 1:
+			return http.StatusTooManyRequests
+		case 2:
+			return http.StatusOK
+		default:
+			// not retryable.
+			return http.StatusBadRequest
+		}
+	}
+
+	ot := trans.NewOTLPTransfer("otlp-retry", server.URL, trans.OTLPTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchTimeout: 20 * time.Millisecond},
+	})
+	defer func() { _ = ot.Stop() }()
+
+	require.NoError(t, ot.Trans(record.New("app", "", []byte("msg"))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, ot.Trans(record.New("app", "", []byte("bad"))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 4
+	}, 2*time.Second, 10*time.Millisecond)
+
+	time.Sleep(700 * time.Millisecond)
+	assert.Len(t, server.received(), 4)
+}
+
+func TestOTLPTransferRetryInBackground(t *testing.T) {
+	t.Parallel()
+
+	server := newOTLPServer(t)
+	defer server.Close()
+
+	server.statuses = func(int) int {
+		return http.StatusServiceUnavailable
+	}
+	server.wait = "3600"
+
+	ot := trans.NewOTLPTransfer("otlp-retry-background", server.URL, trans.OTLPTransferOptions{
+		HTTPTransferOptions: trans.HTTPTransferOptions{BatchSize: 2},
+	})
+
+	// the batches are flushed without waiting for the Retry-After of the failed exports.
+	start := time.Now()
+
+	for range 4 {
+		require.NoError(t, ot.Trans(record.New("app", "", []byte("msg"))))
+	}
+
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Len(t, server.received(), 2)
+
+	// the exports waiting for the retries are dropped when stopped.
+	start = time.Now()
+
+	require.NoError(t, ot.Stop())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestOTLPEndpoint(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, trans.DefaultOTLPEndpoint, trans.OTLPEndpoint(""))
+	assert.Equal(t, "http://collector:4318/v1/logs", trans.OTLPEndpoint("http://collector:4318"))
+	assert.Equal(t, "http://collector:4318/v1/logs", trans.OTLPEndpoint("http://collector:4318/"))
+	assert.Equal(t, "https://otlp.example.com/otlp/v1/logs", trans.OTLPEndpoint("https://otlp.example.com/otlp/v1/logs"))
+}
+
+func TestOTLPSeverityNumber(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, trans.OTLPSeverityNumber(""))
+	assert.Equal(t, 1, trans.OTLPSeverityNumber("trace"))
+	assert.Equal(t, 5, trans.OTLPSeverityNumber("debug"))
+	assert.Equal(t, 9, trans.OTLPSeverityNumber("info"))
+	assert.Equal(t, 13, trans.OTLPSeverityNumber("warn"))
+	assert.Equal(t, 17, trans.OTLPSeverityNumber("error"))
+	assert.Equal(t, 21, trans.OTLPSeverityNumber("fatal"))
+}
@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/vogo/logtail/internal/consts"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeSlack transfer type slack.
+const TypeSlack = "slack"
+
+const (
+	slackHeaderMaxLength  = 150
+	slackSectionMaxLength = 3000
+	slackTruncatedMarker  = "\n...[truncated]"
+	slackCodeBlockStart   = "```\n"
+	slackCodeBlockEnd     = "\n```"
+)
+
+//nolint:gochecknoglobals // ignore this
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "```", "`\u200b`\u200b`")
+
+// SlackMessage the Block Kit message of Slack incoming webhooks.
+type SlackMessage struct {
+	Channel  string        `json:"channel,omitempty"`
+	Username string        `json:"username,omitempty"`
+	Text     string        `json:"text"`
+	Blocks   []*SlackBlock `json:"blocks"`
+}
+
+// SlackBlock a Block Kit block, text for header and section blocks, elements for context blocks.
+type SlackBlock struct {
+	Type     string       `json:"type"`
+	Text     *SlackText   `json:"text,omitempty"`
+	Elements []*SlackText `json:"elements,omitempty"`
+}
+
+// SlackText a Block Kit text object.
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackTransfer posts records to Slack incoming webhooks.
+type SlackTransfer struct {
+	id       string
+	url      string
+	prefix   string
+	channel  string
+	username string
+	template *MessageTemplate // nil for the code block of the records
+	client   *http.Client
+	limiter  *rateLimiter // nil when rate limiting disabled
+	batcher  *Batcher     // nil when batch_size <= 1
+}
+
+func (d *SlackTransfer) Name() string {
+	return d.id
+}
+
+func (d *SlackTransfer) Start() error { return nil }
+
+func (d *SlackTransfer) Stop() error {
+	if d.batcher != nil {
+		d.batcher.Stop()
+	}
+
+	if d.limiter != nil {
+		d.limiter.Stop()
+	}
+
+	closeHTTPClient(d.client)
+
+	return nil
+}
+
+// Trans posts the successive records of a source in a message, or adds them to the batch.
+func (d *SlackTransfer) Trans(records ...*record.Record) error {
+	if d.batcher != nil {
+		for _, rec := range records {
+			d.batcher.AddRecord(rec)
+		}
+
+		return nil
+	}
+
+	return eachSource(records, func(_ string, group []*record.Record) error {
+		return d.post(group)
+	})
+}
+
+func (d *SlackTransfer) post(records []*record.Record) error {
+	if d.limiter != nil && !d.limiter.Allow() {
+		vlog.Warnf("slack transfer %s: rate limit exceeded, dropping message", d.id)
+
+		return nil
+	}
+
+	message, err := d.message(records)
+	if err != nil {
+		vlog.Errorf("slack transfer %s: %v", d.id, err)
+
+		return nil
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if err = httpTransWithClient(d.client, d.url, data); err != nil {
+		vlog.Errorf("slack error: %v", err)
+	}
+
+	return nil
+}
+
+// message builds the message of the records, with a header of the source and router,
+// a section of the records in a code block or rendered by the template, and a context of the metadata.
+func (d *SlackTransfer) message(records []*record.Record) (*SlackMessage, error) {
+	first := records[0]
+
+	title := d.prefix + first.Source
+	if first.Router != "" {
+		title += " / " + first.Router
+	}
+
+	header, _ := TruncateUTF8([]byte(title), slackHeaderMaxLength)
+
+	var section string
+
+	if d.template != nil {
+		content, err := d.template.Render(records)
+		if err != nil {
+			return nil, err
+		}
+
+		section = limitSlackText(content, 0)
+	} else {
+		content := slackEscaper.Replace(string(bytes.Join(record.Datas(records), []byte("\n"))))
+		section = slackCodeBlockStart +
+			limitSlackText([]byte(content), len(slackCodeBlockStart)+len(slackCodeBlockEnd)) +
+			slackCodeBlockEnd
+	}
+
+	context := []string{first.EventTime().Format(consts.FormatDateTime)}
+	if host := hostname(); host != "" {
+		context = append(context, host)
+	}
+
+	if first.Level != "" {
+		context = append(context, first.Level)
+	}
+
+	return &SlackMessage{
+		Channel:  d.channel,
+		Username: d.username,
+		Text:     title,
+		Blocks: []*SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: string(header)}},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: section}},
+			{Type: "context", Elements: []*SlackText{{Type: "mrkdwn", Text: strings.Join(context, " | ")}}},
+		},
+	}, nil
+}
+
+// limitSlackText limits the text to the max length of section blocks, leaving the reserved bytes.
+func limitSlackText(text []byte, reserved int) string {
+	limit := slackSectionMaxLength - reserved
+
+	if len(text) <= limit {
+		return string(text)
+	}
+
+	limited, _ := TruncateUTF8(text, limit-len(slackTruncatedMarker))
+
+	return string(limited) + slackTruncatedMarker
+}
+
+// NewSlackTransfer new slack trans, the channel and username override the defaults of the webhook if not empty.
+func NewSlackTransfer(id, url, prefix string, opts HTTPTransferOptions) *SlackTransfer {
+	t := &SlackTransfer{
+		id:       id,
+		url:      url,
+		prefix:   prefix,
+		channel:  opts.Channel,
+		username: opts.Username,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+	}
+
+	if t.prefix == "" {
+		t.prefix = DefaultTransferPrefix
+	}
+
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, t.prefix)
+	}
+
+	if opts.RateLimit > 0 {
+		t.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+
+	if opts.BatchSize > 1 {
+		t.batcher = NewRecordBatcher(opts.BatchSize, opts.BatchTimeout, t.post)
+	}
+
+	return t
+}
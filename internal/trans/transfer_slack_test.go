@@ -0,0 +1,176 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+func newSlackServer(t *testing.T) (*httptest.Server, chan *trans.SlackMessage) {
+	t.Helper()
+
+	messages := make(chan *trans.SlackMessage, 16)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		msg := &trans.SlackMessage{}
+		if err := json.Unmarshal(body, msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		messages <- msg
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	return server, messages
+}
+
+func TestSlackTransfer(t *testing.T) {
+	t.Parallel()
+
+	server, messages := newSlackServer(t)
+	defer server.Close()
+
+	st := trans.NewSlackTransfer("slack", server.URL, "", trans.HTTPTransferOptions{
+		Channel:  "#alerts",
+		Username: "logtail-bot",
+	})
+	defer func() { _ = st.Stop() }()
+
+	assert.Equal(t, "slack", st.Name())
+
+	rec := record.New("app", "", []byte("ERROR a < b && ```c```"))
+	rec.Router = "error-router"
+	rec.Level = "error"
+	rec.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local)
+
+	require.NoError(t, st.Trans(rec, record.New("app", "", []byte("\tat Main.run"))))
+
+	msg := <-messages
+	assert.Equal(t, "#alerts", msg.Channel)
+	assert.Equal(t, "logtail-bot", msg.Username)
+	assert.Equal(t, "logtail-app / error-router", msg.Text)
+	require.Len(t, msg.Blocks, 3)
+
+	assert.Equal(t, "header", msg.Blocks[0].Type)
+	assert.Equal(t, "logtail-app / error-router", msg.Blocks[0].Text.Text)
+
+	assert.Equal(t, "section", msg.Blocks[1].Type)
+	assert.Equal(t, "mrkdwn", msg.Blocks[1].Text.Type)
+	assert.Equal(t, "```\nERROR a &lt; b &amp;&amp; `\u200b`\u200b`c`\u200b`\u200b`\n\tat Main.run\n```",
+		msg.Blocks[1].Text.Text)
+
+	assert.Equal(t, "context", msg.Blocks[2].Type)
+	assert.True(t, strings.HasPrefix(msg.Blocks[2].Elements[0].Text, "2024-01-15 10:30:45 | "))
+	assert.True(t, strings.HasSuffix(msg.Blocks[2].Elements[0].Text, " | error"))
+}
+
+func TestSlackTransferTruncate(t *testing.T) {
+	t.Parallel()
+
+	server, messages := newSlackServer(t)
+	defer server.Close()
+
+	st := trans.NewSlackTransfer("slack-truncate", server.URL, "", trans.HTTPTransferOptions{})
+	defer func() { _ = st.Stop() }()
+
+	require.NoError(t, st.Trans(record.New(strings.Repeat("s", 200), "", []byte(strings.Repeat("日志", 1000)))))
+
+	msg := <-messages
+	assert.Len(t, msg.Blocks[0].Text.Text, 150)
+
+	section := msg.Blocks[1].Text.Text
+	assert.LessOrEqual(t, len(section), 3000)
+	assert.True(t, strings.HasSuffix(section, "...[truncated]\n```"))
+	assert.NotContains(t, section, "�")
+}
+
+func TestSlackTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	server, messages := newSlackServer(t)
+	defer server.Close()
+
+	st := trans.NewSlackTransfer("slack-template", server.URL, "", trans.HTTPTransferOptions{
+		Template: "*{{.Count}} errors* of `{{.Source}}`",
+	})
+	defer func() { _ = st.Stop() }()
+
+	require.NoError(t, st.Trans(record.New("app", "", []byte("ERROR"))))
+
+	msg := <-messages
+	assert.Empty(t, msg.Channel)
+	assert.Equal(t, "*1 errors* of `app`", msg.Blocks[1].Text.Text)
+}
+
+func TestSlackTransferBatching(t *testing.T) {
+	t.Parallel()
+
+	server, messages := newSlackServer(t)
+	defer server.Close()
+
+	st := trans.NewSlackTransfer("slack-batch", server.URL, "", trans.HTTPTransferOptions{
+		BatchSize:    3,
+		BatchTimeout: 10 * time.Second,
+	})
+
+	for _, msg := range []string{"msg1", "msg2", "msg3", "msg4"} {
+		require.NoError(t, st.Trans(record.New("app", "", []byte(msg))))
+	}
+
+	assert.Equal(t, "```\nmsg1\nmsg2\nmsg3\n```", (<-messages).Blocks[1].Text.Text)
+
+	// the rest are flushed on stop.
+	require.NoError(t, st.Stop())
+	assert.Equal(t, "```\nmsg4\n```", (<-messages).Blocks[1].Text.Text)
+}
+
+func TestSlackTransferRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server, messages := newSlackServer(t)
+	defer server.Close()
+
+	st := trans.NewSlackTransfer("slack-rl", server.URL, "", trans.HTTPTransferOptions{
+		RateLimit: 0.1,
+		RateBurst: 1,
+	})
+	defer func() { _ = st.Stop() }()
+
+	for range 3 {
+		require.NoError(t, st.Trans(record.New("app", "", []byte("msg"))))
+	}
+
+	// only the burst goes through, the others are dropped.
+	assert.Len(t, messages, 1)
+}
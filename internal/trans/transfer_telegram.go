@@ -0,0 +1,437 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vio"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeTelegram transfer type of Telegram bots.
+const TypeTelegram = "telegram"
+
+// parse modes of Telegram messages, empty for plain text.
+const (
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+	TelegramParseModeHTML       = "HTML"
+)
+
+const (
+	// DefaultTelegramAPIURL the default base url of the Telegram Bot API.
+	DefaultTelegramAPIURL = "https://api.telegram.org"
+
+	// telegramTextMaxLength the max length of message texts in UTF-16 code units, after the entities parsed.
+	telegramTextMaxLength = 4096
+
+	// telegramMaxParts the max messages the records are split into, the rest are truncated.
+	telegramMaxParts = 4
+
+	// telegramMaxRetries the max retries of a message after responded with 429 Too Many Requests.
+	telegramMaxRetries = 3
+
+	// telegramMaxPending the max messages waiting to send, the oldest ones are dropped if exceeded.
+	telegramMaxPending = 1024
+
+	telegramTruncatedMarker = "\n...[truncated]"
+)
+
+var ErrTelegramResponse = errors.New("telegram response error")
+
+//nolint:gochecknoglobals // ignore this
+var (
+	// telegramMarkdownEscaper escapes the special characters of MarkdownV2.
+	telegramMarkdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+
+	// telegramCodeEscaper escapes the characters in MarkdownV2 pre and code entities.
+	telegramCodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+)
+
+// EscapeTelegramMarkdown escapes the special characters of Telegram MarkdownV2.
+func EscapeTelegramMarkdown(text string) string {
+	return telegramMarkdownEscaper.Replace(text)
+}
+
+// TelegramMessage the request of the Telegram Bot API sendMessage.
+type TelegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// TelegramTransfer sends records to a Telegram chat by the sendMessage method of the Bot API.
+// Messages are queued and sent in the background, so that waiting for the retries never blocks the routers.
+type TelegramTransfer struct {
+	id        string
+	url       string // the url of the sendMessage method, including the bot token
+	prefix    string
+	chatID    string
+	parseMode string
+	template  *MessageTemplate // nil for the default text
+	client    *http.Client
+	limiter   *rateLimiter // nil when rate limiting disabled
+	batcher   *Batcher     // nil when batch_size <= 1
+
+	mu      sync.Mutex
+	pending []string
+	dropped int // the count of the messages dropped since the last sending
+
+	notify   chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func (d *TelegramTransfer) Name() string {
+	return d.id
+}
+
+func (d *TelegramTransfer) Start() error { return nil }
+
+// Stop flushes the batch, and sends the pending messages without waiting for the retries.
+func (d *TelegramTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		if d.batcher != nil {
+			d.batcher.Stop()
+		}
+
+		close(d.done)
+		<-d.exited
+
+		if d.limiter != nil {
+			d.limiter.Stop()
+		}
+
+		closeHTTPClient(d.client)
+	})
+
+	return nil
+}
+
+// Trans sends the successive records of a source in messages, or adds them to the batch.
+func (d *TelegramTransfer) Trans(records ...*record.Record) error {
+	if d.batcher != nil {
+		for _, rec := range records {
+			d.batcher.AddRecord(rec)
+		}
+
+		return nil
+	}
+
+	return eachSource(records, func(_ string, group []*record.Record) error {
+		return d.post(group)
+	})
+}
+
+// post queues the messages of the records, the records are dropped if the rate limit exceeded.
+func (d *TelegramTransfer) post(records []*record.Record) error {
+	if d.limiter != nil && !d.limiter.Allow() {
+		vlog.Warnf("telegram transfer %s: rate limit exceeded, dropping message", d.id)
+
+		return nil
+	}
+
+	texts, err := d.texts(records)
+	if err != nil {
+		vlog.Errorf("telegram transfer %s: %v", d.id, err)
+
+		return nil
+	}
+
+	d.mu.Lock()
+
+	d.pending = append(d.pending, texts...)
+
+	if exceeded := len(d.pending) - telegramMaxPending; exceeded > 0 {
+		d.pending = d.pending[exceeded:]
+		d.dropped += exceeded
+	}
+
+	d.mu.Unlock()
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// loop sends the pending messages, until stopped and the pending ones are sent.
+func (d *TelegramTransfer) loop() {
+	defer close(d.exited)
+
+	for {
+		select {
+		case <-d.done:
+			d.sendPending()
+
+			return
+		case <-d.notify:
+			d.sendPending()
+		}
+	}
+}
+
+func (d *TelegramTransfer) sendPending() {
+	for {
+		text, ok := d.next()
+		if !ok {
+			return
+		}
+
+		if err := d.send(text); err != nil {
+			vlog.Errorf("telegram error: %v", err)
+		}
+	}
+}
+
+// next takes the next pending message.
+func (d *TelegramTransfer) next() (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dropped > 0 {
+		vlog.Errorf("telegram transfer %s: %d messages dropped for the queue full", d.id, d.dropped)
+		d.dropped = 0
+	}
+
+	if len(d.pending) == 0 {
+		return "", false
+	}
+
+	text := d.pending[0]
+	d.pending = d.pending[1:]
+
+	return text, true
+}
+
+// texts builds the texts of the messages of the records.
+// The default text is split into messages, the text rendered by the template is truncated,
+// as the formatting entities of which may be broken by splitting.
+func (d *TelegramTransfer) texts(records []*record.Record) ([]string, error) {
+	if d.template != nil {
+		text, err := d.template.Render(records)
+		if err != nil {
+			return nil, err
+		}
+
+		// the bytes of a text are never less than the UTF-16 code units.
+		truncated, _ := TruncateUTF8(text, telegramTextMaxLength)
+
+		return []string{string(truncated)}, nil
+	}
+
+	first := records[0]
+
+	title := d.prefix + first.Source
+	if first.Router != "" {
+		title += " / " + first.Router
+	}
+
+	data := string(bytes.Join(record.Datas(records), []byte("\n")))
+	parts := splitTelegramText(data, telegramTextMaxLength-utf16Length(title)-1, telegramMaxParts)
+	texts := make([]string, len(parts))
+
+	for i, part := range parts {
+		switch d.parseMode {
+		case TelegramParseModeMarkdownV2:
+			texts[i] = "*" + EscapeTelegramMarkdown(title) + "*\n```\n" + telegramCodeEscaper.Replace(part) + "\n```"
+		case TelegramParseModeHTML:
+			texts[i] = "<b>" + html.EscapeString(title) + "</b>\n<pre>" + html.EscapeString(part) + "</pre>"
+		default:
+			texts[i] = title + "\n" + part
+		}
+	}
+
+	return texts, nil
+}
+
+// send sends the message, and retries after the duration told by the 429 responses.
+func (d *TelegramTransfer) send(text string) error {
+	data, err := json.Marshal(&TelegramMessage{ChatID: d.chatID, Text: text, ParseMode: d.parseMode})
+	if err != nil {
+		return err
+	}
+
+	for retries := 0; ; retries++ {
+		res, err := d.request(data)
+		if err != nil {
+			return err
+		}
+
+		if res.OK {
+			return nil
+		}
+
+		if res.ErrorCode != http.StatusTooManyRequests || retries >= telegramMaxRetries {
+			return fmt.Errorf("%w: %d %s", ErrTelegramResponse, res.ErrorCode, res.Description)
+		}
+
+		vlog.Warnf("telegram transfer %s: too many requests, retry after %d seconds", d.id, res.Parameters.RetryAfter)
+
+		select {
+		case <-d.done:
+			return fmt.Errorf("%w: %d %s", ErrTelegramResponse, res.ErrorCode, res.Description)
+		case <-time.After(time.Duration(res.Parameters.RetryAfter) * time.Second):
+		}
+	}
+}
+
+// request posts the data, and parses the response, which is JSON for both the successful and failed requests.
+func (d *TelegramTransfer) request(data []byte) (*telegramResponse, error) {
+	res, err := d.client.Post(d.url, contentTypeJSON, vio.NewBytesReader(data))
+	if err != nil {
+		// the url including the bot token is stripped from the error, which is logged.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, fmt.Errorf("telegram %s: %w", urlErr.Op, urlErr.Err)
+		}
+
+		return nil, err
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &telegramResponse{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("http alert error, %w: %d", ErrHTTPStatusNonOK, res.StatusCode)
+	}
+
+	return result, nil
+}
+
+// splitTelegramText splits the text into at most max parts of the limit UTF-16 code units,
+// at the last new line of a part if possible, and the last part is truncated if the text is too long.
+func splitTelegramText(text string, limit, maxParts int) []string {
+	var parts []string
+
+	for len(parts) < maxParts-1 && utf16Length(text) > limit {
+		part := prefixOfLength(text, limit)
+
+		if i := strings.LastIndexByte(part, '\n'); i > 0 {
+			part = part[:i]
+		}
+
+		parts = append(parts, part)
+		text = strings.TrimPrefix(text[len(part):], "\n")
+	}
+
+	if utf16Length(text) > limit {
+		text = prefixOfLength(text, limit-len(telegramTruncatedMarker)) + telegramTruncatedMarker
+	}
+
+	return append(parts, text)
+}
+
+// prefixOfLength returns the longest prefix of the text in the limit UTF-16 code units.
+func prefixOfLength(text string, limit int) string {
+	length := 0
+
+	for i, r := range text {
+		length += utf16.RuneLen(r)
+		if length > limit {
+			return text[:i]
+		}
+	}
+
+	return text
+}
+
+func utf16Length(text string) int {
+	length := 0
+
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+
+	return length
+}
+
+// NewTelegramTransfer new telegram trans sending to the chat by the bot,
+// the api url defaults to DefaultTelegramAPIURL if empty.
+func NewTelegramTransfer(id, apiURL, prefix string, opts HTTPTransferOptions) *TelegramTransfer {
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+
+	t := &TelegramTransfer{
+		id:        id,
+		url:       strings.TrimSuffix(apiURL, "/") + "/bot" + opts.BotToken + "/sendMessage",
+		prefix:    prefix,
+		chatID:    opts.ChatID,
+		parseMode: opts.ParseMode,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+
+	if t.prefix == "" {
+		t.prefix = DefaultTransferPrefix
+	}
+
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, t.prefix)
+	}
+
+	if opts.RateLimit > 0 {
+		t.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+
+	if opts.BatchSize > 1 {
+		t.batcher = NewRecordBatcher(opts.BatchSize, opts.BatchTimeout, t.post)
+	}
+
+	go t.loop()
+
+	return t
+}
@@ -0,0 +1,243 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+type telegramServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []*trans.TelegramMessage
+	requests atomic.Int32
+	limited  atomic.Int32 // the count of requests to respond 429
+}
+
+func newTelegramServer(t *testing.T, retryAfter int) *telegramServer {
+	t.Helper()
+
+	s := &telegramServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+
+		if r.URL.Path != "/bot123:abc/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+
+			return
+		}
+
+		if s.limited.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": false, "error_code": 429, "description": "Too Many Requests",
+				"parameters": map[string]int{"retry_after": retryAfter},
+			})
+
+			return
+		}
+
+		msg := &trans.TelegramMessage{}
+		_ = json.NewDecoder(r.Body).Decode(msg)
+
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		s.mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+
+	return s
+}
+
+func (s *telegramServer) received() []*trans.TelegramMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*trans.TelegramMessage(nil), s.messages...)
+}
+
+func newTelegramTestTransfer(server *telegramServer, opts trans.HTTPTransferOptions) *trans.TelegramTransfer {
+	opts.BotToken = "123:abc"
+	opts.ChatID = "-100123"
+
+	return trans.NewTelegramTransfer("telegram", server.URL+"/", "", opts)
+}
+
+func TestTelegramTransferPlainText(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 0)
+	defer server.Close()
+
+	tt := newTelegramTestTransfer(server, trans.HTTPTransferOptions{})
+	assert.Equal(t, "telegram", tt.Name())
+
+	rec := record.New("app", "", []byte("ERROR <failed> *now*"))
+	rec.Router = "alert"
+	require.NoError(t, tt.Trans(rec))
+	require.NoError(t, tt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "-100123", messages[0].ChatID)
+	assert.Empty(t, messages[0].ParseMode)
+	assert.Equal(t, "logtail-app / alert\nERROR <failed> *now*", messages[0].Text)
+}
+
+func TestTelegramTransferEscape(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 0)
+	defer server.Close()
+
+	markdown := newTelegramTestTransfer(server, trans.HTTPTransferOptions{ParseMode: trans.TelegramParseModeMarkdownV2})
+	require.NoError(t, markdown.Trans(record.New("my_app", "", []byte("a `b` c\\d <e> *f*"))))
+	require.NoError(t, markdown.Stop())
+
+	htmlTransfer := newTelegramTestTransfer(server, trans.HTTPTransferOptions{ParseMode: trans.TelegramParseModeHTML})
+	require.NoError(t, htmlTransfer.Trans(record.New("my_app", "", []byte("a `b` c\\d <e> *f* & g"))))
+	require.NoError(t, htmlTransfer.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "MarkdownV2", messages[0].ParseMode)
+	assert.Equal(t, "*logtail\\-my\\_app*\n```\na \\`b\\` c\\\\d <e> *f*\n```", messages[0].Text)
+	assert.Equal(t, "HTML", messages[1].ParseMode)
+	assert.Equal(t, "<b>logtail-my_app</b>\n<pre>a `b` c\\d &lt;e&gt; *f* &amp; g</pre>", messages[1].Text)
+}
+
+func TestTelegramTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 0)
+	defer server.Close()
+
+	tt := newTelegramTestTransfer(server, trans.HTTPTransferOptions{
+		ParseMode: trans.TelegramParseModeMarkdownV2,
+		Template:  "*{{markdownV2 .Source}}* {{markdownV2 .Text}}",
+	})
+
+	require.NoError(t, tt.Trans(record.New("my-app", "", []byte("disk 95.5% used!"))))
+	require.NoError(t, tt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "*my\\-app* disk 95\\.5% used\\!", messages[0].Text)
+}
+
+func TestTelegramTransferSplit(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 0)
+	defer server.Close()
+
+	tt := newTelegramTestTransfer(server, trans.HTTPTransferOptions{})
+
+	// 3000 UTF-16 code units per line, the emoji is 2 units.
+	line := strings.Repeat("日志😀", 1000)
+
+	records := make([]*record.Record, 3)
+	for i := range records {
+		records[i] = record.New("app", "", []byte(line))
+	}
+
+	require.NoError(t, tt.Trans(records...))
+
+	// split into 4 messages at most, the rest is truncated.
+	require.NoError(t, tt.Trans(record.New("app", "", []byte(strings.Repeat(line, 10)))))
+	require.NoError(t, tt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 7)
+
+	for i, msg := range messages {
+		assert.LessOrEqual(t, len(utf16.Encode([]rune(msg.Text))), 4096)
+		assert.True(t, strings.HasPrefix(msg.Text, "logtail-app\n"))
+
+		if i < 3 {
+			assert.Equal(t, "logtail-app\n"+line, msg.Text)
+		}
+	}
+
+	assert.True(t, strings.HasSuffix(messages[6].Text, "\n...[truncated]"))
+}
+
+func TestTelegramTransferRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 1)
+	defer server.Close()
+
+	server.limited.Store(1)
+
+	tt := newTelegramTestTransfer(server, trans.HTTPTransferOptions{})
+	defer func() { _ = tt.Stop() }()
+
+	start := time.Now()
+
+	// the message is sent in the background, without blocking the router.
+	require.NoError(t, tt.Trans(record.New("app", "", []byte("msg"))))
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 3*time.Second, 20*time.Millisecond)
+
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), server.requests.Load())
+	assert.Equal(t, "logtail-app\nmsg", server.received()[0].Text)
+}
+
+func TestTelegramTransferStopRetry(t *testing.T) {
+	t.Parallel()
+
+	server := newTelegramServer(t, 60)
+	defer server.Close()
+
+	server.limited.Store(10)
+
+	tt := newTelegramTestTransfer(server, trans.HTTPTransferOptions{})
+
+	require.NoError(t, tt.Trans(record.New("app", "", []byte("msg"))))
+
+	assert.Eventually(t, func() bool {
+		return server.requests.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+
+	require.NoError(t, tt.Stop())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, server.received())
+}
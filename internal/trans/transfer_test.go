@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+type bytesTransfer struct {
+	trans.NullTransfer
+	sources []string
+	data    [][]string
+}
+
+func (b *bytesTransfer) Trans(source string, data ...[]byte) error {
+	b.sources = append(b.sources, source)
+
+	values := make([]string, 0, len(data))
+	for _, d := range data {
+		values = append(values, string(d))
+	}
+
+	b.data = append(b.data, values)
+
+	return nil
+}
+
+func TestBytesAdapter(t *testing.T) {
+	t.Parallel()
+
+	transfer := &bytesTransfer{NullTransfer: trans.NullTransfer{ID: "bytes"}}
+
+	var adapter trans.Transfer = trans.NewBytesAdapter(transfer)
+	assert.Equal(t, "bytes", adapter.Name())
+
+	assert.NoError(t, adapter.Trans(
+		record.New("s1", "w1", []byte("a")),
+		record.New("s1", "w2", []byte("b")),
+		record.New("s2", "w3", []byte("c")),
+		record.New("s1", "w1", []byte("d")),
+	))
+	assert.Equal(t, []string{"s1", "s2", "s1"}, transfer.sources)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}}, transfer.data)
+
+	assert.NoError(t, adapter.Trans())
+	assert.Len(t, transfer.sources, 3)
+}
@@ -18,8 +18,13 @@
 package trans
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
+
+	"github.com/vogo/logtail/internal/record"
 )
 
 const TypeWebhook = "webhook"
@@ -27,27 +32,109 @@ const TypeWebhook = "webhook"
 var ErrHTTPStatusNonOK = errors.New("http status non ok")
 
 type WebhookTransfer struct {
-	id      string
-	url     string
-	prefix  string
-	client  *http.Client
-	batcher *Batcher // nil when batch_size <= 1
+	id       string
+	url      string
+	prefix   string
+	envelope bool
+	template *MessageTemplate // nil for posting the data of records
+	client   *http.Client
+	batcher  *Batcher // nil when batch_size <= 1
+}
+
+// Envelope the JSON envelope of the records posted by the webhook transfer in envelope mode.
+type Envelope struct {
+	Hostname string            `json:"hostname"`
+	Count    int               `json:"count"`
+	Records  []*EnvelopeRecord `json:"records"`
+}
+
+// EnvelopeRecord a record in the JSON envelope, the timestamp is the event time of the record.
+type EnvelopeRecord struct {
+	Source    string            `json:"source"`
+	Router    string            `json:"router,omitempty"`
+	Worker    string            `json:"worker,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Level     string            `json:"level,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// NewEnvelope new envelope of the records.
+func NewEnvelope(records []*record.Record) *Envelope {
+	envelope := &Envelope{
+		Hostname: hostname(),
+		Count:    len(records),
+		Records:  make([]*EnvelopeRecord, len(records)),
+	}
+
+	for i, rec := range records {
+		envelope.Records[i] = NewEnvelopeRecord(rec)
+	}
+
+	return envelope
+}
+
+// NewEnvelopeRecord new envelope record of the record.
+func NewEnvelopeRecord(rec *record.Record) *EnvelopeRecord {
+	return &EnvelopeRecord{
+		Source:    rec.Source,
+		Router:    rec.Router,
+		Worker:    rec.Worker,
+		Timestamp: rec.EventTime(),
+		Level:     rec.Level,
+		Message:   string(rec.Data),
+		Fields:    rec.Fields,
+	}
 }
 
 func (d *WebhookTransfer) Name() string {
 	return d.id
 }
 
-func (d *WebhookTransfer) Trans(source string, data ...[]byte) error {
+// Trans posts the records in a request, or adds them to the batch.
+func (d *WebhookTransfer) Trans(records ...*record.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
 	if d.batcher != nil {
-		for _, b := range data {
-			d.batcher.Add(source, b)
+		for _, rec := range records {
+			d.batcher.AddRecord(rec)
 		}
 
 		return nil
 	}
 
-	return httpTransWithClient(d.client, d.url, data...)
+	return d.post(records)
+}
+
+// post posts the records in a request, the body is the JSON envelope in envelope mode,
+// or the message rendered by the template, or the data of the records joined by new lines.
+func (d *WebhookTransfer) post(records []*record.Record) error {
+	if d.envelope {
+		body, err := json.Marshal(NewEnvelope(records))
+		if err != nil {
+			return err
+		}
+
+		return httpPostWithClient(d.client, d.url, contentTypeJSON, body)
+	}
+
+	if d.template != nil {
+		body, err := d.template.Render(records)
+		if err != nil {
+			return err
+		}
+
+		contentType := contentTypeText
+		if json.Valid(body) {
+			contentType = contentTypeJSON
+		}
+
+		return httpPostWithClient(d.client, d.url, contentType, body)
+	}
+
+	return httpPostWithClient(d.client, d.url, contentTypeText, bytes.Join(record.Datas(records), []byte("\n")))
 }
 
 func (d *WebhookTransfer) Start() error { return nil }
@@ -65,9 +152,10 @@ func (d *WebhookTransfer) Stop() error {
 // NewWebhookTransfer new webhook trans.
 func NewWebhookTransfer(id, url, prefix string, opts HTTPTransferOptions) *WebhookTransfer {
 	t := &WebhookTransfer{
-		id:     id,
-		url:    url,
-		prefix: prefix,
+		id:       id,
+		url:      url,
+		prefix:   prefix,
+		envelope: opts.Envelope,
 		client: NewHTTPClient(HTTPClientConfig{
 			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
 			IdleConnTimeout:     opts.IdleConnTimeout,
@@ -78,10 +166,12 @@ func NewWebhookTransfer(id, url, prefix string, opts HTTPTransferOptions) *Webho
 		t.prefix = DefaultTransferPrefix
 	}
 
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, t.prefix)
+	}
+
 	if opts.BatchSize > 1 {
-		t.batcher = NewBatcher(opts.BatchSize, opts.BatchTimeout, func(source string, data []byte) error {
-			return httpTransWithClient(t.client, t.url, data)
-		})
+		t.batcher = NewRecordBatcher(opts.BatchSize, opts.BatchTimeout, t.post)
 	}
 
 	return t
@@ -18,15 +18,18 @@
 package trans_test
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/trans"
 )
 
@@ -51,8 +54,8 @@ func TestWebhookTransferNoBatching(t *testing.T) {
 	wh := trans.NewWebhookTransfer("wh-nobatch", server.URL, "", trans.HTTPTransferOptions{})
 	defer func() { _ = wh.Stop() }()
 
-	require.NoError(t, wh.Trans("src", []byte("msg1")))
-	require.NoError(t, wh.Trans("src", []byte("msg2")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg2"))))
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -86,9 +89,9 @@ func TestWebhookTransferWithBatching(t *testing.T) {
 	})
 	defer func() { _ = wh.Stop() }()
 
-	require.NoError(t, wh.Trans("src", []byte("msg1")))
-	require.NoError(t, wh.Trans("src", []byte("msg2")))
-	require.NoError(t, wh.Trans("src", []byte("msg3")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg2"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg3"))))
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -121,8 +124,8 @@ func TestWebhookTransferBatchTimeout(t *testing.T) {
 	})
 	defer func() { _ = wh.Stop() }()
 
-	require.NoError(t, wh.Trans("src", []byte("msg1")))
-	require.NoError(t, wh.Trans("src", []byte("msg2")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg2"))))
 
 	time.Sleep(250 * time.Millisecond)
 
@@ -156,8 +159,8 @@ func TestWebhookTransferBatchFlushOnStop(t *testing.T) {
 		BatchTimeout: 10 * time.Second,
 	})
 
-	require.NoError(t, wh.Trans("src", []byte("msg1")))
-	require.NoError(t, wh.Trans("src", []byte("msg2")))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg1"))))
+	require.NoError(t, wh.Trans(record.New("src", "", []byte("msg2"))))
 
 	_ = wh.Stop()
 
@@ -200,7 +203,7 @@ func TestWebhookTransferBackwardCompatibility(t *testing.T) {
 	defer func() { _ = wh.Stop() }()
 
 	for range 5 {
-		require.NoError(t, wh.Trans("src", []byte("msg")))
+		require.NoError(t, wh.Trans(record.New("src", "", []byte("msg"))))
 	}
 
 	mu.Lock()
@@ -209,3 +212,163 @@ func TestWebhookTransferBackwardCompatibility(t *testing.T) {
 	// Each Trans() should produce one HTTP request
 	assert.Len(t, requests, 5)
 }
+
+func TestWebhookTransferEnvelope(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+
+	var (
+		requests     []string
+		contentTypes []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, string(body))
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wh := trans.NewWebhookTransfer("wh-envelope", server.URL, "", trans.HTTPTransferOptions{Envelope: true})
+	defer func() { _ = wh.Stop() }()
+
+	rec := record.New("nginx", "/var/log/access.log", []byte("GET /api 500"))
+	rec.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	rec.Level = "error"
+	rec.Fields = map[string]string{"status": "500"}
+	rec.Router = "error-router"
+
+	require.NoError(t, wh.Trans(rec, record.New("nginx", "", []byte("GET / 200"))))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "application/json", contentTypes[0])
+
+	var envelope trans.Envelope
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &envelope))
+	assert.Equal(t, 2, envelope.Count)
+	require.Len(t, envelope.Records, 2)
+
+	first := envelope.Records[0]
+	assert.Equal(t, "nginx", first.Source)
+	assert.Equal(t, "error-router", first.Router)
+	assert.Equal(t, "/var/log/access.log", first.Worker)
+	assert.True(t, rec.Time.Equal(first.Timestamp))
+	assert.Equal(t, "error", first.Level)
+	assert.Equal(t, "GET /api 500", first.Message)
+	assert.Equal(t, map[string]string{"status": "500"}, first.Fields)
+
+	assert.Equal(t, "GET / 200", envelope.Records[1].Message)
+	assert.Nil(t, envelope.Records[1].Fields)
+	assert.NotContains(t, requests[0], `"fields":null`)
+}
+
+func TestWebhookTransferEnvelopeBatching(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, string(body))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wh := trans.NewWebhookTransfer("wh-envelope-batch", server.URL, "", trans.HTTPTransferOptions{
+		Envelope:     true,
+		BatchSize:    3,
+		BatchTimeout: 10 * time.Second,
+	})
+	defer func() { _ = wh.Stop() }()
+
+	for _, msg := range []string{"msg1", "msg2", "msg3"} {
+		require.NoError(t, wh.Trans(record.New("src", "", []byte(msg))))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, requests, 1)
+
+	var envelope trans.Envelope
+	require.NoError(t, json.Unmarshal([]byte(requests[0]), &envelope))
+	assert.Equal(t, 3, envelope.Count)
+	assert.Equal(t, "msg3", envelope.Records[2].Message)
+}
+
+func TestWebhookTransferTemplate(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+
+	var (
+		requests     []string
+		contentTypes []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, string(body))
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wh := trans.NewWebhookTransfer("wh-template", server.URL, "", trans.HTTPTransferOptions{
+		Template: `{"title":{{json (printf "%s/%s" .Source .Router)}},"count":{{.Count}},"text":{{json .Text}}}`,
+	})
+	defer func() { _ = wh.Stop() }()
+
+	rec := record.New("app", "", []byte(`ERROR "quoted"`))
+	rec.Router = "alert"
+
+	require.NoError(t, wh.Trans(rec))
+	require.NoError(t, wh.Trans(record.New("app", "", []byte("raw"))))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, requests, 2)
+	assert.JSONEq(t, `{"title":"app/alert","count":1,"text":"ERROR \"quoted\""}`, requests[0])
+	assert.Equal(t, "application/json", contentTypes[0])
+}
+
+func TestWebhookTransferContentType(t *testing.T) {
+	t.Parallel()
+
+	var contentType atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		contentType.Store(r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	wh := trans.NewWebhookTransfer("wh-text", server.URL, "", trans.HTTPTransferOptions{
+		Template: `{{.Source}}: {{.Text}}`,
+	})
+	defer func() { _ = wh.Stop() }()
+
+	require.NoError(t, wh.Trans(record.New("app", "", []byte("not json"))))
+	assert.Equal(t, "text/plain; charset=utf-8", contentType.Load())
+}
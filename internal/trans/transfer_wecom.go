@@ -0,0 +1,399 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vogo/logtail/internal/consts"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/vogo/vlog"
+)
+
+// TypeWeCom transfer type of WeCom (WeChat Work) group robots.
+const TypeWeCom = "wecom"
+
+// message types of WeCom group robots.
+const (
+	WeComMsgTypeText     = "text"
+	WeComMsgTypeMarkdown = "markdown"
+)
+
+const (
+	weComContentMaxLength = 4096
+
+	// weComMaxPending the max records waiting for the rate limit, the oldest ones are dropped if exceeded.
+	weComMaxPending = 1024
+
+	// the default rate limit of WeCom group robots, 20 messages per minute.
+	weComDefaultRateLimit = 20.0 / 60
+	weComDefaultRateBurst = 20
+
+	// weComErrCodeFreqLimit the error code of WeCom when the rate limit of the robot is exceeded.
+	weComErrCodeFreqLimit = 45009
+
+	// weComStopRetryInterval the interval before retrying once when the rate limit is exceeded at stopping,
+	// a message interval of the default rate limit.
+	weComStopRetryInterval = 3 * time.Second
+)
+
+var (
+	ErrWeComResponse  = errors.New("wecom response error")
+	errWeComFreqLimit = errors.New("wecom api freq out of limit")
+	errWeComNoPending = errors.New("no pending records")
+)
+
+// WeComMessage the message of WeCom group robots.
+type WeComMessage struct {
+	MsgType  string        `json:"msgtype"`
+	Text     *WeComContent `json:"text,omitempty"`
+	Markdown *WeComContent `json:"markdown,omitempty"`
+}
+
+// WeComContent the content of WeCom messages, the mentioned lists are supported by text messages only.
+type WeComContent struct {
+	Content             string   `json:"content"`
+	MentionedList       []string `json:"mentioned_list,omitempty"`
+	MentionedMobileList []string `json:"mentioned_mobile_list,omitempty"`
+}
+
+type weComResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// WeComTransfer sends records to a WeCom group robot.
+// Records are queued and sent under the rate limit of the robot, the queued records are merged into messages,
+// and the records are queued again if WeCom reports the rate limit is exceeded.
+type WeComTransfer struct {
+	id                  string
+	url                 string
+	prefix              string
+	msgType             string
+	mentionedList       []string
+	mentionedMobileList []string
+	template            *MessageTemplate // nil for the default content
+	client              *http.Client
+	limiter             *rateLimiter
+
+	mu      sync.Mutex
+	pending []*record.Record
+	dropped int // the count of the records dropped since the last message
+
+	notify   chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func (d *WeComTransfer) Name() string {
+	return d.id
+}
+
+func (d *WeComTransfer) Start() error { return nil }
+
+// Stop stops sending, and sends the pending records regardless of the rate limit,
+// retrying once if WeCom reports the rate limit is exceeded.
+func (d *WeComTransfer) Stop() error {
+	d.stopOnce.Do(func() {
+		close(d.done)
+		<-d.exited
+
+		d.limiter.Stop()
+		closeHTTPClient(d.client)
+	})
+
+	return nil
+}
+
+// Trans queues the records to send.
+func (d *WeComTransfer) Trans(records ...*record.Record) error {
+	d.mu.Lock()
+
+	d.pending = append(d.pending, records...)
+
+	if exceeded := len(d.pending) - weComMaxPending; exceeded > 0 {
+		d.pending = d.pending[exceeded:]
+		d.dropped += exceeded
+	}
+
+	d.mu.Unlock()
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (d *WeComTransfer) loop() {
+	defer close(d.exited)
+
+	for {
+		select {
+		case <-d.done:
+			d.drain()
+
+			return
+		case <-d.notify:
+			for d.hasPending() && d.limiter.Wait(d.done) {
+				if err := d.sendNext(); errors.Is(err, errWeComFreqLimit) {
+					// wait for the tokens refilled before retrying.
+					d.limiter.Drain()
+				}
+			}
+		}
+	}
+}
+
+// drain sends the pending records when stopping, the records are dropped
+// if the rate limit is still exceeded after a retry.
+func (d *WeComTransfer) drain() {
+	retried := false
+
+	for {
+		err := d.sendNext()
+
+		switch {
+		case errors.Is(err, errWeComNoPending):
+			return
+		case errors.Is(err, errWeComFreqLimit) && retried:
+			d.mu.Lock()
+			dropped := len(d.pending) + d.dropped
+			d.pending, d.dropped = nil, 0
+			d.mu.Unlock()
+
+			vlog.Errorf("wecom transfer %s: %d records dropped for stopping", d.id, dropped)
+
+			return
+		case errors.Is(err, errWeComFreqLimit):
+			retried = true
+
+			time.Sleep(weComStopRetryInterval)
+		}
+	}
+}
+
+func (d *WeComTransfer) hasPending() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.pending) > 0
+}
+
+// sendNext sends the pending records fitting in a message,
+// the records are queued again if the rate limit is exceeded.
+func (d *WeComTransfer) sendNext() error {
+	records, dropped := d.take()
+	if len(records) == 0 {
+		return errWeComNoPending
+	}
+
+	err := d.send(records, dropped)
+
+	if errors.Is(err, errWeComFreqLimit) {
+		vlog.Warnf("wecom transfer %s: rate limit exceeded, retry later", d.id)
+
+		d.mu.Lock()
+		d.pending = append(records, d.pending...)
+		d.dropped += dropped
+		d.mu.Unlock()
+	} else if err != nil {
+		vlog.Errorf("wecom error: %v", err)
+	}
+
+	return err
+}
+
+// take takes the pending records fitting in the content limit, at least one record.
+func (d *WeComTransfer) take() ([]*record.Record, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	size, count := 0, 0
+
+	for _, rec := range d.pending {
+		size += len(rec.Data) + 1
+		if count > 0 && size > weComContentMaxLength {
+			break
+		}
+
+		count++
+	}
+
+	records := d.pending[:count:count]
+	d.pending = d.pending[count:]
+
+	dropped := d.dropped
+	d.dropped = 0
+
+	return records, dropped
+}
+
+func (d *WeComTransfer) send(records []*record.Record, dropped int) error {
+	content, err := d.content(records, dropped)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(d.message(content))
+	if err != nil {
+		return err
+	}
+
+	body, err := httpPostForBody(d.client, d.url, contentTypeJSON, data)
+	if err != nil {
+		return err
+	}
+
+	var res weComResponse
+
+	if err = json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("%w: %s", ErrWeComResponse, body)
+	}
+
+	switch res.ErrCode {
+	case 0:
+		return nil
+	case weComErrCodeFreqLimit:
+		return errWeComFreqLimit
+	default:
+		return fmt.Errorf("%w: %d %s", ErrWeComResponse, res.ErrCode, res.ErrMsg)
+	}
+}
+
+// content builds the content of the records, rendered by the template if set,
+// and truncated to the content limit without breaking a utf8 rune, before the mentions of markdown.
+func (d *WeComTransfer) content(records []*record.Record, dropped int) (string, error) {
+	var buf bytes.Buffer
+
+	if dropped > 0 {
+		_, _ = fmt.Fprintf(&buf, "[%d records dropped for the rate limit]\n", dropped)
+	}
+
+	if d.template != nil {
+		content, err := d.template.Render(records)
+		if err != nil {
+			return "", err
+		}
+
+		buf.Write(content)
+	} else {
+		d.writeDefaultContent(&buf, records)
+	}
+
+	// the mentions are appended after truncating the body, not to be cut off.
+	var mentions bytes.Buffer
+
+	if d.msgType == WeComMsgTypeMarkdown {
+		for _, user := range d.mentionedList {
+			mentions.WriteString("\n<@" + user + ">")
+		}
+	}
+
+	content, _ := TruncateUTF8(buf.Bytes(), max(weComContentMaxLength-mentions.Len(), 0))
+
+	return string(content) + mentions.String(), nil
+}
+
+func (d *WeComTransfer) writeDefaultContent(buf *bytes.Buffer, records []*record.Record) {
+	first := records[0]
+
+	title := d.prefix + first.Source
+	if first.Router != "" {
+		title += " / " + first.Router
+	}
+
+	if d.msgType == WeComMsgTypeMarkdown {
+		meta := []string{first.EventTime().Format(consts.FormatDateTime)}
+		if first.Level != "" {
+			meta = append(meta, first.Level)
+		}
+
+		buf.WriteString("**" + title + "**\n")
+		buf.WriteString(`<font color="comment">` + strings.Join(meta, " | ") + "</font>\n")
+	} else {
+		buf.WriteString("[" + title + "]: ")
+	}
+
+	buf.Write(bytes.Join(record.Datas(records), []byte("\n")))
+}
+
+func (d *WeComTransfer) message(content string) *WeComMessage {
+	if d.msgType == WeComMsgTypeMarkdown {
+		return &WeComMessage{MsgType: WeComMsgTypeMarkdown, Markdown: &WeComContent{Content: content}}
+	}
+
+	return &WeComMessage{
+		MsgType: WeComMsgTypeText,
+		Text: &WeComContent{
+			Content:             content,
+			MentionedList:       d.mentionedList,
+			MentionedMobileList: d.mentionedMobileList,
+		},
+	}
+}
+
+// NewWeComTransfer new WeCom trans, the rate limit defaults to 20 messages per minute of WeCom group robots.
+func NewWeComTransfer(id, url, prefix string, opts HTTPTransferOptions) *WeComTransfer {
+	t := &WeComTransfer{
+		id:                  id,
+		url:                 url,
+		prefix:              prefix,
+		msgType:             opts.MsgType,
+		mentionedList:       opts.MentionedList,
+		mentionedMobileList: opts.MentionedMobileList,
+		client: NewHTTPClient(HTTPClientConfig{
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		}),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+
+	if t.prefix == "" {
+		t.prefix = DefaultTransferPrefix
+	}
+
+	if t.msgType == "" {
+		t.msgType = WeComMsgTypeText
+	}
+
+	if opts.Template != "" {
+		t.template = NewMessageTemplate(opts.Template, t.prefix)
+	}
+
+	if opts.RateLimit > 0 {
+		t.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	} else {
+		t.limiter = newRateLimiter(weComDefaultRateLimit, weComDefaultRateBurst)
+	}
+
+	go t.loop()
+
+	return t
+}
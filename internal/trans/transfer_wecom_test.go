@@ -0,0 +1,318 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trans_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/record"
+	"github.com/vogo/logtail/internal/trans"
+)
+
+type weComServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []*trans.WeComMessage
+	limited  atomic.Int32 // the count of requests to respond rate limit exceeded
+}
+
+func newWeComServer(t *testing.T) *weComServer {
+	t.Helper()
+
+	s := &weComServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if s.limited.Add(-1) >= 0 {
+			_, _ = w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+
+			return
+		}
+
+		msg := &trans.WeComMessage{}
+		if err := json.Unmarshal(body, msg); err != nil {
+			_, _ = w.Write([]byte(`{"errcode":40008,"errmsg":"invalid message type"}`))
+
+			return
+		}
+
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		s.mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+
+	return s
+}
+
+func (s *weComServer) received() []*trans.WeComMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*trans.WeComMessage(nil), s.messages...)
+}
+
+func (s *weComServer) contents() string {
+	var contents []string
+
+	for _, msg := range s.received() {
+		if msg.Text != nil {
+			contents = append(contents, msg.Text.Content)
+		} else {
+			contents = append(contents, msg.Markdown.Content)
+		}
+	}
+
+	return strings.Join(contents, "\n")
+}
+
+func TestWeComTransferText(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom", server.URL, "", trans.HTTPTransferOptions{
+		MentionedList:       []string{"wangqing", "@all"},
+		MentionedMobileList: []string{"13800001111"},
+	})
+
+	assert.Equal(t, "wecom", wt.Name())
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Router = "alert"
+	require.NoError(t, wt.Trans(rec))
+	require.NoError(t, wt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "text", messages[0].MsgType)
+	assert.Nil(t, messages[0].Markdown)
+	assert.Equal(t, "[logtail-app / alert]: ERROR failed", messages[0].Text.Content)
+	assert.Equal(t, []string{"wangqing", "@all"}, messages[0].Text.MentionedList)
+	assert.Equal(t, []string{"13800001111"}, messages[0].Text.MentionedMobileList)
+}
+
+func TestWeComTransferMarkdown(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom-md", server.URL, "", trans.HTTPTransferOptions{
+		MsgType:       trans.WeComMsgTypeMarkdown,
+		MentionedList: []string{"wangqing"},
+	})
+
+	rec := record.New("app", "", []byte("ERROR failed"))
+	rec.Level = "error"
+	rec.Time = time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local)
+	require.NoError(t, wt.Trans(rec))
+	require.NoError(t, wt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "markdown", messages[0].MsgType)
+	assert.Nil(t, messages[0].Text)
+	assert.Equal(t, "**logtail-app**\n<font color=\"comment\">2024-01-15 10:30:45 | error</font>\n"+
+		"ERROR failed\n<@wangqing>", messages[0].Markdown.Content)
+	assert.Empty(t, messages[0].Markdown.MentionedList)
+}
+
+func TestWeComTransferTruncate(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom-truncate", server.URL, "", trans.HTTPTransferOptions{
+		Template: "{{.Text}}",
+	})
+
+	require.NoError(t, wt.Trans(record.New("app", "", []byte(strings.Repeat("日志", 1000)))))
+	require.NoError(t, wt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+
+	content := messages[0].Text.Content
+	assert.Len(t, content, 4095)
+	assert.True(t, utf8.ValidString(content))
+}
+
+func TestWeComTransferTruncateMarkdownMentions(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom-truncate-md", server.URL, "", trans.HTTPTransferOptions{
+		MsgType:       trans.WeComMsgTypeMarkdown,
+		Template:      "{{.Text}}",
+		MentionedList: []string{"wangqing", "@all"},
+	})
+
+	require.NoError(t, wt.Trans(record.New("app", "", []byte(strings.Repeat("x", 5000)))))
+	require.NoError(t, wt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 1)
+
+	// the body is truncated for the mentions kept.
+	content := messages[0].Markdown.Content
+	assert.Len(t, content, 4096)
+	assert.True(t, strings.HasSuffix(content, "x\n<@wangqing>\n<@@all>"))
+}
+
+func TestWeComTransferRateLimitRetry(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	server.limited.Store(1)
+
+	wt := trans.NewWeComTransfer("wecom-retry", server.URL, "", trans.HTTPTransferOptions{
+		RateLimit: 10,
+		RateBurst: 1,
+	})
+	defer func() { _ = wt.Stop() }()
+
+	require.NoError(t, wt.Trans(record.New("app", "", []byte("msg1"))))
+
+	// the message is sent again after WeCom reports the rate limit exceeded.
+	assert.Eventually(t, func() bool {
+		return server.contents() == "[logtail-app]: msg1"
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestWeComTransferQueue(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom-queue", server.URL, "", trans.HTTPTransferOptions{
+		RateLimit: 5,
+		RateBurst: 1,
+	})
+	defer func() { _ = wt.Stop() }()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, wt.Trans(record.New("app", "", fmt.Appendf(nil, "msg%d", i))))
+	}
+
+	// the records waiting for the rate limit are merged, instead of being dropped.
+	assert.Eventually(t, func() bool {
+		contents := server.contents()
+
+		return strings.Contains(contents, "msg1") && strings.Contains(contents, "msg2") &&
+			strings.Contains(contents, "msg3")
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.LessOrEqual(t, len(server.received()), 2)
+}
+
+func TestWeComTransferStopSendsPending(t *testing.T) {
+	t.Parallel()
+
+	server := newWeComServer(t)
+	defer server.Close()
+
+	wt := trans.NewWeComTransfer("wecom-stop", server.URL, "", trans.HTTPTransferOptions{
+		RateLimit: 0.01,
+		RateBurst: 1,
+	})
+
+	require.NoError(t, wt.Trans(record.New("app", "", []byte("first"))))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	records := make([]*record.Record, 1030)
+	for i := range records {
+		records[i] = record.New("app", "", []byte("m"))
+	}
+
+	require.NoError(t, wt.Trans(records...))
+	require.NoError(t, wt.Stop())
+
+	messages := server.received()
+	require.Len(t, messages, 2)
+	content, found := strings.CutPrefix(messages[1].Text.Content, "[6 records dropped for the rate limit]\n")
+	assert.True(t, found)
+	assert.Equal(t, 1024, strings.Count(content, "m"))
+}
+
+func TestWeComTransferStopRetryRateLimit(t *testing.T) {
+	t.Parallel()
+
+	// the pending records are sent by the retry, or dropped if the rate limit is still exceeded after the retry.
+	for name, limited := range map[string]int32{"Retried": 1, "Dropped": 2} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newWeComServer(t)
+			defer server.Close()
+
+			wt := trans.NewWeComTransfer("wecom-stop-retry", server.URL, "", trans.HTTPTransferOptions{
+				RateLimit: 0.01,
+				RateBurst: 1,
+			})
+
+			// take the only token.
+			require.NoError(t, wt.Trans(record.New("app", "", []byte("first"))))
+
+			assert.Eventually(t, func() bool {
+				return len(server.received()) == 1
+			}, time.Second, 10*time.Millisecond)
+
+			server.limited.Store(limited)
+
+			require.NoError(t, wt.Trans(record.New("app", "", []byte("pending"))))
+
+			start := time.Now()
+
+			require.NoError(t, wt.Stop())
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			messages := server.received()
+			if limited == 1 {
+				require.Len(t, messages, 2)
+				assert.Contains(t, messages[1].Text.Content, "pending")
+			} else {
+				assert.Len(t, messages, 1)
+			}
+		})
+	}
+}
@@ -17,8 +17,19 @@
 
 package trans
 
+import "github.com/vogo/logtail/internal/util"
+
 const DoubleSize = 2
 
+// TruncateUTF8 cuts the bytes to the limit without breaking a utf8 rune, and returns whether truncated.
+func TruncateUTF8(bytes []byte, limit int) ([]byte, bool) {
+	if len(bytes) <= limit {
+		return bytes, false
+	}
+
+	return bytes[:util.RuneStart(bytes, limit)], true
+}
+
 //nolint:gomnd,funlen //ignore this
 func EscapeLimitJSONBytes(bytes []byte, capacity int) []byte {
 	if size := len(bytes); size < capacity {
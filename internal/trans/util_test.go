@@ -50,6 +50,21 @@ func TestEscapeLimitJsonBytes(t *testing.T) {
 	assert.Equal(t, []byte(`test 操作异常`), trans.EscapeLimitJSONBytes([]byte("test 操作异常"), 1024))
 }
 
+func TestTruncateUTF8(t *testing.T) {
+	t.Parallel()
+
+	data, truncated := trans.TruncateUTF8([]byte("你好世界"), 12)
+	assert.Equal(t, "你好世界", string(data))
+	assert.False(t, truncated)
+
+	data, truncated = trans.TruncateUTF8([]byte("你好世界"), 8)
+	assert.Equal(t, "你好", string(data))
+	assert.True(t, truncated)
+
+	data, _ = trans.TruncateUTF8([]byte("ab你好"), 3)
+	assert.Equal(t, "ab", string(data))
+}
+
 func TestEscapeLog(t *testing.T) {
 	t.Parallel()
 
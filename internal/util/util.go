@@ -17,9 +17,7 @@
 
 package util
 
-import (
-	"fmt"
-)
+import "unicode/utf8"
 
 const DefaultMapSize = 4
 
@@ -49,7 +47,18 @@ func IgnoreLineEnd(bytes []byte, length, index int) int {
 	return index
 }
 
-// FollowRetryTailCommand flag `-F` is same as `--follow=name --retry`.
-func FollowRetryTailCommand(f string) string {
-	return fmt.Sprintf("tail -F %s", f)
+// RuneStart returns the index not after the given index at the start of a utf8 rune,
+// to cut the bytes without breaking a rune.
+func RuneStart(bytes []byte, index int) int {
+	if index >= len(bytes) {
+		return len(bytes)
+	}
+
+	for i := index; i > 0 && i > index-utf8.UTFMax; i-- {
+		if utf8.RuneStart(bytes[i]) {
+			return i
+		}
+	}
+
+	return index
 }
@@ -75,11 +75,15 @@ func TestIgnoreLineEnd(t *testing.T) {
 	assert.Equal(t, 7, idx) // skips \r\n
 }
 
-func TestFollowRetryTailCommand(t *testing.T) {
+func TestRuneStart(t *testing.T) {
 	t.Parallel()
 
-	cmd := util.FollowRetryTailCommand("/var/log/app.log")
-	assert.Equal(t, "tail -F /var/log/app.log", cmd)
+	data := []byte("ab错误")
+	assert.Equal(t, 2, util.RuneStart(data, 2))
+	assert.Equal(t, 2, util.RuneStart(data, 3))
+	assert.Equal(t, 2, util.RuneStart(data, 4))
+	assert.Equal(t, 5, util.RuneStart(data, 5))
+	assert.Equal(t, len(data), util.RuneStart(data, 100))
 }
 
 func TestAllStacks(t *testing.T) {
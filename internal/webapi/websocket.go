@@ -27,6 +27,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/vogo/logtail/internal/conf"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/route"
 	"github.com/vogo/logtail/internal/tail"
 	"github.com/vogo/logtail/internal/trans"
@@ -54,9 +55,9 @@ func (ww *WebsocketTransfer) Start() error { return nil }
 
 func (ww *WebsocketTransfer) Stop() error { return nil }
 
-func (ww *WebsocketTransfer) Trans(_ string, data ...[]byte) error {
-	for _, d := range data {
-		err := ww.conn.WriteMessage(1, d)
+func (ww *WebsocketTransfer) Trans(records ...*record.Record) error {
+	for _, rec := range records {
+		err := ww.conn.WriteMessage(1, rec.Data)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package work
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vogo/logtail/internal/util"
+	"github.com/vogo/vogo/vlog"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+// DefaultCheckpointInterval default interval to flush the checkpoint file.
+const DefaultCheckpointInterval = 5 * time.Second
+
+// Checkpoint stores the read positions of following files,
+// and persists them to the checkpoint file if configured.
+type Checkpoint struct {
+	mu        sync.Mutex
+	runner    *vrun.Runner
+	file      string
+	positions map[string]*FilePosition
+	followers map[string]*FileFollower
+	saved     []byte
+}
+
+// NewCheckpoint new checkpoint, positions are only kept in memory if the file is empty.
+func NewCheckpoint(file string) *Checkpoint {
+	return &Checkpoint{
+		runner:    vrun.New(),
+		file:      file,
+		positions: make(map[string]*FilePosition, util.DefaultMapSize),
+		followers: make(map[string]*FileFollower, util.DefaultMapSize),
+	}
+}
+
+// Load positions from the checkpoint file, positions of files not existing are ignored.
+func (c *Checkpoint) Load() error {
+	if c.file == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	positions := make(map[string]*FilePosition, util.DefaultMapSize)
+	if err = json.Unmarshal(data, &positions); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path, position := range positions {
+		if _, statErr := os.Stat(path); statErr != nil {
+			vlog.Infof("ignore checkpoint of not existing file: %s", path)
+
+			continue
+		}
+
+		c.positions[path] = position
+	}
+
+	c.saved = data
+
+	return nil
+}
+
+// Position returns the saved position of the file, nil if not exists.
+func (c *Checkpoint) Position(path string) *FilePosition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.positions[path]
+}
+
+// Register the follower to save its position when flushing.
+func (c *Checkpoint) Register(follower *FileFollower) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.followers[follower.Path()] = follower
+}
+
+// Unregister the follower and save its last position.
+func (c *Checkpoint) Unregister(follower *FileFollower) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.followers[follower.Path()] != follower {
+		return
+	}
+
+	delete(c.followers, follower.Path())
+
+	if position := follower.Position(); position != nil {
+		c.positions[follower.Path()] = position
+	}
+}
+
+// Remove the position of the file, called when the file is removed.
+func (c *Checkpoint) Remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.followers, path)
+	delete(c.positions, path)
+}
+
+// Flush positions of all followers to the checkpoint file.
+func (c *Checkpoint) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path, follower := range c.followers {
+		if position := follower.Position(); position != nil {
+			c.positions[path] = position
+		}
+	}
+
+	if c.file == "" || (len(c.positions) == 0 && c.saved == nil) {
+		return nil
+	}
+
+	data, err := json.Marshal(c.positions)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(data, c.saved) {
+		return nil
+	}
+
+	// write to a temp file and rename it, to avoid a broken checkpoint file.
+	tempFile := c.file + ".tmp"
+
+	if err = os.WriteFile(tempFile, data, 0o600); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tempFile, c.file); err != nil {
+		return err
+	}
+
+	c.saved = data
+
+	return nil
+}
+
+// StartFlushLoop flush the checkpoint file periodically until stopped.
+func (c *Checkpoint) StartFlushLoop(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.runner.C:
+			return
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				vlog.Warnf("flush checkpoint error: %v", err)
+			}
+		}
+	}
+}
+
+// Stop the flush loop and flush the checkpoint file.
+func (c *Checkpoint) Stop() {
+	c.runner.Stop()
+
+	if err := c.Flush(); err != nil {
+		vlog.Warnf("flush checkpoint error: %v", err)
+	}
+}
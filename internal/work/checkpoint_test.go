@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package work_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/work"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+func TestCheckpoint_FlushAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	checkpointFile := filepath.Join(dir, "logtail.checkpoint")
+
+	appendFile(t, path, "line1\n")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	require.NoError(t, follower.Follow(&buf))
+
+	checkpoint := work.NewCheckpoint(checkpointFile)
+	checkpoint.Register(follower)
+	checkpoint.Stop()
+
+	follower.Close()
+	appendFile(t, path, "line2\n")
+
+	loaded := work.NewCheckpoint(checkpointFile)
+	require.NoError(t, loaded.Load())
+
+	position := loaded.Position(path)
+	require.NotNil(t, position)
+	assert.Equal(t, int64(len("line1\n")), position.Offset)
+
+	resumed := work.NewFileFollower(path, position)
+	defer resumed.Close()
+
+	require.NoError(t, resumed.Follow(&buf))
+	assert.Equal(t, "line1\nline2\n", buf.String())
+}
+
+func TestCheckpoint_IgnoreNotExistFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	checkpointFile := filepath.Join(dir, "logtail.checkpoint")
+
+	require.NoError(t, os.WriteFile(checkpointFile, []byte(`{"/not/exist/app.log":{"dev":1,"inode":2,"offset":3}}`), 0o600))
+
+	checkpoint := work.NewCheckpoint(checkpointFile)
+	require.NoError(t, checkpoint.Load())
+	assert.Nil(t, checkpoint.Position("/not/exist/app.log"))
+}
+
+func TestCheckpoint_Unregister(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "line1\n")
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	require.NoError(t, follower.Follow(&bytes.Buffer{}))
+
+	checkpoint := work.NewCheckpoint("")
+	checkpoint.Register(follower)
+
+	follower.Close()
+	checkpoint.Unregister(follower)
+
+	position := checkpoint.Position(path)
+	require.NotNil(t, position)
+	assert.Equal(t, int64(len("line1\n")), position.Offset)
+
+	checkpoint.Remove(path)
+	assert.Nil(t, checkpoint.Position(path))
+}
+
+func TestFileFollower_RotatedWhileNotFollowing(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "line1\n")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	require.NoError(t, follower.Follow(&buf))
+	follower.Close()
+
+	// rotate the file while not following.
+	appendFile(t, path, "line2\n")
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	appendFile(t, path, "line3\n")
+
+	resumed := work.NewFileFollower(path, follower.Position())
+	defer resumed.Close()
+
+	require.NoError(t, resumed.Follow(&buf))
+	assert.Equal(t, "line1\nline2\nline3\n", buf.String())
+}
+
+func TestWorkerFilePosition_ExcludeIncompleteRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "line1\nincomplete")
+
+	worker := work.NewRawWorker("w1", "", false)
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(worker))
+	assert.Equal(t, int64(len("line1\n")), follower.Position().Offset)
+}
+
+func TestWorkerFilePosition_FlushTimeout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	checkpointFile := filepath.Join(dir, "logtail.checkpoint")
+
+	appendFile(t, path, "line1\nincomplete")
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	defer router.Stop()
+
+	worker := work.NewRawWorker("w1", "", false)
+	worker.Runner = runner
+	worker.FlushTimeout = 20 * time.Millisecond
+	worker.Routers["test-router"] = router
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	require.NoError(t, follower.Follow(worker))
+	assert.Equal(t, int64(len("line1\n")), follower.Position().Offset)
+
+	// the incomplete record is flushed by the timer, and is included in the position.
+	assert.Eventually(t, func() bool {
+		return len(router.Channel) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	checkpoint := work.NewCheckpoint(checkpointFile)
+	checkpoint.Register(follower)
+	checkpoint.Stop()
+	follower.Close()
+
+	loaded := work.NewCheckpoint(checkpointFile)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, int64(len("line1\nincomplete")), loaded.Position(path).Offset)
+
+	appendFile(t, path, "line2\n")
+
+	resumed := work.NewFileFollower(path, loaded.Position(path))
+	defer resumed.Close()
+
+	require.NoError(t, resumed.Follow(worker))
+
+	// no record is replayed after resuming.
+	assert.Equal(t, []string{"line1\n", "incomplete", "line2\n"}, receivedRecords(router))
+}
@@ -0,0 +1,352 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package work
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+)
+
+const (
+	// FileFollowInterval interval to check new data of a following file.
+	FileFollowInterval = 200 * time.Millisecond
+
+	fileReadBufferSize = 32 * 1024
+)
+
+// FilePosition the read position of a following file.
+// The device and inode are zero if the identity of the file is unknown.
+type FilePosition struct {
+	Dev    uint64 `json:"dev"`
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// matchFile whether the position belongs to the file, true if the identity is unknown.
+func (p *FilePosition) matchFile(info os.FileInfo) bool {
+	if p.Dev == 0 && p.Inode == 0 {
+		return true
+	}
+
+	dev, inode := fileIdentity(info)
+
+	return p.Dev == dev && p.Inode == inode
+}
+
+// BufferedWriter a writer buffering incomplete data,
+// which should be read again when following a file from the saved position.
+type BufferedWriter interface {
+	io.Writer
+	Buffered() int
+}
+
+// FileFollower follows a file like `tail -F`, it reads new data appended to the file,
+// reads from the start if the file is truncated (copytruncate rotation),
+// and reopens the file if it's renamed and recreated (create rotation).
+type FileFollower struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	info   os.FileInfo
+	offset int64
+	closed bool
+	buf    []byte
+
+	// writer the writer of the data, whose incomplete data is excluded from the position, nil if not buffered.
+	writer BufferedWriter
+
+	// the file rotated while not following, read the remaining data of it first.
+	rotated *os.File
+
+	// the position to start when opening the file, nil means the end of the file.
+	position *FilePosition
+}
+
+// NewFileFollower new file follower starting from the position, nil position means the end of the file.
+func NewFileFollower(path string, position *FilePosition) *FileFollower {
+	return &FileFollower{
+		path:     path,
+		position: position,
+		buf:      make([]byte, fileReadBufferSize),
+	}
+}
+
+// Path the path of the following file.
+func (f *FileFollower) Path() string {
+	return f.path
+}
+
+// Position returns the position of the data completely written.
+func (f *FileFollower) Position() *FilePosition {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.currentPosition()
+}
+
+func (f *FileFollower) currentPosition() *FilePosition {
+	if f.file == nil {
+		return f.position
+	}
+
+	dev, inode := fileIdentity(f.info)
+
+	// the incomplete data is taken when taking the position, as it may be flushed after read, e.g. by a timer.
+	pending := 0
+	if f.writer != nil {
+		pending = f.writer.Buffered()
+	}
+
+	return &FilePosition{
+		Dev:    dev,
+		Inode:  inode,
+		Offset: max(f.offset-int64(pending), 0),
+	}
+}
+
+// Follow reads all new data of the file into the writer.
+// It does nothing if the file not exists, and will read from the start when the file is created.
+func (f *FileFollower) Follow(writer io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	f.writer, _ = writer.(BufferedWriter)
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			if os.IsNotExist(err) {
+				// read from the start when the file is created.
+				f.position = &FilePosition{}
+
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	if f.rotated != nil {
+		_, err := f.readFile(f.rotated, writer)
+
+		_ = f.rotated.Close()
+		f.rotated = nil
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := f.read(writer); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the file is renamed or removed, wait for it to be recreated.
+			return nil
+		}
+
+		return err
+	}
+
+	if !os.SameFile(f.info, info) {
+		vlog.Infof("file rotated: %s", f.path)
+
+		// read the data written to the rotated file after the last read.
+		if err = f.read(writer); err != nil {
+			return err
+		}
+
+		f.closeFile()
+		f.position = &FilePosition{}
+
+		if err = f.open(); err != nil {
+			return err
+		}
+
+		return f.read(writer)
+	}
+
+	if info.Size() < f.offset {
+		vlog.Infof("file truncated: %s", f.path)
+
+		if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		f.offset = 0
+
+		return f.read(writer)
+	}
+
+	return nil
+}
+
+// Close the following file, the follower can't be used after closed.
+func (f *FileFollower) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.position = f.currentPosition()
+
+	f.closeFile()
+	f.closed = true
+}
+
+func (f *FileFollower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	offset := f.startOffset(info)
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	f.file = file
+	f.info = info
+	f.offset = offset
+
+	return nil
+}
+
+// startOffset returns the offset to start reading the opened file.
+func (f *FileFollower) startOffset(info os.FileInfo) int64 {
+	switch {
+	case f.position == nil:
+		return info.Size()
+	case !f.position.matchFile(info):
+		// the file was rotated, it's a new file.
+		f.rotated = findRotatedFile(f.path, f.position)
+
+		return 0
+	case f.position.Offset > info.Size():
+		// the file was truncated.
+		return 0
+	default:
+		return f.position.Offset
+	}
+}
+
+// findRotatedFile finds the file of the position in the directory of the path,
+// and opens it at the offset of the position, returns nil if not found.
+func findRotatedFile(path string, position *FilePosition) *os.File {
+	dir := filepath.Dir(path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, infoErr := entry.Info()
+		if infoErr != nil || !position.matchFile(info) || info.Size() <= position.Offset {
+			continue
+		}
+
+		rotatedPath := filepath.Join(dir, entry.Name())
+
+		file, openErr := os.Open(rotatedPath)
+		if openErr != nil {
+			return nil
+		}
+
+		if _, seekErr := file.Seek(position.Offset, io.SeekStart); seekErr != nil {
+			_ = file.Close()
+
+			return nil
+		}
+
+		vlog.Infof("read remaining data of rotated file %s for %s", rotatedPath, path)
+
+		return file
+	}
+
+	return nil
+}
+
+func (f *FileFollower) read(writer io.Writer) error {
+	n, err := f.readFile(f.file, writer)
+
+	f.offset += n
+
+	return err
+}
+
+// readFile reads the file to the end, returns the count of bytes read.
+func (f *FileFollower) readFile(file *os.File, writer io.Writer) (int64, error) {
+	var total int64
+
+	for {
+		n, err := file.Read(f.buf)
+		if n > 0 {
+			total += int64(n)
+
+			if _, writeErr := writer.Write(f.buf[:n]); writeErr != nil {
+				return total, writeErr
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return total, nil
+			}
+
+			return total, err
+		}
+	}
+}
+
+func (f *FileFollower) closeFile() {
+	if f.rotated != nil {
+		_ = f.rotated.Close()
+		f.rotated = nil
+	}
+
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+		f.info = nil
+	}
+}
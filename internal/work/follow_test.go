@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package work_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vogo/logtail/internal/work"
+)
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	require.NoError(t, appendData(path, data))
+}
+
+// appendData appends the data to the file, for the goroutines other than the test one.
+func appendData(path, data string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.WriteString(data); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+func TestFileFollower_FromEnd(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old line\n")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, nil)
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(&buf))
+	assert.Empty(t, buf.String())
+
+	appendFile(t, path, "new line\n")
+	require.NoError(t, follower.Follow(&buf))
+	assert.Equal(t, "new line\n", buf.String())
+	assert.Equal(t, int64(len("old line\nnew line\n")), follower.Position().Offset)
+}
+
+func TestFileFollower_FromStart(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "line1\n")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(&buf))
+	assert.Equal(t, "line1\n", buf.String())
+}
+
+func TestFileFollower_NotExist(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, nil)
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(&buf))
+
+	// created file should be read from the start.
+	appendFile(t, path, "line1\n")
+	require.NoError(t, follower.Follow(&buf))
+	assert.Equal(t, "line1\n", buf.String())
+}
+
+func TestFileFollower_CopyTruncate(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, nil)
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(&buf))
+
+	appendFile(t, path, "before truncate\n")
+	require.NoError(t, follower.Follow(&buf))
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendFile(t, path, "after\n")
+	require.NoError(t, follower.Follow(&buf))
+
+	assert.Equal(t, "before truncate\nafter\n", buf.String())
+}
+
+func TestFileFollower_RenameCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, nil)
+	defer follower.Close()
+
+	require.NoError(t, follower.Follow(&buf))
+
+	appendFile(t, path, "line1\n")
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+
+	// the file is renamed but not recreated yet.
+	require.NoError(t, follower.Follow(&buf))
+	assert.Equal(t, "line1\n", buf.String())
+
+	appendFile(t, path, "line2\n")
+	require.NoError(t, follower.Follow(&buf))
+	assert.Equal(t, "line1\nline2\n", buf.String())
+}
+
+func TestFileFollower_RotateWhileWriting(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "")
+
+	const lines, rotateLines = 400, 20
+
+	var expected strings.Builder
+
+	for i := range lines {
+		expected.WriteString(fmt.Sprintf("line-%04d\n", i))
+	}
+
+	// the writer sends its error, nil if all lines written, as require must be called in the test goroutine.
+	written := make(chan error, 1)
+
+	go func() {
+		written <- func() error {
+			for i := range lines {
+				if err := appendData(path, fmt.Sprintf("line-%04d\n", i)); err != nil {
+					return err
+				}
+
+				if i%rotateLines == rotateLines-1 {
+					if err := os.Rename(path, filepath.Join(dir, fmt.Sprintf("app.log.%d", i/rotateLines))); err != nil {
+						return err
+					}
+
+					if err := appendData(path, ""); err != nil {
+						return err
+					}
+				}
+
+				time.Sleep(20 * time.Microsecond)
+			}
+
+			return nil
+		}()
+	}()
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	defer follower.Close()
+
+	for following := true; following; {
+		select {
+		case err := <-written:
+			require.NoError(t, err)
+
+			following = false
+		case <-time.After(100 * time.Microsecond):
+		}
+
+		require.NoError(t, follower.Follow(&buf))
+	}
+
+	// no line is lost when the file is rotated after the last read.
+	assert.Equal(t, expected.String(), buf.String())
+}
+
+func TestFileFollower_ResumePosition(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "line1\n")
+
+	var buf bytes.Buffer
+
+	follower := work.NewFileFollower(path, &work.FilePosition{})
+	require.NoError(t, follower.Follow(&buf))
+	follower.Close()
+
+	appendFile(t, path, "line2\n")
+
+	resumed := work.NewFileFollower(path, follower.Position())
+	defer resumed.Close()
+
+	require.NoError(t, resumed.Follow(&buf))
+	assert.Equal(t, "line1\nline2\n", buf.String())
+}
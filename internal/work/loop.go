@@ -41,6 +41,12 @@ func (w *Worker) StartLoop() {
 		}
 	}
 
+	if w.Follower != nil {
+		w.startFollowLoop()
+
+		return
+	}
+
 	if w.command == "" {
 		<-w.Runner.C
 
@@ -89,3 +95,27 @@ func (w *Worker) StartLoop() {
 		}
 	}
 }
+
+// startFollowLoop read new data of the following file periodically.
+func (w *Worker) startFollowLoop() {
+	vlog.Infof("worker [%s] follow file: %s", w.ID, w.Follower.Path())
+
+	ticker := time.NewTicker(FileFollowInterval)
+	defer ticker.Stop()
+
+	for {
+		interval := ticker.C
+
+		if err := w.Follower.Follow(w); err != nil {
+			vlog.Errorf("worker [%s] follow file error: %+v, retry after 10s! file: %s", w.ID, err, w.Follower.Path())
+
+			interval = time.After(CommandFailRetryInterval)
+		}
+
+		select {
+		case <-w.Runner.C:
+			return
+		case <-interval:
+		}
+	}
+}
@@ -18,7 +18,10 @@
 package work
 
 import (
+	"time"
+
 	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/util"
 	"github.com/vogo/vogo/vlog"
 )
@@ -40,31 +43,202 @@ func (w *Worker) Write(data []byte) (int, error) {
 		return 0, nil
 	}
 
+	w.bufMu.Lock()
+	defer w.bufMu.Unlock()
+
+	if w.Format != nil && w.Format.IsAuto() {
+		w.detectFormat(data)
+	}
+
 	var firstLog []byte
 
 	for len(data) > 0 {
 		firstLog, data = match.SplitFirstLog(w.Format, data)
 
-		w.buf = append(w.buf, firstLog...)
+		w.appendRecord(firstLog)
 
 		if len(data) > 0 || firstLog[len(firstLog)-1] == '\n' {
-			w.flushData(w.buf)
+			w.flushRecord()
+		}
+	}
+
+	w.resetFlushTimer()
+
+	return dataLen, nil
+}
 
-			// reset buffer
+// appendRecord appends data to the buffered record, and truncates or splits it if exceeding the max size.
+func (w *Worker) appendRecord(data []byte) {
+	if w.discarding {
+		return
+	}
+
+	w.buf = append(w.buf, data...)
+
+	for w.MaxRecordSize > 0 && len(w.buf) > w.MaxRecordSize {
+		size := util.RuneStart(w.buf, w.MaxRecordSize)
+
+		if !w.SplitLargeRecord {
+			w.flushData(markRecord(w.buf[:size], TruncatedMarker))
 			w.buf = nil
+			w.discarding = true
+
+			return
 		}
+
+		w.flushData(markRecord(w.buf[:size], SplitMarker))
+		w.buf = w.buf[size:]
 	}
+}
 
-	return dataLen, nil
+// markRecord returns a new record with the marker appended to the data.
+func markRecord(data []byte, marker string) []byte {
+	record := make([]byte, 0, len(data)+len(marker)+1)
+	record = append(record, data...)
+	record = append(record, marker...)
+
+	return append(record, '\n')
 }
 
+// flushRecord flushes the buffered record.
+func (w *Worker) flushRecord() {
+	if len(w.buf) > 0 {
+		w.flushData(w.buf)
+	}
+
+	// reset buffer
+	w.buf = nil
+	w.discarding = false
+}
+
+// resetFlushTimer resets the timer to flush the incomplete record after the flush timeout.
+func (w *Worker) resetFlushTimer() {
+	if w.FlushTimeout <= 0 {
+		return
+	}
+
+	if len(w.buf) == 0 && !w.discarding {
+		if w.flushTimer != nil {
+			w.flushTimer.Stop()
+		}
+
+		return
+	}
+
+	if w.flushTimer == nil {
+		w.flushTimer = time.AfterFunc(w.FlushTimeout, w.flushTimeout)
+
+		return
+	}
+
+	w.flushTimer.Reset(w.FlushTimeout)
+}
+
+func (w *Worker) stopFlushTimer() {
+	w.bufMu.Lock()
+	defer w.bufMu.Unlock()
+
+	if w.flushTimer != nil {
+		w.flushTimer.Stop()
+	}
+}
+
+// flushTimeout flushes the incomplete record when no data arrives in the flush timeout.
+func (w *Worker) flushTimeout() {
+	w.bufMu.Lock()
+	defer w.bufMu.Unlock()
+
+	if len(w.buf) > 0 {
+		vlog.Debugf("worker [%s] flush incomplete record after %s", w.ID, w.FlushTimeout)
+	}
+
+	w.flushRecord()
+}
+
+// detectFormat detects the format preset from the first lines,
+// the lines are split by the auto format, i.e. line by line, before the preset is detected.
+func (w *Worker) detectFormat(data []byte) {
+	if w.formatDetector == nil {
+		w.formatDetector = match.NewFormatDetector()
+		w.formatName.Store(match.PresetAuto)
+	}
+
+	format, done := w.formatDetector.Detect(data)
+	if !done {
+		return
+	}
+
+	w.formatDetector = nil
+
+	preset := ""
+
+	if format == nil {
+		vlog.Infof("worker [%s] no format preset detected", w.ID)
+	} else {
+		preset = format.Preset
+		vlog.Infof("worker [%s] format preset detected: %s", w.ID, preset)
+	}
+
+	w.formatName.Store(preset)
+
+	// keep the other settings of the format, e.g. the extract patterns.
+	w.Format = w.Format.Detected(preset)
+}
+
+// FormatName returns the name of the format preset, `auto` if it's detecting.
+func (w *Worker) FormatName() string {
+	if name, ok := w.formatName.Load().(string); ok {
+		return name
+	}
+
+	if format := w.Format; format != nil {
+		return format.Preset
+	}
+
+	return ""
+}
+
+// Buffered returns the size of the incomplete record in the buffer.
+func (w *Worker) Buffered() int {
+	w.bufMu.Lock()
+	defer w.bufMu.Unlock()
+
+	return len(w.buf)
+}
+
+// flushData builds a record of the data with the detected level, event time and extracted fields,
+// and passes it to the routers.
 func (w *Worker) flushData(data []byte) {
+	rec := record.New(w.Source, w.recordWorker(), data)
+	rec.Level = match.DetectLevel(w.Format, data).String()
+
+	if w.Format != nil {
+		if t, ok := w.Format.ParseTime(data); ok {
+			rec.Time = t
+		}
+
+		rec.Fields = w.Format.ExtractFields(data)
+	}
+
+	w.receive(rec)
+}
+
+// recordWorker returns the path of the followed file, or the worker id.
+func (w *Worker) recordWorker() string {
+	if w.Follower != nil {
+		return w.Follower.Path()
+	}
+
+	return w.ID
+}
+
+func (w *Worker) receive(rec *record.Record) {
 	for _, r := range w.Routers {
-		r.Receive(data)
+		r.Receive(rec)
 	}
 
 	if w.MergingWorker != nil {
-		w.MergingWorker.flushData(data)
+		w.MergingWorker.receive(rec)
 	}
 }
 
@@ -106,5 +280,11 @@ func (w *Worker) Stop() {
 		w.cmd = nil
 	}
 
+	w.stopFlushTimer()
+
+	if w.Follower != nil {
+		w.Follower.Close()
+	}
+
 	w.StopRouters()
 }
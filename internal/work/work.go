@@ -21,6 +21,7 @@ import (
 	"errors"
 	"os/exec"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vogo/logtail/internal/conf"
@@ -33,6 +34,12 @@ import (
 const (
 	// CommandFailRetryInterval command fail retry interval.
 	CommandFailRetryInterval = 10 * time.Second
+
+	// TruncatedMarker the marker appended to the truncated record.
+	TruncatedMarker = "...[truncated]"
+
+	// SplitMarker the marker appended to each part of the split record except the last one.
+	SplitMarker = "...[continued]"
 )
 
 var ErrWorkerCommandStopped = errors.New("worker command stopped")
@@ -43,9 +50,34 @@ type Worker struct {
 	ID      string
 	command string
 	cmd     *exec.Cmd
-	buf     []byte
 	Format  *match.Format
 
+	// bufMu protects the buffer of the incomplete record.
+	bufMu sync.Mutex
+	buf   []byte
+
+	// discarding whether discarding the rest of the truncated record.
+	discarding bool
+
+	// FlushTimeout flushes the incomplete record after the duration without new data, 0 means never.
+	FlushTimeout time.Duration
+	flushTimer   *time.Timer
+
+	// MaxRecordSize the max bytes of a record, the exceeded record is truncated, 0 means unlimited.
+	MaxRecordSize int
+
+	// SplitLargeRecord splits the record exceeding the max size into multiple records instead of truncating it.
+	SplitLargeRecord bool
+
+	// formatDetector detects the format preset if the preset of the format is auto.
+	formatDetector *match.FormatDetector
+
+	// formatName the name of the format preset for statistics.
+	formatName atomic.Value
+
+	// Follower follows a file instead of running a command if it's not nil.
+	Follower *FileFollower
+
 	Runner            *vrun.Runner
 	TransfersFunc     trans.TransferMatcher
 	MergingWorker     *Worker
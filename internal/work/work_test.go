@@ -20,8 +20,11 @@ package work_test
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vogo/logtail/internal/match"
+	"github.com/vogo/logtail/internal/record"
 	"github.com/vogo/logtail/internal/route"
 	"github.com/vogo/logtail/internal/work"
 	"github.com/vogo/vogo/vsync/vrun"
@@ -55,7 +58,7 @@ func TestWorkerWrite_SingleLine(t *testing.T) {
 		Runner:  runner.NewChild(),
 		ID:      "test-router",
 		Name:    "test-router",
-		Channel: make(chan []byte, 16),
+		Channel: make(chan *record.Record, 16),
 	}
 
 	w := work.NewRawWorker("w1", "echo", false)
@@ -68,8 +71,9 @@ func TestWorkerWrite_SingleLine(t *testing.T) {
 
 	// Read directly from channel — data should already be buffered
 	select {
-	case data := <-router.Channel:
-		assert.Equal(t, "hello\n", string(data))
+	case rec := <-router.Channel:
+		assert.Equal(t, "hello\n", string(rec.Data))
+		assert.Equal(t, "w1", rec.Worker)
 	default:
 		t.Fatal("expected data in router channel")
 	}
@@ -77,6 +81,229 @@ func TestWorkerWrite_SingleLine(t *testing.T) {
 	router.Stop()
 }
 
+func TestWorkerWrite_AutoFormat(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+
+	router := &route.Router{
+		Lock:    sync.Mutex{},
+		Runner:  runner.NewChild(),
+		ID:      "test-router",
+		Name:    "test-router",
+		Channel: make(chan *record.Record, 16),
+	}
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.Format = &match.Format{Preset: match.PresetAuto}
+	w.Routers["test-router"] = router
+
+	assert.Equal(t, match.PresetAuto, w.FormatName())
+
+	_, err := w.Write([]byte("I0115 10:30:45.123456 1 main.go:10] started\n" +
+		"E0115 10:30:45.456789 1 main.go:20] failed\n" +
+		"goroutine 1 [running]:\n" +
+		"W0115 10:30:46.000001 1 main.go:30] retry\n" +
+		"I0115 10:30:47.000001 1 main.go:40] done\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, match.PresetGlog, w.FormatName())
+
+	var records []string
+
+	for len(router.Channel) > 0 {
+		records = append(records, string((<-router.Channel).Data))
+	}
+
+	// the data is split by the detected format.
+	assert.Equal(t, []string{
+		"I0115 10:30:45.123456 1 main.go:10] started\n",
+		"E0115 10:30:45.456789 1 main.go:20] failed\ngoroutine 1 [running]:\n",
+		"W0115 10:30:46.000001 1 main.go:30] retry\n",
+		"I0115 10:30:47.000001 1 main.go:40] done\n",
+	}, records)
+
+	router.Stop()
+}
+
+func TestWorkerWrite_AutoFormatKeepsSettings(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.Format = &match.Format{
+		Preset:     match.PresetAuto,
+		LevelField: "severity",
+		Extract:    []string{`"msg":"(?P<msg>[^"]*)"`},
+	}
+	w.Routers["test-router"] = router
+
+	_, err := w.Write([]byte(
+		`{"time":"2024-01-15T10:30:45+08:00","level":"INFO","msg":"started","severity":"debug"}` + "\n" +
+			`{"time":"2024-01-15T10:30:46+08:00","level":"INFO","msg":"failed","severity":"error"}` + "\n" +
+			`{"time":"2024-01-15T10:30:47+08:00","level":"INFO","msg":"retry","severity":"warn"}` + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, match.PresetGoSlogJSON, w.FormatName())
+	assert.Equal(t, match.PresetGoSlogJSON, w.Format.Preset)
+
+	var levels, messages []string
+
+	for len(router.Channel) > 0 {
+		rec := <-router.Channel
+		levels = append(levels, rec.Level)
+		messages = append(messages, rec.Fields["msg"])
+	}
+
+	// the level field and the extract patterns are kept after the preset detected.
+	assert.Equal(t, []string{"debug", "error", "warn"}, levels)
+	assert.Equal(t, []string{"started", "failed", "retry"}, messages)
+
+	router.Stop()
+}
+
+// newChannelRouter returns a router buffering received records in the channel.
+func newChannelRouter(runner *vrun.Runner) *route.Router {
+	return &route.Router{
+		Lock:    sync.Mutex{},
+		Runner:  runner.NewChild(),
+		ID:      "test-router",
+		Name:    "test-router",
+		Channel: make(chan *record.Record, 16),
+	}
+}
+
+func receivedRecords(router *route.Router) []string {
+	var records []string
+
+	for len(router.Channel) > 0 {
+		records = append(records, string((<-router.Channel).Data))
+	}
+
+	return records
+}
+
+func TestWorkerWrite_FlushTimeout(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.FlushTimeout = 50 * time.Millisecond
+	w.Routers["test-router"] = router
+
+	_, err := w.Write([]byte("first\nERROR stack\n\tat Main.run"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first\n"}, receivedRecords(router))
+	assert.Equal(t, len("ERROR stack\n\tat Main.run"), w.Buffered())
+
+	// the incomplete record is flushed after the timeout.
+	select {
+	case rec := <-router.Channel:
+		assert.Equal(t, "ERROR stack\n\tat Main.run", string(rec.Data))
+	case <-time.After(time.Second):
+		t.Fatal("expected incomplete record flushed")
+	}
+
+	assert.Equal(t, 0, w.Buffered())
+
+	w.Stop()
+	router.Stop()
+}
+
+func TestWorkerWrite_MaxRecordSize(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.MaxRecordSize = 10
+	w.Routers["test-router"] = router
+
+	// the rest of the truncated record is discarded until the next record.
+	_, _ = w.Write([]byte("0123456789abcdef"))
+	_, _ = w.Write([]byte("ghijk\nnext\n"))
+	assert.Equal(t, []string{"0123456789" + work.TruncatedMarker + "\n", "next\n"}, receivedRecords(router))
+
+	// utf8 runes are not broken.
+	_, _ = w.Write([]byte("错误错误错误\n"))
+	assert.Equal(t, []string{"错误错" + work.TruncatedMarker + "\n"}, receivedRecords(router))
+
+	w.SplitLargeRecord = true
+	_, _ = w.Write([]byte("0123456789abcdefghij"))
+	_, _ = w.Write([]byte("klm\n"))
+	assert.Equal(t, []string{
+		"0123456789" + work.SplitMarker + "\n",
+		"abcdefghij" + work.SplitMarker + "\n",
+		"klm\n",
+	}, receivedRecords(router))
+
+	router.Stop()
+}
+
+func TestWorkerWrite_EventTime(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	merging := work.NewRawWorker("merging", "", false)
+	merging.Routers["test-router"] = router
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.Source = "app"
+	w.Format = &match.Format{Preset: match.PresetJavaLogback}
+	w.MergingWorker = merging
+
+	_, _ = w.Write([]byte("2024-01-15 10:30:45.123 ERROR failed\n\tat Main.run\n10:30:46.000 INFO ok\n"))
+
+	// the merging worker receives the records of the worker.
+	first := <-router.Channel
+	assert.Equal(t, "app", first.Source)
+	assert.Equal(t, "w1", first.Worker)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.Local), first.Time)
+	assert.Equal(t, first.Time, first.EventTime())
+	assert.Equal(t, "error", first.Level)
+
+	// the arrival time is used if no event time parsed.
+	second := <-router.Channel
+	assert.Equal(t, "10:30:46.000 INFO ok\n", string(second.Data))
+	assert.Equal(t, "info", second.Level)
+	assert.True(t, second.Time.IsZero())
+	assert.Equal(t, second.Arrival, second.EventTime())
+
+	router.Stop()
+}
+
+func TestWorkerWrite_ExtractFields(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	router := newChannelRouter(runner)
+
+	w := work.NewRawWorker("w1", "echo", false)
+	w.Runner = runner
+	w.Format = &match.Format{Extract: []string{`order=(?P<order>\d+) cost=%{DURATION:cost}`}}
+	w.Routers["test-router"] = router
+
+	_, _ = w.Write([]byte("pay failed order=1024 cost=15ms\nstarted\n"))
+
+	first := <-router.Channel
+	assert.Equal(t, map[string]string{"order": "1024", "cost": "15ms"}, first.Fields)
+
+	second := <-router.Channel
+	assert.Nil(t, second.Fields)
+
+	router.Stop()
+}
+
 func TestWorkerStopRouters(t *testing.T) {
 	t.Parallel()
 
@@ -87,7 +314,7 @@ func TestWorkerStopRouters(t *testing.T) {
 		Runner:  runner.NewChild(),
 		ID:      "r1",
 		Name:    "r1",
-		Channel: make(chan []byte, 1),
+		Channel: make(chan *record.Record, 1),
 	}
 
 	w := work.NewRawWorker("w1", "echo", false)
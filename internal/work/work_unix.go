@@ -20,6 +20,7 @@
 package work
 
 import (
+	"os"
 	"os/exec"
 	"syscall"
 )
@@ -35,3 +36,13 @@ func KillCmd(cmd *exec.Cmd) error {
 
 	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 }
+
+// fileIdentity returns the device and inode of the file.
+func fileIdentity(info os.FileInfo) (uint64, uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		//nolint:unconvert // the types of dev and ino differ on platforms.
+		return uint64(stat.Dev), uint64(stat.Ino)
+	}
+
+	return 0, 0
+}
@@ -17,7 +17,10 @@
 
 package work
 
-import "os/exec"
+import (
+	"os"
+	"os/exec"
+)
 
 func SetCmdSysProcAttr(cmd *exec.Cmd) {
 }
@@ -29,3 +32,8 @@ func KillCmd(cmd *exec.Cmd) error {
 
 	return cmd.Process.Kill()
 }
+
+// fileIdentity returns zero identity for the file index is not available in the file info.
+func fileIdentity(_ os.FileInfo) (uint64, uint64) {
+	return 0, 0
+}